@@ -0,0 +1,47 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types reported by Frigates
+const (
+	// ConditionReady is True once the Frigate is Completed
+	ConditionReady = "Ready"
+	// ConditionProgressing is True while the Frigate is being worked on
+	ConditionProgressing = "Progressing"
+	// ConditionDegraded is True when the Frigate failed or a reconcile step failed
+	ConditionDegraded = "Degraded"
+	// ConditionTransitionVetoed is True while a guard refuses the next phase
+	ConditionTransitionVetoed = "TransitionVetoed"
+)
+
+// ConditionStatus is True, False or Unknown
+type ConditionStatus string
+
+// These are valid condition statuses
+const (
+	ConditionTrue    ConditionStatus = "True"
+	ConditionFalse   ConditionStatus = "False"
+	ConditionUnknown ConditionStatus = "Unknown"
+)
+
+// Condition mirrors metav1.Condition, which is not available in the
+// apimachinery version this project builds with. Field names and json
+// tags match so switching later does not change the API
+type Condition struct {
+	// Type of condition in CamelCase
+	Type string `json:"type"`
+	// Status of the condition, one of True, False, Unknown
+	// +kubebuilder:validation:Enum=True;False;Unknown
+	Status ConditionStatus `json:"status"`
+	// ObservedGeneration is the metadata.generation the condition was set for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the condition changed status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Reason for the last transition in CamelCase
+	Reason string `json:"reason"`
+	// Message is a human readable description of the transition
+	Message string `json:"message"`
+}
@@ -0,0 +1,5 @@
+package v1
+
+// Hub marks v1 as the version all other Frigate versions convert to.
+// Objects are still stored as v1beta1 so existing ones keep working
+func (*Frigate) Hub() {}
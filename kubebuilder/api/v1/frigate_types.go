@@ -0,0 +1,268 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FrigateSpec defines the desired state of Frigate
+type FrigateSpec struct {
+	// Callsign identifies the Frigate on the radio, it was named foo in v1beta1
+	// +optional
+	Callsign string `json:"callsign,omitempty"`
+
+	// Replicas is the desired number of crew replicas, defaults to 1
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// FrigateClassName is the ShipClass providing defaults for the Frigate,
+	// like storageClassName for PersistentVolumeClaims. Frigates of a class
+	// handled by another controller are ignored. Cannot be changed after
+	// creation
+	// +optional
+	FrigateClassName string `json:"frigateClassName,omitempty"`
+
+	// HarborRef is the Harbor in the same namespace the Frigate docks at.
+	// The Frigate is only Completed once the Harbor is Ready. Cannot be
+	// changed after creation
+	// +optional
+	HarborRef *HarborReference `json:"harborRef,omitempty"`
+
+	// CargoTemplate describes the Pods run by the Frigate, spec.replicas
+	// of them are created and replaced whenever the template changes
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	CargoTemplate *corev1.PodTemplateSpec `json:"cargoTemplate,omitempty"`
+
+	// ConfigRef is a ConfigMap or Secret in the same namespace exposed to
+	// the cargo Pods as environment variables. Pods are replaced when its
+	// data changes
+	// +optional
+	ConfigRef *ConfigReference `json:"configRef,omitempty"`
+
+	// Expose creates a Service for the cargo Pods
+	// +optional
+	Expose *ExposeSpec `json:"expose,omitempty"`
+
+	// Suspend stops the controller from changing the Frigate, only the
+	// Suspended condition is updated. Deletions are still handled
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// TTLSecondsAfterFinished deletes the Frigate once it has been
+	// Completed or Failure for that many seconds, like Jobs. Frigates
+	// are kept when unset
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+// HarborReference references a Harbor in the same namespace
+type HarborReference struct {
+	// Name of the Harbor
+	Name string `json:"name"`
+}
+
+// ConfigReference references a ConfigMap or Secret in the same namespace
+type ConfigReference struct {
+	// Kind of the referenced object
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+	// Name of the referenced object
+	Name string `json:"name"`
+}
+
+// ExposeSpec describes the Service exposing the cargo Pods
+type ExposeSpec struct {
+	// Type of the Service, defaults to ClusterIP
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Ports of the Service
+	// +kubebuilder:validation:MinItems=1
+	Ports []ExposePort `json:"ports"`
+	// LoadBalancerSourceRanges are the CIDRs allowed to reach a
+	// LoadBalancer Service, i.e. 203.0.113.0/24. Every client is
+	// allowed when empty, only valid for the LoadBalancer type
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// ExposePort is a port of the Service
+type ExposePort struct {
+	// Name of the port, required when there are several ports
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Port exposed by the Service
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+	// TargetPort is the port of the Pods, defaults to port
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	TargetPort int32 `json:"targetPort,omitempty"`
+}
+
+// FrigatePhase is the lifecycle phase of a Frigate
+// +kubebuilder:validation:Enum=Pending;Running;Completed;Failure
+type FrigatePhase string
+
+// These are valid Frigate phases
+const (
+	// FrigatePending is a Frigate not handled by the controller yet
+	FrigatePending FrigatePhase = "Pending"
+	// FrigateRunning is a Frigate being worked on
+	FrigateRunning FrigatePhase = "Running"
+	// FrigateCompleted is a Frigate ready to sail
+	FrigateCompleted FrigatePhase = "Completed"
+	// FrigateFailure is a Frigate that failed
+	FrigateFailure FrigatePhase = "Failure"
+)
+
+// Reasons set in status when a Frigate fails
+const (
+	// ReasonStepFailed is set when a reconcile step returned an error
+	ReasonStepFailed = "StepFailed"
+	// ReasonNameReserved is set for Frigates using a reserved name
+	ReasonNameReserved = "NameReserved"
+)
+
+// FrigateStatus defines the observed state of Frigate
+type FrigateStatus struct {
+	// Phase of the Frigate, Completed or Failure
+	// +optional
+	Phase FrigatePhase `json:"phase,omitempty"`
+
+	// Reason is a machine readable code explaining the phase, set on failures
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Replicas is the number of replicas currently running
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// ReadyReplicas is the number of ready Pods reported by the cargo
+	// Deployment
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Selector is the label selector of the replicas, used by the scale subresource
+	// +optional
+	Selector string `json:"selector,omitempty"`
+	// TemplateHash identifies the cargo template the Pods are created from
+	// +optional
+	TemplateHash string `json:"templateHash,omitempty"`
+	// ConfigHash identifies the data of spec.configRef the Pods are created with
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+	// ClassHash identifies the ShipClass defaults the Pods are created with
+	// +optional
+	ClassHash string `json:"classHash,omitempty"`
+	// ServiceName is the Service created for spec.expose
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// ClusterIP is the cluster IP allocated to the Service of spec.expose
+	// +optional
+	ClusterIP string `json:"clusterIP,omitempty"`
+	// ExternalAddress is the IP or hostname of the load balancer once
+	// provisioned for a LoadBalancer Service
+	// +optional
+	ExternalAddress string `json:"externalAddress,omitempty"`
+
+	// Conditions of the Frigate: Ready, Progressing and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Children reports the Pods of the Frigate, the ones not ready first.
+	// The list is capped, only the first entries are kept
+	// +optional
+	Children []ChildStatus `json:"children,omitempty"`
+
+	// Checkpoint records the progress of the last reconcile
+	// +optional
+	Checkpoint *StepCheckpoint `json:"checkpoint,omitempty"`
+
+	// FirstReconciledAt is when the Frigate was first reconciled successfully
+	// +optional
+	FirstReconciledAt *metav1.Time `json:"firstReconciledAt,omitempty"`
+	// FirstReadyAt is when the Frigate first reached the Completed phase
+	// +optional
+	FirstReadyAt *metav1.Time `json:"firstReadyAt,omitempty"`
+
+	// StartTime is when the Frigate entered its first phase
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// LastTransitionTime is when the phase last changed
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ChildStatus is the rolled up status of a Pod of the Frigate
+type ChildStatus struct {
+	// Name of the Pod
+	Name string `json:"name"`
+	// Ready is true when the Pod is ready
+	Ready bool `json:"ready"`
+	// Reason of the last container termination or of a waiting container
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// StepCheckpoint records which reconcile steps completed so a failed
+// reconcile can be reported and retried from the failed step
+type StepCheckpoint struct {
+	// SpecHash identifies the Frigate spec the steps ran for. The generation
+	// cannot be used while status is written together with the spec
+	SpecHash string `json:"specHash"`
+	// Completed is the last step that finished successfully
+	// +optional
+	Completed string `json:"completed,omitempty"`
+	// Failed is the step that returned an error, empty when every step finished
+	// +optional
+	Failed string `json:"failed,omitempty"`
+	// Message is the error returned by the failed step
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.selector
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Callsign",type="string",JSONPath=".spec.callsign"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.reason"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Frigate is the Schema for the frigates API
+type Frigate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FrigateSpec   `json:"spec,omitempty"`
+	Status FrigateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrigateList contains a list of Frigate
+type FrigateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Frigate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Frigate{}, &FrigateList{})
+}
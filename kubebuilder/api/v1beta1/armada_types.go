@@ -0,0 +1,91 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ArmadaPriorityAnnotation orders the Frigates waiting to run in an
+// Armada, higher values run first. Frigates without it have priority 0
+const ArmadaPriorityAnnotation = "ship.danielfbm.github.io/armada-priority"
+
+// ReasonArmadaFull is the veto reason of Frigates waiting for a
+// running slot of an Armada
+const ReasonArmadaFull = "ArmadaFull"
+
+// ArmadaSpec defines the desired state of Armada
+type ArmadaSpec struct {
+	// Selector matches the member Frigates
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// NamespaceSelector restricts the members to the namespaces it
+	// matches, Frigates of every namespace are members if unset
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// MaxRunning is the number of members allowed past the Pending phase
+	// at the same time, members wait in Pending for a slot ordered by
+	// the ship.danielfbm.github.io/armada-priority annotation, then by
+	// age. Unlimited if unset
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRunning *int32 `json:"maxRunning,omitempty"`
+}
+
+// ArmadaStatus defines the observed state of Armada
+type ArmadaStatus struct {
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Total number of member Frigates
+	// +optional
+	Total int32 `json:"total,omitempty"`
+	// Running is the number of members in the Running or Completed phase
+	// +optional
+	Running int32 `json:"running,omitempty"`
+
+	// Admitted lists the members, as namespace/name, allowed past the
+	// Pending phase. Only set when spec.maxRunning is
+	// +optional
+	// +listType=set
+	Admitted []string `json:"admitted,omitempty"`
+
+	// Conditions of the Armada, Ready is False while the selectors are
+	// invalid
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+// +kubebuilder:printcolumn:name="Running",type="integer",JSONPath=".status.running"
+// +kubebuilder:printcolumn:name="Max",type="integer",JSONPath=".spec.maxRunning"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Armada selects Frigates across namespaces and limits how many of
+// them run at the same time
+type Armada struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ArmadaSpec   `json:"spec,omitempty"`
+	Status ArmadaStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ArmadaList contains a list of Armada
+type ArmadaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Armada `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Armada{}, &ArmadaList{})
+}
@@ -0,0 +1,135 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultBackoffLimit is the number of retries of a CruiseMission
+// without spec.backoffLimit, the same as Jobs
+const DefaultBackoffLimit = 6
+
+// CruiseMissionSpec defines the workload of a CruiseMission
+type CruiseMissionSpec struct {
+	// Template describes the Pod running the mission. A new Pod is
+	// created for every attempt, its restartPolicy is always Never
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Template corev1.PodTemplateSpec `json:"template"`
+
+	// BackoffLimit is the number of failed attempts retried before the
+	// mission fails, defaults to 6
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds fails the mission and stops its Pod once it
+	// has been running for that many seconds, retries included
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// CruiseMissionPhase is the lifecycle phase of a CruiseMission
+// +kubebuilder:validation:Enum=Pending;Running;Completed;Failure
+type CruiseMissionPhase string
+
+// These are valid CruiseMission phases
+const (
+	// CruiseMissionPending is a mission waiting to launch its next attempt
+	CruiseMissionPending CruiseMissionPhase = "Pending"
+	// CruiseMissionRunning is a mission with a running Pod
+	CruiseMissionRunning CruiseMissionPhase = "Running"
+	// CruiseMissionCompleted is a mission whose Pod succeeded
+	CruiseMissionCompleted CruiseMissionPhase = "Completed"
+	// CruiseMissionFailure is a mission out of retries or time
+	CruiseMissionFailure CruiseMissionPhase = "Failure"
+)
+
+// Reasons set in status when a CruiseMission fails
+const (
+	// ReasonBackoffLimitExceeded is set once more attempts than
+	// spec.backoffLimit failed
+	ReasonBackoffLimitExceeded = "BackoffLimitExceeded"
+	// ReasonDeadlineExceeded is set once spec.activeDeadlineSeconds passed
+	ReasonDeadlineExceeded = "DeadlineExceeded"
+)
+
+// CruiseMissionStatus defines the observed state of CruiseMission
+type CruiseMissionStatus struct {
+	// Phase of the mission
+	// +optional
+	Phase CruiseMissionPhase `json:"phase,omitempty"`
+	// Reason is a machine readable code explaining the phase, set on failures
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Active is the number of running Pods
+	// +optional
+	Active int32 `json:"active,omitempty"`
+	// Succeeded is the number of Pods that succeeded
+	// +optional
+	Succeeded int32 `json:"succeeded,omitempty"`
+	// Failed is the number of Pods that failed
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// StartTime is when the first attempt was launched
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// LastFailureTime is when the last failed attempt was seen, the
+	// next one is launched after an exponential backoff
+	// +optional
+	LastFailureTime *metav1.Time `json:"lastFailureTime,omitempty"`
+	// CompletionTime is when the mission Completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Conditions of the mission: Ready, Progressing and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Checkpoint records the progress of the last reconcile
+	// +optional
+	Checkpoint *StepCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed"
+// +kubebuilder:printcolumn:name="Completion",type="date",JSONPath=".status.completionTime"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.reason"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// CruiseMission runs a Pod to completion, retrying failed attempts
+// like a Job. Frigates are long running, missions finish
+type CruiseMission struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   CruiseMissionSpec   `json:"spec,omitempty"`
+	Status CruiseMissionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// CruiseMissionList contains a list of CruiseMission
+type CruiseMissionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CruiseMission `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CruiseMission{}, &CruiseMissionList{})
+}
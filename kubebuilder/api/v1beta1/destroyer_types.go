@@ -0,0 +1,91 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DestroyerSpec defines the desired state of Destroyer
+type DestroyerSpec struct {
+	// Escort is the name of a Frigate in the same namespace the Destroyer
+	// escorts. The Destroyer is only Completed once the Frigate is Completed
+	// +optional
+	Escort string `json:"escort,omitempty"`
+}
+
+// DestroyerPhase is the lifecycle phase of a Destroyer
+// +kubebuilder:validation:Enum=Pending;Running;Completed;Failure
+type DestroyerPhase string
+
+// These are valid Destroyer phases
+const (
+	// DestroyerPending is a Destroyer waiting for its escorted Frigate
+	DestroyerPending DestroyerPhase = "Pending"
+	// DestroyerRunning is a Destroyer being worked on
+	DestroyerRunning DestroyerPhase = "Running"
+	// DestroyerCompleted is a ready Destroyer
+	DestroyerCompleted DestroyerPhase = "Completed"
+	// DestroyerFailure is a Destroyer that cannot become ready
+	DestroyerFailure DestroyerPhase = "Failure"
+)
+
+// ReasonEscortNotFound is set when the escorted Frigate does not exist
+const ReasonEscortNotFound = "EscortNotFound"
+
+// DestroyerStatus defines the observed state of Destroyer
+type DestroyerStatus struct {
+	// Phase of the Destroyer
+	// +optional
+	Phase DestroyerPhase `json:"phase,omitempty"`
+	// Reason is a machine readable code explaining the phase, set on failures
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions of the Destroyer: Ready, Progressing and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Checkpoint records the progress of the last reconcile
+	// +optional
+	Checkpoint *StepCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Escort",type="string",JSONPath=".spec.escort"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.reason"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Destroyer is the Schema for the destroyers API
+type Destroyer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DestroyerSpec   `json:"spec,omitempty"`
+	Status DestroyerStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// DestroyerList contains a list of Destroyer
+type DestroyerList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Destroyer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Destroyer{}, &DestroyerList{})
+}
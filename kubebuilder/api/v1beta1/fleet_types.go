@@ -0,0 +1,64 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FleetSpec defines the desired state of Fleet
+type FleetSpec struct {
+	// Selector matches the member Frigates in the namespace of the Fleet
+	Selector *metav1.LabelSelector `json:"selector"`
+}
+
+// FleetStatus defines the observed state of Fleet
+type FleetStatus struct {
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Total number of member Frigates
+	// +optional
+	Total int32 `json:"total,omitempty"`
+	// Ready is the number of member Frigates in the Completed phase
+	// +optional
+	Ready int32 `json:"ready,omitempty"`
+	// Failed is the number of member Frigates in the Failure phase
+	// +optional
+	Failed int32 `json:"failed,omitempty"`
+
+	// Conditions of the Fleet, Ready is True when every member is ready
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Total",type="integer",JSONPath=".status.total"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.ready"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Fleet groups Frigates selected by label and rolls up their status
+type Fleet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetSpec   `json:"spec,omitempty"`
+	Status FleetStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FleetList contains a list of Fleet
+type FleetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Fleet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Fleet{}, &FleetList{})
+}
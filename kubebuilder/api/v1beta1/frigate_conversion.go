@@ -0,0 +1,174 @@
+package v1beta1
+
+import (
+	shipv1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &Frigate{}
+
+// ConvertTo converts this Frigate to the Hub version (v1)
+func (src *Frigate) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*shipv1.Frigate)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.Callsign = src.Spec.Foo
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.FrigateClassName = src.Spec.FrigateClassName
+	dst.Spec.HarborRef = nil
+	if src.Spec.HarborRef != nil {
+		dst.Spec.HarborRef = &shipv1.HarborReference{Name: src.Spec.HarborRef.Name}
+	}
+	dst.Spec.CargoTemplate = src.Spec.CargoTemplate.DeepCopy()
+	dst.Spec.ConfigRef = nil
+	if src.Spec.ConfigRef != nil {
+		dst.Spec.ConfigRef = &shipv1.ConfigReference{Kind: src.Spec.ConfigRef.Kind, Name: src.Spec.ConfigRef.Name}
+	}
+	dst.Spec.Expose = nil
+	if expose := src.Spec.Expose; expose != nil {
+		dst.Spec.Expose = &shipv1.ExposeSpec{
+			Type: expose.Type,
+		}
+		if expose.LoadBalancerSourceRanges != nil {
+			dst.Spec.Expose.LoadBalancerSourceRanges = append([]string{}, expose.LoadBalancerSourceRanges...)
+		}
+		if expose.Ports != nil {
+			dst.Spec.Expose.Ports = make([]shipv1.ExposePort, 0, len(expose.Ports))
+		}
+		for _, port := range expose.Ports {
+			dst.Spec.Expose.Ports = append(dst.Spec.Expose.Ports, shipv1.ExposePort(port))
+		}
+	}
+	dst.Spec.Suspend = src.Spec.Suspend
+	dst.Spec.TTLSecondsAfterFinished = src.Spec.TTLSecondsAfterFinished
+	dst.Status.Phase = shipv1.FrigatePhase(src.Status.Phase)
+	dst.Status.Reason = src.Status.Reason
+	dst.Status.Message = src.Status.Message
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Replicas = src.Status.Replicas
+	dst.Status.ReadyReplicas = src.Status.ReadyReplicas
+	dst.Status.Selector = src.Status.Selector
+	dst.Status.TemplateHash = src.Status.TemplateHash
+	dst.Status.ConfigHash = src.Status.ConfigHash
+	dst.Status.ClassHash = src.Status.ClassHash
+	dst.Status.ServiceName = src.Status.ServiceName
+	dst.Status.ClusterIP = src.Status.ClusterIP
+	dst.Status.ExternalAddress = src.Status.ExternalAddress
+	dst.Status.Conditions = nil
+	if src.Status.Conditions != nil {
+		dst.Status.Conditions = make([]shipv1.Condition, 0, len(src.Status.Conditions))
+	}
+	for _, condition := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, shipv1.Condition{
+			Type:               condition.Type,
+			Status:             shipv1.ConditionStatus(condition.Status),
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	dst.Status.FirstReconciledAt = src.Status.FirstReconciledAt
+	dst.Status.FirstReadyAt = src.Status.FirstReadyAt
+	dst.Status.StartTime = src.Status.StartTime
+	dst.Status.LastTransitionTime = src.Status.LastTransitionTime
+	dst.Status.Children = nil
+	if src.Status.Children != nil {
+		dst.Status.Children = make([]shipv1.ChildStatus, 0, len(src.Status.Children))
+	}
+	for _, child := range src.Status.Children {
+		dst.Status.Children = append(dst.Status.Children, shipv1.ChildStatus(child))
+	}
+	dst.Status.Checkpoint = nil
+	if checkpoint := src.Status.Checkpoint; checkpoint != nil {
+		dst.Status.Checkpoint = &shipv1.StepCheckpoint{
+			SpecHash:  checkpoint.SpecHash,
+			Completed: checkpoint.Completed,
+			Failed:    checkpoint.Failed,
+			Message:   checkpoint.Message,
+		}
+	}
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version
+func (dst *Frigate) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*shipv1.Frigate)
+	dst.ObjectMeta = src.ObjectMeta
+	dst.Spec.Foo = src.Spec.Callsign
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.FrigateClassName = src.Spec.FrigateClassName
+	dst.Spec.HarborRef = nil
+	if src.Spec.HarborRef != nil {
+		dst.Spec.HarborRef = &HarborReference{Name: src.Spec.HarborRef.Name}
+	}
+	dst.Spec.CargoTemplate = src.Spec.CargoTemplate.DeepCopy()
+	dst.Spec.ConfigRef = nil
+	if src.Spec.ConfigRef != nil {
+		dst.Spec.ConfigRef = &ConfigReference{Kind: src.Spec.ConfigRef.Kind, Name: src.Spec.ConfigRef.Name}
+	}
+	dst.Spec.Expose = nil
+	if expose := src.Spec.Expose; expose != nil {
+		dst.Spec.Expose = &ExposeSpec{
+			Type: expose.Type,
+		}
+		if expose.LoadBalancerSourceRanges != nil {
+			dst.Spec.Expose.LoadBalancerSourceRanges = append([]string{}, expose.LoadBalancerSourceRanges...)
+		}
+		if expose.Ports != nil {
+			dst.Spec.Expose.Ports = make([]ExposePort, 0, len(expose.Ports))
+		}
+		for _, port := range expose.Ports {
+			dst.Spec.Expose.Ports = append(dst.Spec.Expose.Ports, ExposePort(port))
+		}
+	}
+	dst.Spec.Suspend = src.Spec.Suspend
+	dst.Spec.TTLSecondsAfterFinished = src.Spec.TTLSecondsAfterFinished
+	dst.Status.Phase = FrigatePhase(src.Status.Phase)
+	dst.Status.Reason = src.Status.Reason
+	dst.Status.Message = src.Status.Message
+	dst.Status.ObservedGeneration = src.Status.ObservedGeneration
+	dst.Status.Replicas = src.Status.Replicas
+	dst.Status.ReadyReplicas = src.Status.ReadyReplicas
+	dst.Status.Selector = src.Status.Selector
+	dst.Status.TemplateHash = src.Status.TemplateHash
+	dst.Status.ConfigHash = src.Status.ConfigHash
+	dst.Status.ClassHash = src.Status.ClassHash
+	dst.Status.ServiceName = src.Status.ServiceName
+	dst.Status.ClusterIP = src.Status.ClusterIP
+	dst.Status.ExternalAddress = src.Status.ExternalAddress
+	dst.Status.Conditions = nil
+	if src.Status.Conditions != nil {
+		dst.Status.Conditions = make([]Condition, 0, len(src.Status.Conditions))
+	}
+	for _, condition := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, Condition{
+			Type:               condition.Type,
+			Status:             ConditionStatus(condition.Status),
+			ObservedGeneration: condition.ObservedGeneration,
+			LastTransitionTime: condition.LastTransitionTime,
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+		})
+	}
+	dst.Status.FirstReconciledAt = src.Status.FirstReconciledAt
+	dst.Status.FirstReadyAt = src.Status.FirstReadyAt
+	dst.Status.StartTime = src.Status.StartTime
+	dst.Status.LastTransitionTime = src.Status.LastTransitionTime
+	dst.Status.Children = nil
+	if src.Status.Children != nil {
+		dst.Status.Children = make([]ChildStatus, 0, len(src.Status.Children))
+	}
+	for _, child := range src.Status.Children {
+		dst.Status.Children = append(dst.Status.Children, ChildStatus(child))
+	}
+	dst.Status.Checkpoint = nil
+	if checkpoint := src.Status.Checkpoint; checkpoint != nil {
+		dst.Status.Checkpoint = &StepCheckpoint{
+			SpecHash:  checkpoint.SpecHash,
+			Completed: checkpoint.Completed,
+			Failed:    checkpoint.Failed,
+			Message:   checkpoint.Message,
+		}
+	}
+	return nil
+}
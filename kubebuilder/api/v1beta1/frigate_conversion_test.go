@@ -0,0 +1,146 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	shipv1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1"
+	fuzz "github.com/google/gofuzz"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestFrigateConversion(t *testing.T) {
+	ttl := int32(60)
+	original := &Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", Labels: map[string]string{"fleet": "north"}},
+		Spec: FrigateSpec{
+			Foo:              "bar",
+			FrigateClassName: "fast",
+			HarborRef:        &HarborReference{Name: "north"},
+			ConfigRef:        &ConfigReference{Kind: "Secret", Name: "creds"},
+			Expose: &ExposeSpec{
+				Type:                     corev1.ServiceTypeLoadBalancer,
+				Ports:                    []ExposePort{{Name: "http", Port: 80, TargetPort: 8080}},
+				LoadBalancerSourceRanges: []string{"203.0.113.0/24"},
+			},
+			Suspend:                 true,
+			TTLSecondsAfterFinished: &ttl,
+		},
+		Status: FrigateStatus{
+			Phase:           FrigateCompleted,
+			Checkpoint:      &StepCheckpoint{SpecHash: "abc", Completed: "phase"},
+			ConfigHash:      "cafe",
+			ClassHash:       "beef",
+			ServiceName:     "some",
+			ExternalAddress: "203.0.113.7",
+		},
+	}
+
+	hub := &shipv1.Frigate{}
+	if err := original.DeepCopy().ConvertTo(hub); err != nil {
+		t.Fatalf("should convert to v1: %v", err)
+	}
+	if hub.Spec.Callsign != "bar" || hub.Name != "some" || hub.Status.Checkpoint.Completed != "phase" || hub.Spec.HarborRef.Name != "north" || !hub.Spec.Suspend || *hub.Spec.TTLSecondsAfterFinished != 60 ||
+		hub.Spec.ConfigRef.Name != "creds" || hub.Status.ConfigHash != "cafe" ||
+		hub.Spec.FrigateClassName != "fast" || hub.Status.ClassHash != "beef" ||
+		hub.Spec.Expose.Ports[0].TargetPort != 8080 || hub.Status.ExternalAddress != "203.0.113.7" {
+		t.Errorf("unexpected v1 frigate %+v", hub)
+	}
+
+	back := &Frigate{}
+	if err := back.ConvertFrom(hub); err != nil {
+		t.Fatalf("should convert from v1: %v", err)
+	}
+	if !reflect.DeepEqual(back, original) {
+		t.Errorf("round trip changed the frigate\nexpected %+v\ngot      %+v", original, back)
+	}
+}
+
+// roundTrips is the number of fuzzed Frigates converted in each direction
+const roundTrips = 200
+
+// frigateFuzzerFuncs keep the quantities and ports of the fuzzed cargo
+// template valid, random ones cannot be encoded to JSON
+func frigateFuzzerFuncs(codecs serializer.CodecFactory) []interface{} {
+	return []interface{}{
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1000), resource.DecimalExponent)
+		},
+		func(i *intstr.IntOrString, c fuzz.Continue) {
+			if c.RandBool() {
+				*i = intstr.FromInt(c.Intn(65536))
+			} else {
+				*i = intstr.FromString(c.RandString())
+			}
+		},
+	}
+}
+
+func TestFrigateConversionRoundTripFuzz(t *testing.T) {
+	scheme := runtime.NewScheme()
+	AddToScheme(scheme)
+	shipv1.AddToScheme(scheme)
+	seed := time.Now().UnixNano()
+	t.Logf("fuzzing with seed %d", seed)
+	f := fuzzer.FuzzerFor(fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, frigateFuzzerFuncs), rand.NewSource(seed), serializer.NewCodecFactory(scheme))
+
+	for i := 0; i < roundTrips; i++ {
+		// 1. v1beta1 -> v1 -> v1beta1
+		original := &Frigate{}
+		f.Fuzz(original)
+		hub := &shipv1.Frigate{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("should convert to v1: %v", err)
+		}
+		back := &Frigate{}
+		if err := back.ConvertFrom(hub); err != nil {
+			t.Fatalf("should convert from v1: %v", err)
+		}
+		assertRoundTrip(t, original, back)
+
+		// 2. v1 -> v1beta1 -> v1
+		originalHub := &shipv1.Frigate{}
+		f.Fuzz(originalHub)
+		spoke := &Frigate{}
+		if err := spoke.ConvertFrom(originalHub.DeepCopy()); err != nil {
+			t.Fatalf("should convert from v1: %v", err)
+		}
+		backHub := &shipv1.Frigate{}
+		if err := spoke.ConvertTo(backHub); err != nil {
+			t.Fatalf("should convert to v1: %v", err)
+		}
+		assertRoundTrip(t, originalHub, backHub)
+	}
+}
+
+// assertRoundTrip fails when the converted object differs from the
+// original, in memory or as stored by the API server
+func assertRoundTrip(t *testing.T, original, back runtime.Object) {
+	t.Helper()
+	if !apiequality.Semantic.DeepEqual(original, back) {
+		t.Fatalf("round trip changed the frigate: %s", diff.ObjectReflectDiff(original, back))
+	}
+	originalJSON, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("should marshal: %v", err)
+	}
+	backJSON, err := json.Marshal(back)
+	if err != nil {
+		t.Fatalf("should marshal: %v", err)
+	}
+	if string(originalJSON) != string(backJSON) {
+		t.Fatalf("round trip changed the stored frigate\nexpected %s\ngot      %s", originalJSON, backJSON)
+	}
+}
@@ -0,0 +1,97 @@
+package v1beta1
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateSpec checks the rules spanning several fields of the spec.
+//
+// They are written as the x-kubernetes-validations rules they should
+// become, which need apiextensions.k8s.io/v1 CRDs and a newer
+// controller-gen. Until then the strict webhook enforces them:
+//
+//	(has(self.replicas) && self.replicas == 0) || self.foo != ''
+//	!has(self.configRef) || has(self.cargoTemplate)
+//	!has(self.expose.loadBalancerSourceRanges) || self.expose.type == 'LoadBalancer'
+//	self.expose.ports.size() == 1 || self.expose.ports.all(p, p.name != '')
+func (r *Frigate) ValidateSpec() field.ErrorList {
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	// replicas defaults to 1
+	if (r.Spec.Replicas == nil || *r.Spec.Replicas > 0) && r.Spec.Foo == "" {
+		errs = append(errs, field.Required(spec.Child("foo"), "foo is required unless replicas is 0"))
+	}
+	if r.Spec.ConfigRef != nil && r.Spec.CargoTemplate == nil {
+		errs = append(errs, field.Forbidden(spec.Child("configRef"), "configRef is only loaded into the Pods of cargoTemplate"))
+	}
+	if r.Spec.Expose != nil {
+		errs = append(errs, validateExpose(spec.Child("expose"), r.Spec.Expose)...)
+	}
+	return errs
+}
+
+// ValidateUpdate rejects changes to the fields that cannot change after
+// creation: moving a Frigate to another class or Harbor means a new
+// Frigate. As transition rules:
+//
+//	self.frigateClassName == oldSelf.frigateClassName
+//	self.harborRef == oldSelf.harborRef
+func (r *Frigate) ValidateUpdate(old runtime.Object) field.ErrorList {
+	oldFrigate, ok := old.(*Frigate)
+	if !ok {
+		return nil
+	}
+	var errs field.ErrorList
+	spec := field.NewPath("spec")
+	if r.Spec.FrigateClassName != oldFrigate.Spec.FrigateClassName {
+		errs = append(errs, field.Invalid(spec.Child("frigateClassName"), r.Spec.FrigateClassName,
+			fmt.Sprintf("field is immutable, was %q", oldFrigate.Spec.FrigateClassName)))
+	}
+	if !reflect.DeepEqual(r.Spec.HarborRef, oldFrigate.Spec.HarborRef) {
+		errs = append(errs, field.Forbidden(spec.Child("harborRef"), "field is immutable, recreate the Frigate to dock at another Harbor"))
+	}
+	return errs
+}
+
+// Warnings returns the deprecation notices of the fields set that were
+// renamed in v1, they keep working in v1beta1
+func (r *Frigate) Warnings() []string {
+	var warnings []string
+	if r.Spec.Foo != "" {
+		warnings = append(warnings, "spec.foo is deprecated, use spec.callsign of ship.danielfbm.github.io/v1")
+	}
+	return warnings
+}
+
+// validateExpose checks the source ranges are CIDRs of a LoadBalancer
+// and the ports can be told apart
+func validateExpose(path *field.Path, expose *ExposeSpec) field.ErrorList {
+	var errs field.ErrorList
+	ranges := path.Child("loadBalancerSourceRanges")
+	if len(expose.LoadBalancerSourceRanges) > 0 && expose.Type != corev1.ServiceTypeLoadBalancer {
+		errs = append(errs, field.Forbidden(ranges, "only allowed for the LoadBalancer type"))
+	}
+	for i, cidr := range expose.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			errs = append(errs, field.Invalid(ranges.Index(i), cidr, "must be a CIDR, i.e. 203.0.113.0/24"))
+		}
+	}
+	names := map[string]bool{}
+	for i, port := range expose.Ports {
+		name := path.Child("ports").Index(i).Child("name")
+		switch {
+		case port.Name == "" && len(expose.Ports) > 1:
+			errs = append(errs, field.Required(name, "required when there are several ports"))
+		case names[port.Name]:
+			errs = append(errs, field.Duplicate(name, port.Name))
+		}
+		names[port.Name] = true
+	}
+	return errs
+}
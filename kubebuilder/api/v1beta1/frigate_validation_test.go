@@ -0,0 +1,103 @@
+package v1beta1
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestFrigateValidateSpec(t *testing.T) {
+	zero, two := int32(0), int32(2)
+	table := []struct {
+		name   string
+		spec   FrigateSpec
+		errors int
+	}{
+		{name: "defaults", spec: FrigateSpec{}, errors: 1},
+		{name: "defaults with foo", spec: FrigateSpec{Foo: "bar"}},
+		{name: "replicas with foo", spec: FrigateSpec{Foo: "bar", Replicas: &two}},
+		{name: "scaled down without foo", spec: FrigateSpec{Replicas: &zero}},
+		{name: "replicas without foo", spec: FrigateSpec{Replicas: &two}, errors: 1},
+		{
+			name: "configRef with cargo",
+			spec: FrigateSpec{Foo: "bar", ConfigRef: &ConfigReference{Kind: "ConfigMap", Name: "a"}, CargoTemplate: &corev1.PodTemplateSpec{}},
+		},
+		{
+			name:   "configRef without cargo",
+			spec:   FrigateSpec{Replicas: &two, ConfigRef: &ConfigReference{Kind: "ConfigMap", Name: "a"}},
+			errors: 2,
+		},
+	}
+	for _, test := range table {
+		frigate := &Frigate{Spec: test.spec}
+		if errs := frigate.ValidateSpec(); len(errs) != test.errors {
+			t.Errorf("%s: expected %d errors got %v", test.name, test.errors, errs)
+		}
+	}
+}
+
+func TestFrigateValidateExpose(t *testing.T) {
+	table := []struct {
+		name   string
+		expose ExposeSpec
+		errors int
+	}{
+		{name: "single port", expose: ExposeSpec{Ports: []ExposePort{{Port: 80}}}},
+		{
+			name: "load balancer with source ranges",
+			expose: ExposeSpec{
+				Type:                     corev1.ServiceTypeLoadBalancer,
+				Ports:                    []ExposePort{{Name: "http", Port: 80}, {Name: "https", Port: 443}},
+				LoadBalancerSourceRanges: []string{"203.0.113.0/24", "10.0.0.0/8"},
+			},
+		},
+		{
+			name:   "source ranges without load balancer",
+			expose: ExposeSpec{Type: corev1.ServiceTypeNodePort, Ports: []ExposePort{{Port: 80}}, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}},
+			errors: 1,
+		},
+		{
+			name:   "invalid source range",
+			expose: ExposeSpec{Type: corev1.ServiceTypeLoadBalancer, Ports: []ExposePort{{Port: 80}}, LoadBalancerSourceRanges: []string{"10.0.0.1"}},
+			errors: 1,
+		},
+		{
+			name:   "ports without names",
+			expose: ExposeSpec{Ports: []ExposePort{{Name: "http", Port: 80}, {Port: 443}, {Name: "http", Port: 8080}}},
+			errors: 2,
+		},
+	}
+	for _, test := range table {
+		frigate := &Frigate{Spec: FrigateSpec{Foo: "bar", Expose: &test.expose}}
+		if errs := frigate.ValidateSpec(); len(errs) != test.errors {
+			t.Errorf("%s: expected %d errors got %v", test.name, test.errors, errs)
+		}
+	}
+}
+
+func TestFrigateValidateUpdate(t *testing.T) {
+	old := &Frigate{Spec: FrigateSpec{Foo: "bar", FrigateClassName: "fast", HarborRef: &HarborReference{Name: "north"}}}
+	table := []struct {
+		name   string
+		update func(spec *FrigateSpec)
+		errors int
+	}{
+		{name: "unchanged", update: func(spec *FrigateSpec) {}},
+		{name: "mutable field", update: func(spec *FrigateSpec) { spec.Foo = "baz" }},
+		{name: "class", update: func(spec *FrigateSpec) { spec.FrigateClassName = "slow" }, errors: 1},
+		{name: "harbor", update: func(spec *FrigateSpec) { spec.HarborRef.Name = "south" }, errors: 1},
+		{name: "harbor removed", update: func(spec *FrigateSpec) { spec.HarborRef = nil }, errors: 1},
+		{
+			name:   "both",
+			update: func(spec *FrigateSpec) { spec.FrigateClassName, spec.HarborRef = "", nil },
+			errors: 2,
+		},
+	}
+	for _, test := range table {
+		frigate := old.DeepCopy()
+		test.update(&frigate.Spec)
+		if errs := frigate.ValidateUpdate(old); len(errs) != test.errors {
+			t.Errorf("%s: expected %d errors got %v", test.name, test.errors, errs)
+		}
+	}
+}
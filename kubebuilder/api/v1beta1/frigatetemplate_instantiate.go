@@ -0,0 +1,114 @@
+package v1beta1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// parameterReference matches $(NAME) in template strings
+var parameterReference = regexp.MustCompile(`\$\(([A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// Instantiate returns the Frigate of instance, named after it in the
+// namespace of the template, with the parameters substituted. It fails
+// when a required parameter is missing, the instance sets parameters
+// the template does not declare or the template references them
+func (in *FrigateTemplate) Instantiate(instance TemplateInstance) (*Frigate, error) {
+	values, err := in.parameterValues(instance)
+	if err != nil {
+		return nil, err
+	}
+	undefined := map[string]bool{}
+	substitute := func(value string) string {
+		return parameterReference.ReplaceAllStringFunc(value, func(ref string) string {
+			name := parameterReference.FindStringSubmatch(ref)[1]
+			value, ok := values[name]
+			if !ok {
+				undefined[name] = true
+			}
+			return value
+		})
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&in.Spec.Template.Spec)
+	if err != nil {
+		return nil, err
+	}
+	substituteStrings(obj, substitute)
+	frigate := &Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name,
+			Namespace: in.Namespace,
+			Labels:    map[string]string{},
+		},
+	}
+	if err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &frigate.Spec); err != nil {
+		return nil, err
+	}
+	for key, value := range in.Spec.Template.Labels {
+		frigate.Labels[key] = substitute(value)
+	}
+	frigate.Labels[FrigateTemplateLabel] = in.Name
+	if len(undefined) > 0 {
+		names := make([]string, 0, len(undefined))
+		for name := range undefined {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("template references undefined parameters %s", strings.Join(names, ", "))
+	}
+	return frigate, nil
+}
+
+// parameterValues merges the values of instance with the defaults
+func (in *FrigateTemplate) parameterValues(instance TemplateInstance) (map[string]string, error) {
+	values := map[string]string{}
+	declared := map[string]bool{}
+	var missing, undeclared []string
+	for _, parameter := range in.Spec.Parameters {
+		declared[parameter.Name] = true
+		value, ok := instance.Parameters[parameter.Name]
+		if !ok {
+			value = parameter.Default
+		}
+		if parameter.Required && value == "" {
+			missing = append(missing, parameter.Name)
+		}
+		values[parameter.Name] = value
+	}
+	for name := range instance.Parameters {
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		return nil, fmt.Errorf("missing required parameters %s", strings.Join(missing, ", "))
+	case len(undeclared) > 0:
+		sort.Strings(undeclared)
+		return nil, fmt.Errorf("unknown parameters %s", strings.Join(undeclared, ", "))
+	}
+	return values, nil
+}
+
+// substituteStrings replaces every string value of obj in place, keys
+// are left as is
+func substituteStrings(obj interface{}, substitute func(string) string) interface{} {
+	switch value := obj.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = substituteStrings(item, substitute)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = substituteStrings(item, substitute)
+		}
+	case string:
+		return substitute(value)
+	}
+	return obj
+}
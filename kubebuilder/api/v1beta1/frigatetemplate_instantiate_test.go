@@ -0,0 +1,65 @@
+package v1beta1
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFrigateTemplateInstantiate(t *testing.T) {
+	replicas := int32(2)
+	template := &FrigateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "patrol", Namespace: "default"},
+		Spec: FrigateTemplateSpec{
+			Parameters: []TemplateParameter{
+				{Name: "CALLSIGN", Required: true},
+				{Name: "SEA", Default: "north"},
+			},
+			Template: FrigateInstanceTemplate{
+				Labels: map[string]string{"sea": "$(SEA)"},
+				Spec: FrigateSpec{
+					Foo:       "$(CALLSIGN)-$(SEA)",
+					Replicas:  &replicas,
+					HarborRef: &HarborReference{Name: "harbor-$(SEA)"},
+				},
+			},
+		},
+	}
+
+	// 1. parameters are substituted, defaults used when unset
+	frigate, err := template.Instantiate(TemplateInstance{Name: "alpha", Parameters: map[string]string{"CALLSIGN": "alpha"}})
+	if err != nil {
+		t.Fatalf("should instantiate: %v", err)
+	}
+	if frigate.Name != "alpha" || frigate.Namespace != "default" || frigate.Spec.Foo != "alpha-north" || *frigate.Spec.Replicas != 2 ||
+		frigate.Spec.HarborRef.Name != "harbor-north" {
+		t.Errorf("unexpected frigate %+v", frigate)
+	}
+	if frigate.Labels["sea"] != "north" || frigate.Labels[FrigateTemplateLabel] != "patrol" {
+		t.Errorf("unexpected labels %v", frigate.Labels)
+	}
+	// the template is left untouched
+	if template.Spec.Template.Spec.Foo != "$(CALLSIGN)-$(SEA)" {
+		t.Errorf("template was modified: %+v", template.Spec.Template.Spec)
+	}
+
+	// 2. invalid instances are refused
+	for name, test := range map[string]struct {
+		template   *FrigateTemplate
+		parameters map[string]string
+		expected   string
+	}{
+		"missing required": {template, map[string]string{"SEA": "south"}, "missing required parameters CALLSIGN"},
+		"undeclared":       {template, map[string]string{"CALLSIGN": "a", "SPEED": "10"}, "unknown parameters SPEED"},
+		"undefined reference": {
+			&FrigateTemplate{Spec: FrigateTemplateSpec{Template: FrigateInstanceTemplate{Spec: FrigateSpec{Foo: "$(SPEED)"}}}},
+			nil, "undefined parameters SPEED",
+		},
+	} {
+		_, err := test.template.Instantiate(TemplateInstance{Name: "bravo", Parameters: test.parameters})
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected %q, got %v", name, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FrigateTemplateLabel is set on instantiated Frigates with the name
+// of their FrigateTemplate
+const FrigateTemplateLabel = "ship.danielfbm.github.io/frigate-template"
+
+// FrigateTemplateSpec defines a parameterized Frigate and the instances
+// created from it
+type FrigateTemplateSpec struct {
+	// Parameters of the template, referenced as $(NAME) in the string
+	// fields and label values of the template
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+
+	// Template of the instantiated Frigates
+	Template FrigateInstanceTemplate `json:"template"`
+
+	// Instances are the Frigates created from the template, Frigates of
+	// instances removed from the list are deleted
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Instances []TemplateInstance `json:"instances,omitempty"`
+}
+
+// TemplateParameter is a value substituted in the template
+type TemplateParameter struct {
+	// Name of the parameter, referenced as $(NAME)
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	// Description of the parameter
+	// +optional
+	Description string `json:"description,omitempty"`
+	// Default is used by instances not setting the parameter
+	// +optional
+	Default string `json:"default,omitempty"`
+	// Required parameters must be set by every instance unless they
+	// have a default
+	// +optional
+	Required bool `json:"required,omitempty"`
+}
+
+// FrigateInstanceTemplate describes the Frigates created from a template
+type FrigateInstanceTemplate struct {
+	// Labels set on the instantiated Frigates
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Spec of the instantiated Frigates
+	Spec FrigateSpec `json:"spec"`
+}
+
+// TemplateInstance is a Frigate created from the template
+type TemplateInstance struct {
+	// Name of the Frigate, in the namespace of the template
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Parameters values of the instance
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// InstanceStatus is the observed state of an instance
+type InstanceStatus struct {
+	// Name of the Frigate
+	Name string `json:"name"`
+	// Phase of the Frigate
+	// +optional
+	Phase FrigatePhase `json:"phase,omitempty"`
+	// Error explains why the Frigate could not be instantiated
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// FrigateTemplateStatus defines the observed state of FrigateTemplate
+type FrigateTemplateStatus struct {
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Instances reports the Frigates created from the template
+	// +optional
+	// +listType=map
+	// +listMapKey=name
+	Instances []InstanceStatus `json:"instances,omitempty"`
+
+	// Conditions of the template, Ready is False while an instance
+	// cannot be instantiated
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// FrigateTemplate creates Frigates from a parameterized spec
+type FrigateTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FrigateTemplateSpec   `json:"spec,omitempty"`
+	Status FrigateTemplateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrigateTemplateList contains a list of FrigateTemplate
+type FrigateTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FrigateTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FrigateTemplate{}, &FrigateTemplateList{})
+}
@@ -0,0 +1,59 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FrigateTombstoneSpec holds the last known state of a deleted Frigate
+type FrigateTombstoneSpec struct {
+	// FrigateName is the name of the deleted Frigate
+	FrigateName string `json:"frigateName"`
+	// FrigateUID is the uid of the deleted Frigate
+	// +optional
+	FrigateUID string `json:"frigateUID,omitempty"`
+	// Snapshot of the Frigate taken while its finalizer held the deletion
+	Snapshot FrigateSnapshot `json:"snapshot"`
+	// DeletedAt is the time the Frigate was deleted
+	DeletedAt metav1.Time `json:"deletedAt"`
+	// ExpiresAt is the time the tombstone will be removed
+	// and the Frigate can no longer be restored
+	ExpiresAt metav1.Time `json:"expiresAt"`
+}
+
+// FrigateSnapshot is the part of a Frigate needed to recreate it
+type FrigateSnapshot struct {
+	// Labels of the Frigate
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations of the Frigate
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Spec of the Frigate
+	Spec FrigateSpec `json:"spec"`
+	// Status of the Frigate, kept for reference only
+	// +optional
+	Status FrigateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrigateTombstone keeps a deleted Frigate around for a while so it can be restored
+type FrigateTombstone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FrigateTombstoneSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrigateTombstoneList contains a list of FrigateTombstone
+type FrigateTombstoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FrigateTombstone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FrigateTombstone{}, &FrigateTombstoneList{})
+}
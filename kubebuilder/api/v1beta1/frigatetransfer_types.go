@@ -0,0 +1,128 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FrigateTransferSpec defines the Frigate to move and where to
+type FrigateTransferSpec struct {
+	// Source is the name of the Frigate to move, in the namespace of the transfer
+	// +kubebuilder:validation:MinLength=1
+	Source string `json:"source"`
+	// TargetNamespace is the namespace the Frigate is moved to. It must
+	// carry the AcceptTransfersLabel set to "true"
+	// +kubebuilder:validation:MinLength=1
+	TargetNamespace string `json:"targetNamespace"`
+	// TargetName is the name of the Frigate in the target namespace,
+	// defaults to the source name
+	// +optional
+	TargetName string `json:"targetName,omitempty"`
+}
+
+// AcceptTransfersLabel opts a namespace in as the target of FrigateTransfers.
+// Transfers are created in the source namespace, without it anyone able
+// to create one could write Frigates into any namespace
+const AcceptTransfersLabel = "ship.danielfbm.github.io/accept-transfers"
+
+// FrigateTransferPhase is the lifecycle phase of a FrigateTransfer
+// +kubebuilder:validation:Enum=Pending;Running;Completed;Failure
+type FrigateTransferPhase string
+
+// These are valid FrigateTransfer phases
+const (
+	// FrigateTransferPending is a transfer not started yet
+	FrigateTransferPending FrigateTransferPhase = "Pending"
+	// FrigateTransferRunning is a transfer in progress
+	FrigateTransferRunning FrigateTransferPhase = "Running"
+	// FrigateTransferCompleted is a transfer whose source was removed
+	FrigateTransferCompleted FrigateTransferPhase = "Completed"
+	// FrigateTransferFailure is a transfer that cannot be completed
+	FrigateTransferFailure FrigateTransferPhase = "Failure"
+)
+
+// Reasons set in status when a FrigateTransfer fails
+const (
+	// ReasonSourceNotFound is set when the source Frigate does not exist
+	ReasonSourceNotFound = "SourceNotFound"
+	// ReasonTargetExists is set when a Frigate not created by the
+	// transfer already exists in the target namespace
+	ReasonTargetExists = "TargetExists"
+	// ReasonTransferNotAccepted is set when the target namespace does
+	// not exist or does not accept transfers
+	ReasonTransferNotAccepted = "TransferNotAccepted"
+)
+
+// FrigateTransferStatus defines the observed state of FrigateTransfer
+type FrigateTransferStatus struct {
+	// Phase of the transfer
+	// +optional
+	Phase FrigateTransferPhase `json:"phase,omitempty"`
+	// Reason is a machine readable code explaining the phase, set on failures
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable explanation of the reason
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// SourceUID is the uid of the source Frigate when it was snapshotted
+	// +optional
+	SourceUID string `json:"sourceUID,omitempty"`
+	// Tombstone is the FrigateTombstone holding the snapshot of the
+	// source, the target is recreated from it
+	// +optional
+	Tombstone string `json:"tombstone,omitempty"`
+	// Payloads is the number of payload ConfigMaps copied to the target
+	// +optional
+	Payloads int32 `json:"payloads,omitempty"`
+	// Children is the number of Pods recreated in the target namespace
+	// +optional
+	Children int32 `json:"children,omitempty"`
+
+	// Conditions of the transfer: Ready, Progressing and Degraded
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+
+	// Checkpoint records the progress of the last reconcile
+	// +optional
+	Checkpoint *StepCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Source",type="string",JSONPath=".spec.source"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.targetNamespace"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.reason"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// FrigateTransfer moves a Frigate to another namespace. Objects cannot
+// change namespace, the Frigate is recreated in the target from a
+// tombstone of the source which is then deleted
+type FrigateTransfer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FrigateTransferSpec   `json:"spec,omitempty"`
+	Status FrigateTransferStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// FrigateTransferList contains a list of FrigateTransfer
+type FrigateTransferList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FrigateTransfer `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FrigateTransfer{}, &FrigateTransferList{})
+}
@@ -0,0 +1,74 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HarborReference references a Harbor in the same namespace
+type HarborReference struct {
+	// Name of the Harbor
+	Name string `json:"name"`
+}
+
+// Reasons set in Frigate status while waiting for their Harbor
+const (
+	// ReasonHarborNotFound is set when the referenced Harbor does not exist
+	ReasonHarborNotFound = "HarborNotFound"
+	// ReasonHarborNotReady is set while the referenced Harbor is not Ready
+	ReasonHarborNotReady = "HarborNotReady"
+)
+
+// HarborSpec defines the desired state of Harbor
+type HarborSpec struct {
+	// Capacity is the number of Frigates that can dock, unlimited when 0
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Capacity int32 `json:"capacity,omitempty"`
+}
+
+// HarborStatus defines the observed state of Harbor
+type HarborStatus struct {
+	// ObservedGeneration is the metadata.generation of the spec the
+	// status was computed for
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Docked is the number of Frigates referencing the Harbor
+	// +optional
+	Docked int32 `json:"docked,omitempty"`
+
+	// Conditions of the Harbor, Ready is False while it is over capacity
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Docked",type="integer",JSONPath=".status.docked"
+// +kubebuilder:printcolumn:name="Capacity",type="integer",JSONPath=".spec.capacity"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// Harbor is where Frigates dock, referenced by spec.harborRef
+type Harbor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   HarborSpec   `json:"spec,omitempty"`
+	Status HarborStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// HarborList contains a list of Harbor
+type HarborList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Harbor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Harbor{}, &HarborList{})
+}
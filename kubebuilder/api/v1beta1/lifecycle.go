@@ -0,0 +1,98 @@
+package v1beta1
+
+// The accessors below let pkg/lifecycle manage the phase, conditions and
+// step checkpoint of every ship kind without knowing their status types
+
+// GetSpec returns the spec, its hash identifies the step checkpoint
+func (in *Frigate) GetSpec() interface{} { return in.Spec }
+
+// GetPhase returns the status phase
+func (in *Frigate) GetPhase() string { return string(in.Status.Phase) }
+
+// SetPhase sets the status phase
+func (in *Frigate) SetPhase(phase string) { in.Status.Phase = FrigatePhase(phase) }
+
+// SetReason sets the status reason and message
+func (in *Frigate) SetReason(reason, message string) {
+	in.Status.Reason, in.Status.Message = reason, message
+}
+
+// GetConditions returns the status conditions to be modified in place
+func (in *Frigate) GetConditions() *[]Condition { return &in.Status.Conditions }
+
+// GetCheckpoint returns the step checkpoint
+func (in *Frigate) GetCheckpoint() *StepCheckpoint { return in.Status.Checkpoint }
+
+// SetCheckpoint sets the step checkpoint
+func (in *Frigate) SetCheckpoint(checkpoint *StepCheckpoint) { in.Status.Checkpoint = checkpoint }
+
+// GetSpec returns the spec, its hash identifies the step checkpoint
+func (in *Destroyer) GetSpec() interface{} { return in.Spec }
+
+// GetPhase returns the status phase
+func (in *Destroyer) GetPhase() string { return string(in.Status.Phase) }
+
+// SetPhase sets the status phase
+func (in *Destroyer) SetPhase(phase string) { in.Status.Phase = DestroyerPhase(phase) }
+
+// SetReason sets the status reason and message
+func (in *Destroyer) SetReason(reason, message string) {
+	in.Status.Reason, in.Status.Message = reason, message
+}
+
+// GetConditions returns the status conditions to be modified in place
+func (in *Destroyer) GetConditions() *[]Condition { return &in.Status.Conditions }
+
+// GetCheckpoint returns the step checkpoint
+func (in *Destroyer) GetCheckpoint() *StepCheckpoint { return in.Status.Checkpoint }
+
+// SetCheckpoint sets the step checkpoint
+func (in *Destroyer) SetCheckpoint(checkpoint *StepCheckpoint) { in.Status.Checkpoint = checkpoint }
+
+// GetSpec returns the spec, its hash identifies the step checkpoint
+func (in *FrigateTransfer) GetSpec() interface{} { return in.Spec }
+
+// GetPhase returns the status phase
+func (in *FrigateTransfer) GetPhase() string { return string(in.Status.Phase) }
+
+// SetPhase sets the status phase
+func (in *FrigateTransfer) SetPhase(phase string) { in.Status.Phase = FrigateTransferPhase(phase) }
+
+// SetReason sets the status reason and message
+func (in *FrigateTransfer) SetReason(reason, message string) {
+	in.Status.Reason, in.Status.Message = reason, message
+}
+
+// GetConditions returns the status conditions to be modified in place
+func (in *FrigateTransfer) GetConditions() *[]Condition { return &in.Status.Conditions }
+
+// GetCheckpoint returns the step checkpoint
+func (in *FrigateTransfer) GetCheckpoint() *StepCheckpoint { return in.Status.Checkpoint }
+
+// SetCheckpoint sets the step checkpoint
+func (in *FrigateTransfer) SetCheckpoint(checkpoint *StepCheckpoint) {
+	in.Status.Checkpoint = checkpoint
+}
+
+// GetSpec returns the spec, its hash identifies the step checkpoint
+func (in *CruiseMission) GetSpec() interface{} { return in.Spec }
+
+// GetPhase returns the status phase
+func (in *CruiseMission) GetPhase() string { return string(in.Status.Phase) }
+
+// SetPhase sets the status phase
+func (in *CruiseMission) SetPhase(phase string) { in.Status.Phase = CruiseMissionPhase(phase) }
+
+// SetReason sets the status reason and message
+func (in *CruiseMission) SetReason(reason, message string) {
+	in.Status.Reason, in.Status.Message = reason, message
+}
+
+// GetConditions returns the status conditions to be modified in place
+func (in *CruiseMission) GetConditions() *[]Condition { return &in.Status.Conditions }
+
+// GetCheckpoint returns the step checkpoint
+func (in *CruiseMission) GetCheckpoint() *StepCheckpoint { return in.Status.Checkpoint }
+
+// SetCheckpoint sets the step checkpoint
+func (in *CruiseMission) SetCheckpoint(checkpoint *StepCheckpoint) { in.Status.Checkpoint = checkpoint }
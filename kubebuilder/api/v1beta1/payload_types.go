@@ -0,0 +1,15 @@
+package v1beta1
+
+// PayloadReference points to a bulky payload, like telemetry or inspection
+// reports, that is kept in an owned ConfigMap instead of the object status
+type PayloadReference struct {
+	// ConfigMap name holding the payload, in the same namespace as the owner
+	ConfigMap string `json:"configMap"`
+	// Key inside the ConfigMap binaryData
+	Key string `json:"key"`
+	// Hash of the payload content (sha256, hex encoded)
+	Hash string `json:"hash"`
+	// Size of the payload in bytes
+	// +optional
+	Size int `json:"size,omitempty"`
+}
@@ -0,0 +1,60 @@
+package v1beta1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultShipClassController is the controller name of the ShipClasses
+// handled by this project
+const DefaultShipClassController = "ship.danielfbm.github.io/frigate-controller"
+
+// ReasonShipClassNotFound is set while the ShipClass of a Frigate does not exist
+const ReasonShipClassNotFound = "ShipClassNotFound"
+
+// ShipClassSpec defines the defaults of the Frigates of a class
+type ShipClassSpec struct {
+	// Controller is the name of the controller handling Frigates of the
+	// class, like the provisioner of a StorageClass
+	Controller string `json:"controller"`
+
+	// Image is used by cargo containers without an image
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Resources are used by cargo containers without requests and limits
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// ReconcileInterval requeues Frigates of the class periodically,
+	// they are only reconciled when something changes if unset
+	// +optional
+	ReconcileInterval *metav1.Duration `json:"reconcileInterval,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Controller",type="string",JSONPath=".spec.controller"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ShipClass provides defaults for the Frigates naming it in
+// spec.frigateClassName, like StorageClasses do for PersistentVolumeClaims
+type ShipClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ShipClassSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ShipClassList contains a list of ShipClass
+type ShipClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShipClass `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShipClass{}, &ShipClassList{})
+}
@@ -0,0 +1,52 @@
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShipLabelPolicySpec lists the labels required on the Frigates of the
+// namespace depending on their spec
+type ShipLabelPolicySpec struct {
+	// Rules are evaluated in order, when rules of one or more policies
+	// require different values for a label the first one wins
+	// +kubebuilder:validation:MinItems=1
+	Rules []LabelRule `json:"rules"`
+}
+
+// LabelRule requires labels on the Frigates matching it
+type LabelRule struct {
+	// Match maps dotted field paths of the Frigate to the value they must
+	// have, i.e. spec.harborRef.name: north. A missing field matches "".
+	// Every Frigate matches an empty Match
+	// +optional
+	Match map[string]string `json:"match,omitempty"`
+	// Labels required on matching Frigates
+	// +kubebuilder:validation:MinProperties=1
+	Labels map[string]string `json:"labels"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ShipLabelPolicy adds labels to Frigates based on their spec. Labels are
+// set at admission and repaired by the shiplabelpolicy controller, they
+// are never removed
+type ShipLabelPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ShipLabelPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ShipLabelPolicyList contains a list of ShipLabelPolicy
+type ShipLabelPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ShipLabelPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ShipLabelPolicy{}, &ShipLabelPolicyList{})
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/admissionpolicy"
+)
+
+// admissionpolicy-gen writes the ValidatingAdmissionPolicies mirroring
+// the Frigate webhooks
+//
+//	go generate ./pkg/admissionpolicy
+func main() {
+	var output string
+	flag.StringVar(&output, "output", "config/admissionpolicy/frigates.yaml", "File to write the policies to, - for stdout.")
+	flag.Parse()
+
+	if err := generate(output, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generate renders the policies to output, or to stdout when output is -
+func generate(output string, stdout io.Writer) error {
+	data, err := admissionpolicy.Render(admissionpolicy.Policies)
+	if err != nil {
+		return err
+	}
+	if output == "-" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(output, data, 0644)
+}
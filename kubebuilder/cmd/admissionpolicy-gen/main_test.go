@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// golden is the committed output of go generate ./pkg/admissionpolicy
+const golden = "../../config/admissionpolicy/frigates.yaml"
+
+func TestGenerateMatchesGolden(t *testing.T) {
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("should read golden file: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "admissionpolicy-gen")
+	if err != nil {
+		t.Fatalf("should create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// 1. written to a file
+	output := filepath.Join(dir, "frigates.yaml")
+	if err = generate(output, nil); err != nil {
+		t.Fatalf("should generate: %v", err)
+	}
+	written, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatalf("should read output: %v", err)
+	}
+	if !bytes.Equal(written, expected) {
+		t.Errorf("%s is outdated, run go generate ./pkg/admissionpolicy", golden)
+	}
+
+	// 2. written to stdout
+	stdout := &bytes.Buffer{}
+	if err = generate("-", stdout); err != nil {
+		t.Fatalf("should generate to stdout: %v", err)
+	}
+	if !bytes.Equal(stdout.Bytes(), expected) {
+		t.Errorf("stdout should match %s", golden)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/controllers"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/paging"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// phases accepted by --phase
+var phases = []shipv1beta1.FrigatePhase{
+	shipv1beta1.FrigatePending,
+	shipv1beta1.FrigateRunning,
+	shipv1beta1.FrigateCompleted,
+	shipv1beta1.FrigateFailure,
+}
+
+// runGet lists Frigates, filters are applied by the API server
+func runGet(args []string) (err error) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "Only list Frigates in this namespace. Defaults to all namespaces.")
+	phase := fs.String("phase", "", "Only list Frigates in this phase: Pending, Running, Completed or Failure.")
+	selector := fs.String("selector", "", "Label selector, i.e. 'team=blue'.")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	set, err := labels.ConvertSelectorToLabelsMap(*selector)
+	if err != nil {
+		return fmt.Errorf("invalid --selector: %v", err)
+	}
+	if *phase != "" {
+		// the controller mirrors the phase into a label
+		// so filtering happens on the server
+		if set[controllers.PhaseLabel], err = parsePhase(*phase); err != nil {
+			return
+		}
+	}
+
+	c, err := newClient()
+	if err != nil {
+		return
+	}
+	return listFrigates(context.Background(), c, os.Stdout, *namespace, set)
+}
+
+// listFrigates prints the Frigates of namespace matching set as a table
+func listFrigates(ctx context.Context, reader client.Reader, out io.Writer, namespace string, set labels.Set) (err error) {
+	frigates := &shipv1beta1.FrigateList{}
+	lister := &paging.Lister{Reader: reader}
+	if err = lister.List(ctx, frigates,
+		client.InNamespace(namespace),
+		client.MatchingLabels(set),
+	); err != nil {
+		return
+	}
+	sort.Slice(frigates.Items, func(i, j int) bool {
+		a, b := frigates.Items[i], frigates.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tPHASE\tREASON\tAGE")
+	for _, f := range frigates.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", f.Namespace, f.Name, valueOr(string(f.Status.Phase), "<none>"), valueOr(f.Status.Reason, "<none>"), age(f.CreationTimestamp.Time))
+	}
+	return w.Flush()
+}
+
+// parsePhase accepts phases in any case
+func parsePhase(value string) (string, error) {
+	names := make([]string, 0, len(phases))
+	for _, phase := range phases {
+		if strings.EqualFold(value, string(phase)) {
+			return string(phase), nil
+		}
+		names = append(names, string(phase))
+	}
+	return "", fmt.Errorf("unknown phase %q, expected one of %s", value, strings.Join(names, ", "))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/controllers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// clock is the fixed time of the tests
+var clock = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func testFrigate(namespace, name string, phase shipv1beta1.FrigatePhase, reason string, created time.Duration) runtime.Object {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			Labels:            map[string]string{controllers.PhaseLabel: string(phase)},
+			CreationTimestamp: metav1.NewTime(clock.Add(-created)),
+		},
+		Status: shipv1beta1.FrigateStatus{Phase: phase, Reason: reason},
+	}
+}
+
+func fleet() []runtime.Object {
+	return []runtime.Object{
+		testFrigate("red", "charlie", "", "", 72*time.Hour),
+		testFrigate("blue", "bravo", shipv1beta1.FrigateFailure, "Crashed", 90*time.Second),
+		testFrigate("blue", "alpha", shipv1beta1.FrigateCompleted, "", 2*time.Hour),
+	}
+}
+
+func TestListFrigates(t *testing.T) {
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	c := fake.NewFakeClientWithScheme(scheme, fleet()...)
+	charlie, bravo, alpha := age(clock.Add(-72*time.Hour)), age(clock.Add(-90*time.Second)), age(clock.Add(-2*time.Hour))
+
+	for _, test := range []struct {
+		name      string
+		namespace string
+		set       labels.Set
+		expected  string
+	}{
+		{
+			name: "all namespaces",
+			expected: "" +
+				"NAMESPACE  NAME     PHASE      REASON   AGE\n" +
+				"blue       alpha    Completed  <none>   " + alpha + "\n" +
+				"blue       bravo    Failure    Crashed  " + bravo + "\n" +
+				"red        charlie  <none>     <none>   " + charlie + "\n",
+		},
+		{
+			name:      "namespace",
+			namespace: "blue",
+			expected: "" +
+				"NAMESPACE  NAME   PHASE      REASON   AGE\n" +
+				"blue       alpha  Completed  <none>   " + alpha + "\n" +
+				"blue       bravo  Failure    Crashed  " + bravo + "\n",
+		},
+		{
+			name: "phase",
+			set:  labels.Set{controllers.PhaseLabel: "Completed"},
+			expected: "" +
+				"NAMESPACE  NAME   PHASE      REASON  AGE\n" +
+				"blue       alpha  Completed  <none>  " + alpha + "\n",
+		},
+		{
+			name: "no match",
+			set:  labels.Set{"team": "green"},
+			expected: "" +
+				"NAMESPACE  NAME  PHASE  REASON  AGE\n",
+		},
+	} {
+		out := &bytes.Buffer{}
+		if err := listFrigates(context.TODO(), c, out, test.namespace, test.set); err != nil {
+			t.Fatalf("%s: should list: %v", test.name, err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", test.name, test.expected, out.String())
+		}
+	}
+}
+
+func TestParsePhase(t *testing.T) {
+	for _, test := range []struct {
+		value    string
+		expected string
+		err      bool
+	}{
+		{"Completed", "Completed", false},
+		{"failure", "Failure", false},
+		{"RUNNING", "Running", false},
+		{"sunk", "", true},
+	} {
+		phase, err := parsePhase(test.value)
+		if phase != test.expected || (err != nil) != test.err {
+			t.Errorf("parsePhase(%q) expected %q and error %v, got %q and %v", test.value, test.expected, test.err, phase, err)
+		}
+	}
+}
+
+func TestDrawTop(t *testing.T) {
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	event := func(name, kind, object, reason string, seen time.Duration) runtime.Object {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "blue", Name: name},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: object},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        "container exited",
+			LastTimestamp:  metav1.NewTime(clock.Add(-seen)),
+		}
+	}
+	objects := append(fleet(),
+		event("older", "Frigate", "bravo", "Retrying", time.Minute),
+		event("latest", "Frigate", "bravo", "Crashed", 30*time.Second),
+		event("pod", "Pod", "bravo-0", "BackOff", time.Second),
+	)
+	c := fake.NewFakeClientWithScheme(scheme, objects...)
+
+	// Frigates sorted by namespace and name, then the latest Frigate events
+	out := &bytes.Buffer{}
+	if err := drawTop(out, c, "", 1); err != nil {
+		t.Fatalf("should draw: %v", err)
+	}
+	expected := clearScreen +
+		"frigatectl top - " + clock.Format(time.RFC1123) + " - 3 frigates\n" +
+		"\n" +
+		"NAMESPACE  NAME     PHASE      AGE\n" +
+		"blue       alpha    Completed  " + age(clock.Add(-2*time.Hour)) + "\n" +
+		"blue       bravo    Failure    " + age(clock.Add(-90*time.Second)) + "\n" +
+		"red        charlie  <none>     " + age(clock.Add(-72*time.Hour)) + "\n" +
+		"\n" +
+		"Recent events\n" +
+		"\n" +
+		"LAST SEEN  TYPE     REASON   OBJECT         MESSAGE\n" +
+		"30s        Warning  Crashed  frigate/bravo  container exited\n"
+	if out.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, out.String())
+	}
+}
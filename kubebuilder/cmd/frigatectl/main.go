@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = shipv1beta1.AddToScheme(scheme)
+}
+
+// command is a frigatectl sub command
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "get", usage: "list Frigates, filtered by phase or labels on the server", run: runGet},
+	{name: "top", usage: "live view of Frigates, their phases and recent events", run: runTop},
+	{name: "undelete", usage: "restore a deleted Frigate from its tombstone", run: runUndelete},
+	{name: "version", usage: "print the version, with --server what the running controller supports", run: runVersion},
+}
+
+// frigatectl is a small CLI to work with ship resources
+// it reads the kubeconfig the same way the manager does
+//
+//	frigatectl <command> [flags]
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == flag.Arg(0) {
+			if err := cmd.run(flag.Args()[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "frigatectl %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: frigatectl [--kubeconfig=path] <command> [flags]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+	}
+}
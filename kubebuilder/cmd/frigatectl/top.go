@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/duration"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// now is replaced in tests
+var now = time.Now
+
+// runTop renders Frigates and their recent events every time
+// the shared informers observe a change
+func runTop(args []string) error {
+	fs := flag.NewFlagSet("top", flag.ExitOnError)
+	namespace := fs.String("namespace", "", "Only show resources in this namespace. Defaults to all namespaces.")
+	events := fs.Int("events", 10, "Number of recent events to display.")
+	refresh := fs.Duration("refresh", 2*time.Second, "Redraw at least this often to update ages.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	informers, err := cache.New(ctrl.GetConfigOrDie(), cache.Options{Scheme: scheme, Namespace: *namespace})
+	if err != nil {
+		return err
+	}
+
+	// every informer event asks for a redraw
+	// the channel is buffered so bursts are coalesced
+	changed := make(chan struct{}, 1)
+	notify := toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { trigger(changed) },
+		UpdateFunc: func(interface{}, interface{}) { trigger(changed) },
+		DeleteFunc: func(interface{}) { trigger(changed) },
+	}
+	for _, obj := range []runtime.Object{&shipv1beta1.Frigate{}, &corev1.Event{}} {
+		informer, err := informers.GetInformer(obj)
+		if err != nil {
+			return err
+		}
+		informer.AddEventHandler(notify)
+	}
+
+	stop := ctrl.SetupSignalHandler()
+	go func() {
+		if err := informers.Start(stop); err != nil {
+			fmt.Fprintf(os.Stderr, "informers stopped: %v\n", err)
+		}
+	}()
+	if !informers.WaitForCacheSync(stop) {
+		return fmt.Errorf("waiting for caches to sync")
+	}
+
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+	for {
+		if err = drawTop(os.Stdout, informers, *namespace, *events); err != nil {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+func trigger(changed chan struct{}) {
+	select {
+	case changed <- struct{}{}:
+	default:
+	}
+}
+
+// drawTop prints one frame of the dashboard
+func drawTop(out io.Writer, reader client.Reader, namespace string, maxEvents int) (err error) {
+	ctx := context.Background()
+	frigates := &shipv1beta1.FrigateList{}
+	if err = reader.List(ctx, frigates, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	sort.Slice(frigates.Items, func(i, j int) bool {
+		a, b := frigates.Items[i], frigates.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	eventList := &corev1.EventList{}
+	if err = reader.List(ctx, eventList, client.InNamespace(namespace)); err != nil {
+		return
+	}
+	shipEvents := make([]corev1.Event, 0, len(eventList.Items))
+	for _, evt := range eventList.Items {
+		if evt.InvolvedObject.Kind == "Frigate" {
+			shipEvents = append(shipEvents, evt)
+		}
+	}
+	sort.Slice(shipEvents, func(i, j int) bool {
+		return shipEvents[i].LastTimestamp.After(shipEvents[j].LastTimestamp.Time)
+	})
+	if len(shipEvents) > maxEvents {
+		shipEvents = shipEvents[:maxEvents]
+	}
+
+	fmt.Fprint(out, clearScreen)
+	fmt.Fprintf(out, "frigatectl top - %s - %d frigates\n\n", now().Format(time.RFC1123), len(frigates.Items))
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tPHASE\tAGE")
+	for _, f := range frigates.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Namespace, f.Name, valueOr(string(f.Status.Phase), "<none>"), age(f.CreationTimestamp.Time))
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nRecent events\n\n")
+	w = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tMESSAGE")
+	for _, evt := range shipEvents {
+		fmt.Fprintf(w, "%s\t%s\t%s\tfrigate/%s\t%s\n", age(evt.LastTimestamp.Time), evt.Type, evt.Reason, evt.InvolvedObject.Name, evt.Message)
+	}
+	return w.Flush()
+}
+
+func age(t time.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	return duration.HumanDuration(now().Sub(t))
+}
+
+func valueOr(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/controllers"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/paging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runUndelete recreates a deleted Frigate from its latest tombstone
+func runUndelete(args []string) (err error) {
+	fs := flag.NewFlagSet("undelete", flag.ExitOnError)
+	namespace := fs.String("namespace", "default", "Namespace of the deleted Frigate.")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: frigatectl undelete [--namespace=ns] <name>")
+	}
+	c, err := newClient()
+	if err != nil {
+		return
+	}
+	return undelete(context.Background(), c, os.Stdout, *namespace, fs.Arg(0))
+}
+
+// undelete recreates the Frigate namespace/name from its latest
+// tombstone and removes the tombstone
+func undelete(ctx context.Context, c client.Client, out io.Writer, namespace, name string) (err error) {
+	tombstones := &shipv1beta1.FrigateTombstoneList{}
+	// the CLI talks to the API server directly, list in pages
+	lister := &paging.Lister{Reader: c}
+	if err = lister.List(ctx, tombstones,
+		client.InNamespace(namespace),
+		client.MatchingLabels{controllers.FrigateNameLabel: name},
+	); err != nil {
+		return
+	}
+	if len(tombstones.Items) == 0 {
+		return fmt.Errorf("no tombstone found for frigate %s/%s", namespace, name)
+	}
+	// the same name could have been deleted more than once
+	latest := tombstones.Items[0]
+	for _, t := range tombstones.Items[1:] {
+		if t.Spec.DeletedAt.After(latest.Spec.DeletedAt.Time) {
+			latest = t
+		}
+	}
+
+	snapshot := latest.Spec.Snapshot
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        latest.Spec.FrigateName,
+			Namespace:   latest.Namespace,
+			Labels:      snapshot.Labels,
+			Annotations: snapshot.Annotations,
+		},
+		Spec: snapshot.Spec,
+	}
+	if err = c.Create(ctx, frigate); err != nil {
+		return fmt.Errorf("recreating frigate: %v", err)
+	}
+	if err = c.Delete(ctx, &latest); err != nil {
+		return fmt.Errorf("frigate restored but tombstone %s was not removed: %v", latest.Name, err)
+	}
+	fmt.Fprintf(out, "frigate/%s restored from tombstone %s (deleted %s)\n", frigate.Name, latest.Name, latest.Spec.DeletedAt.Format("2006-01-02 15:04:05"))
+	return
+}
+
+// newClient builds a client using the same kubeconfig lookup as the manager
+func newClient() (client.Client, error) {
+	return client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/controllers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func tombstone(name string, deletedAt time.Time, foo string) *shipv1beta1.FrigateTombstone {
+	return &shipv1beta1.FrigateTombstone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{controllers.FrigateNameLabel: "some"},
+		},
+		Spec: shipv1beta1.FrigateTombstoneSpec{
+			FrigateName: "some",
+			Snapshot: shipv1beta1.FrigateSnapshot{
+				Labels: map[string]string{"team": "blue"},
+				Spec:   shipv1beta1.FrigateSpec{Foo: foo},
+			},
+			DeletedAt: metav1.NewTime(deletedAt),
+			ExpiresAt: metav1.NewTime(deletedAt.Add(time.Hour)),
+		},
+	}
+}
+
+func TestUndelete(t *testing.T) {
+	ctx := context.TODO()
+	deletedAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := fake.NewFakeClientWithScheme(scheme,
+		tombstone("some-old", deletedAt.Add(-time.Hour), "old"),
+		tombstone("some-new", deletedAt, "new"),
+	)
+	out := &bytes.Buffer{}
+
+	// 1. the Frigate is recreated from its latest tombstone
+	if err := undelete(ctx, c, out, "default", "some"); err != nil {
+		t.Fatalf("should undelete: %v", err)
+	}
+	frigate := &shipv1beta1.Frigate{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "some"}, frigate); err != nil {
+		t.Fatalf("should get restored frigate: %v", err)
+	}
+	if frigate.Spec.Foo != "new" || frigate.Labels["team"] != "blue" {
+		t.Errorf("expected the latest snapshot, got %+v", frigate)
+	}
+	expected := "frigate/some restored from tombstone some-new (deleted " + deletedAt.Local().Format("2006-01-02 15:04:05") + ")\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+
+	// 2. the tombstone used is removed, older ones are kept
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "some-new"}, &shipv1beta1.FrigateTombstone{}); !errors.IsNotFound(err) {
+		t.Errorf("used tombstone should be removed, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "some-old"}, &shipv1beta1.FrigateTombstone{}); err != nil {
+		t.Errorf("older tombstone should be kept: %v", err)
+	}
+
+	// 3. restoring a Frigate that exists again fails
+	if err := undelete(ctx, c, out, "default", "some"); err == nil {
+		t.Errorf("expected an error when the frigate already exists")
+	}
+
+	// 4. Frigates without tombstone cannot be restored
+	if err := undelete(ctx, c, out, "default", "other"); err == nil {
+		t.Errorf("expected an error without tombstone")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/buildinfo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// runVersion prints the frigatectl build and, with --server, what the
+// running controller reports on its capabilities endpoint
+func runVersion(args []string) (err error) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	server := fs.Bool("server", false, "Also query the running controller through the API server.")
+	namespace := fs.String("manager-namespace", "controller-system", "Namespace of the controller manager.")
+	selector := fs.String("manager-selector", "control-plane=controller-manager", "Label selector of the controller manager Pods.")
+	port := fs.Int("summary-port", 8081, "Port of the manager summary endpoint, see --summary-addr.")
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	printBuild(w, "Client", buildinfo.Get())
+	if !*server {
+		return w.Flush()
+	}
+
+	capabilities, err := serverCapabilities(*namespace, *selector, *port)
+	if err != nil {
+		return
+	}
+	printBuild(w, "Server", capabilities.Build)
+	fmt.Fprintf(w, "Controllers:\t%s\n", valueOr(strings.Join(capabilities.Controllers, ", "), "<none>"))
+	fmt.Fprintf(w, "Webhooks:\t%s\n", valueOr(strings.Join(capabilities.Webhooks, ", "), "<none>"))
+	gates := make([]string, 0, len(capabilities.FeatureGates))
+	for gate, enabled := range capabilities.FeatureGates {
+		gates = append(gates, gate+"="+strconv.FormatBool(enabled))
+	}
+	sort.Strings(gates)
+	fmt.Fprintf(w, "Feature gates:\t%s\n", valueOr(strings.Join(gates, ", "), "<none>"))
+	for _, api := range capabilities.APIs {
+		fmt.Fprintf(w, "API:\t%s.%s (%s)\t%s\n", api.Resource, api.Group, api.Kind, strings.Join(api.Versions, ", "))
+	}
+	return w.Flush()
+}
+
+func printBuild(w io.Writer, name string, info buildinfo.Info) {
+	fmt.Fprintf(w, "%s:\t%s\tcommit %s, built %s, %s %s\n", name, info.Version, info.Commit, info.Date, info.GoVersion, info.Platform)
+}
+
+// serverCapabilities reads the capabilities of a running manager Pod
+// through the API server proxy, the summary port is not exposed otherwise
+func serverCapabilities(namespace, selector string, port int) (capabilities *buildinfo.Capabilities, err error) {
+	clientset, err := kubernetes.NewForConfig(ctrl.GetConfigOrDie())
+	if err != nil {
+		return
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+			continue
+		}
+		var data []byte
+		if data, err = clientset.CoreV1().RESTClient().Get().
+			Namespace(namespace).
+			Resource("pods").
+			Name(pod.Name + ":" + strconv.Itoa(port)).
+			SubResource("proxy").
+			Suffix(buildinfo.CapabilitiesPath).
+			DoRaw(); err != nil {
+			return nil, fmt.Errorf("querying %s/%s: %v", namespace, pod.Name, err)
+		}
+		capabilities = &buildinfo.Capabilities{}
+		if err = json.Unmarshal(data, capabilities); err != nil {
+			return nil, fmt.Errorf("decoding capabilities of %s/%s: %v", namespace, pod.Name, err)
+		}
+		return
+	}
+	return nil, fmt.Errorf("no running manager Pod in %s matching %q", namespace, selector)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/seed"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// seed applies a YAML file with ship resources to the current cluster
+//
+//	go run ./cmd/seed --file config/seed/workshop.yaml
+func main() {
+	var file, namespace string
+	var wait bool
+	var timeout time.Duration
+	flag.StringVar(&file, "file", "config/seed/workshop.yaml", "YAML file with the resources to apply.")
+	flag.StringVar(&namespace, "namespace", "default", "Namespace for resources that do not declare one.")
+	flag.BoolVar(&wait, "wait", true, "Wait for each kind to be ready before applying the next one.")
+	flag.DurationVar(&timeout, "timeout", time.Minute, "How long to wait for each resource to be ready.")
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(func(o *zap.Options) {
+		o.Development = true
+	}))
+	log := ctrl.Log.WithName("seed")
+
+	config := ctrl.GetConfigOrDie()
+	mapper, err := apiutil.NewDynamicRESTMapper(config)
+	if err != nil {
+		log.Error(err, "unable to create rest mapper")
+		os.Exit(1)
+	}
+	c, err := client.New(config, client.Options{Mapper: mapper})
+	if err != nil {
+		log.Error(err, "unable to create client")
+		os.Exit(1)
+	}
+
+	reader, err := os.Open(file)
+	if err != nil {
+		log.Error(err, "unable to open seed file", "file", file)
+		os.Exit(1)
+	}
+	defer reader.Close()
+
+	loader := seed.NewLoader(c, mapper, log, seed.Options{Namespace: namespace, Wait: wait, Timeout: timeout})
+	if err = loader.Load(context.Background(), reader); err != nil {
+		log.Error(err, "seeding failed", "file", file)
+		os.Exit(1)
+	}
+	log.Info("seeding done", "file", file)
+}
@@ -0,0 +1,99 @@
+// Package crd embeds the generated CustomResourceDefinitions so tests
+// and the manager can use them without depending on the working directory.
+package crd
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// Bases contains the files generated by `make manifests` in config/crd/bases
+//
+//go:embed bases/*.yaml
+var Bases embed.FS
+
+// typesHash is the hash of the api types at the time manifests were generated
+//
+//go:embed types.sha256
+var typesHash string
+
+// TypesHash returns the hash of the api types used to generate the embedded CRDs
+func TypesHash() string {
+	return strings.TrimSpace(typesHash)
+}
+
+// CRDs decodes all embedded CustomResourceDefinitions
+func CRDs() (crds []*apiextensionsv1beta1.CustomResourceDefinition, err error) {
+	var entries []string
+	if entries, err = listBases(); err != nil {
+		return
+	}
+	for _, name := range entries {
+		var data []byte
+		if data, err = Bases.ReadFile(name); err != nil {
+			return
+		}
+		crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+		if err = yaml.Unmarshal(data, crd); err != nil {
+			err = fmt.Errorf("decoding %s: %v", name, err)
+			return
+		}
+		crds = append(crds, crd)
+	}
+	return
+}
+
+// listBases returns the embedded file names in a stable order
+func listBases() (names []string, err error) {
+	entries, err := Bases.ReadDir("bases")
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		names = append(names, path.Join("bases", entry.Name()))
+	}
+	sort.Strings(names)
+	return
+}
+
+// HashTypes computes the hash of all *_types.go files in the api versions
+// inside apiDir. It must match what hack/crd-hash.sh writes to types.sha256
+func HashTypes(apiDir string) (string, error) {
+	files, err := filepath.Glob(filepath.Join(apiDir, "*", "*_types.go"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	hash := sha256.New()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(data)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// CheckFresh returns an error when the embedded CRDs were generated
+// from a different version of the api types found in apiDir
+func CheckFresh(apiDir string) error {
+	current, err := HashTypes(apiDir)
+	if err != nil {
+		return err
+	}
+	if current != TypesHash() {
+		return fmt.Errorf("generated CRDs are stale: api types hash is %s but CRDs were generated from %s, run `make manifests`", current, TypesHash())
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+// Package samples embeds the example manifests of config/samples
+// so they can be served by the manager.
+package samples
+
+import (
+	"embed"
+)
+
+// Manifests contains one example manifest per kind
+//
+//go:embed *.yaml
+var Manifests embed.FS
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+type configuration struct {
+	Kind     string `json:"kind"`
+	Webhooks []struct {
+		Name        string `json:"name"`
+		SideEffects string `json:"sideEffects"`
+	} `json:"webhooks"`
+}
+
+func webhooks(t *testing.T, file string) map[string]string {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("should read %s: %v", file, err)
+	}
+	result := map[string]string{}
+	for _, doc := range bytes.Split(data, []byte("\n---\n")) {
+		config := configuration{}
+		if err = yaml.Unmarshal(doc, &config); err != nil {
+			t.Fatalf("should decode %s: %v", file, err)
+		}
+		for _, webhook := range config.Webhooks {
+			result[config.Kind+"/"+webhook.Name] = webhook.SideEffects
+		}
+	}
+	return result
+}
+
+// the generated manifests are regenerated by make manifests, the side
+// effects patch has to keep up with them
+func TestEveryWebhookDeclaresSideEffects(t *testing.T) {
+	generated := webhooks(t, "manifests.yaml")
+	patched := webhooks(t, "sideeffects_patch.yaml")
+	if len(generated) == 0 {
+		t.Fatalf("expected webhooks in manifests.yaml")
+	}
+	for name := range generated {
+		if patched[name] != "None" {
+			t.Errorf("%s should be declared with sideEffects None in sideeffects_patch.yaml", name)
+		}
+	}
+	for name := range patched {
+		if _, ok := generated[name]; !ok {
+			t.Errorf("%s is patched but not generated", name)
+		}
+	}
+}
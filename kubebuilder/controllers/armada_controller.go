@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// ArmadaReconciler selects the members of an Armada across namespaces
+// and admits the ones allowed past the Pending phase. The admission is
+// enforced by ArmadaGuard in the frigate controller
+type ArmadaReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the Armada CRD is missing, optional
+	CRDs *crdwatch.Watcher
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=armadas,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=armadas/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *ArmadaReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("armada", req.Name)
+
+	armada := &shipv1beta1.Armada{}
+	if err = r.Get(ctx, req.NamespacedName, armada); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	status := armada.Status.DeepCopy()
+	status.ObservedGeneration = armada.Generation
+	frigateSelector, namespaceSelector, selectorErr := armadaSelectors(armada)
+	if selectorErr != nil {
+		// retrying does not help until the spec is fixed
+		log.Error(selectorErr, "invalid selector")
+		conditions.SetStatusCondition(&status.Conditions, shipv1beta1.Condition{
+			Type:               shipv1beta1.ConditionReady,
+			Status:             shipv1beta1.ConditionFalse,
+			ObservedGeneration: armada.Generation,
+			Reason:             "InvalidSelector",
+			Message:            selectorErr.Error(),
+		})
+	} else {
+		var members []shipv1beta1.Frigate
+		if members, err = r.members(ctx, frigateSelector, namespaceSelector); err != nil {
+			return
+		}
+		admitArmada(armada, status, members)
+	}
+
+	if reflect.DeepEqual(*status, armada.Status) {
+		return
+	}
+	armadaCopy := armada.DeepCopy()
+	armadaCopy.Status = *status
+	objdiff.Log(log, "reconcile changed armada", armada, armadaCopy)
+	if err = r.Status().Patch(ctx, armadaCopy, client.MergeFrom(armada)); err != nil {
+		log.Error(err, "updating armada status")
+	}
+	return
+}
+
+// members lists the Frigates of every namespace matching the selectors
+func (r *ArmadaReconciler) members(ctx context.Context, frigateSelector, namespaceSelector labels.Selector) ([]shipv1beta1.Frigate, error) {
+	frigates := &shipv1beta1.FrigateList{}
+	if err := r.List(ctx, frigates, client.MatchingLabelsSelector{Selector: frigateSelector}); err != nil {
+		return nil, err
+	}
+	if namespaceSelector.Empty() {
+		return frigates.Items, nil
+	}
+	namespaces := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaces, client.MatchingLabelsSelector{Selector: namespaceSelector}); err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(namespaces.Items))
+	for _, namespace := range namespaces.Items {
+		selected[namespace.Name] = true
+	}
+	members := make([]shipv1beta1.Frigate, 0, len(frigates.Items))
+	for _, frigate := range frigates.Items {
+		if selected[frigate.Namespace] {
+			members = append(members, frigate)
+		}
+	}
+	return members, nil
+}
+
+// armadaSelectors parses the selectors of armada, an unset namespace
+// selector matches every namespace
+func armadaSelectors(armada *shipv1beta1.Armada) (frigates, namespaces labels.Selector, err error) {
+	if frigates, err = metav1.LabelSelectorAsSelector(armada.Spec.Selector); err != nil {
+		return
+	}
+	namespaces = labels.Everything()
+	if armada.Spec.NamespaceSelector != nil {
+		if namespaces, err = metav1.LabelSelectorAsSelector(armada.Spec.NamespaceSelector); err != nil {
+			err = fmt.Errorf("namespaceSelector: %v", err)
+		}
+	}
+	return
+}
+
+// frigateRunning is true for phases holding a running slot of an Armada
+func frigateRunning(phase shipv1beta1.FrigatePhase) bool {
+	return phase == shipv1beta1.FrigateRunning || phase == shipv1beta1.FrigateCompleted
+}
+
+// armadaPriority returns the priority annotation of frigate, 0 when
+// unset or invalid
+func armadaPriority(frigate *shipv1beta1.Frigate) int {
+	priority, _ := strconv.Atoi(frigate.Annotations[shipv1beta1.ArmadaPriorityAnnotation])
+	return priority
+}
+
+// admitArmada counts the members and, when the Armada limits them,
+// admits the running members plus the waiting ones with the highest
+// priority until spec.maxRunning is reached. Running members are never
+// preempted, lowering the limit only stops admitting new ones
+func admitArmada(armada *shipv1beta1.Armada, status *shipv1beta1.ArmadaStatus, members []shipv1beta1.Frigate) {
+	var admitted []string
+	var waiting []*shipv1beta1.Frigate
+	for i := range members {
+		frigate := &members[i]
+		if frigateRunning(frigate.Status.Phase) {
+			admitted = append(admitted, frigate.Namespace+"/"+frigate.Name)
+		} else {
+			waiting = append(waiting, frigate)
+		}
+	}
+	status.Total, status.Running = int32(len(members)), int32(len(admitted))
+	status.Admitted = nil
+
+	ready := shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionReady,
+		Status:             shipv1beta1.ConditionTrue,
+		ObservedGeneration: armada.Generation,
+		Reason:             "Unlimited",
+		Message:            fmt.Sprintf("%d of %d Frigates running", status.Running, status.Total),
+	}
+	if armada.Spec.MaxRunning != nil {
+		sort.SliceStable(waiting, func(i, j int) bool {
+			if pi, pj := armadaPriority(waiting[i]), armadaPriority(waiting[j]); pi != pj {
+				return pi > pj
+			}
+			if ti, tj := waiting[i].CreationTimestamp, waiting[j].CreationTimestamp; !ti.Equal(&tj) {
+				return ti.Before(&tj)
+			}
+			return waiting[i].Namespace+"/"+waiting[i].Name < waiting[j].Namespace+"/"+waiting[j].Name
+		})
+		slots := int(*armada.Spec.MaxRunning) - len(admitted)
+		for i := 0; i < slots && i < len(waiting); i++ {
+			admitted = append(admitted, waiting[i].Namespace+"/"+waiting[i].Name)
+		}
+		sort.Strings(admitted)
+		status.Admitted = admitted
+
+		ready.Reason = "SlotsAvailable"
+		if queued := len(waiting) - (len(admitted) - int(status.Running)); queued > 0 {
+			ready.Reason = "AtCapacity"
+			ready.Message = fmt.Sprintf("%d of %d Frigates running, %d waiting for a slot", status.Running, status.Total, queued)
+		}
+	}
+	conditions.SetStatusCondition(&status.Conditions, ready)
+}
+
+// armadasForFrigate maps a Frigate to the Armadas whose selector
+// matches it, their namespace selector is checked when reconciling
+func (r *ArmadaReconciler) armadasForFrigate(obj handler.MapObject) []ctrl.Request {
+	frigateLabels := labels.Set(obj.Meta.GetLabels())
+	return r.armadas(func(armada *shipv1beta1.Armada) bool {
+		selector, err := metav1.LabelSelectorAsSelector(armada.Spec.Selector)
+		return err == nil && selector.Matches(frigateLabels)
+	})
+}
+
+// armadasForNamespace maps a Namespace to the Armadas selecting
+// namespaces, so label changes add or remove members
+func (r *ArmadaReconciler) armadasForNamespace(obj handler.MapObject) []ctrl.Request {
+	return r.armadas(func(armada *shipv1beta1.Armada) bool {
+		return armada.Spec.NamespaceSelector != nil
+	})
+}
+
+// armadas returns the requests of the Armadas matching filter
+func (r *ArmadaReconciler) armadas(filter func(*shipv1beta1.Armada) bool) (requests []ctrl.Request) {
+	armadas := &shipv1beta1.ArmadaList{}
+	if err := r.List(context.Background(), armadas); err != nil {
+		r.Log.Error(err, "listing armadas")
+		return
+	}
+	for i := range armadas.Items {
+		if filter(&armadas.Items[i]) {
+			requests = append(requests, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: armadas.Items[i].Name},
+			})
+		}
+	}
+	return
+}
+
+func (r *ArmadaReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.Armada{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Frigate{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.armadasForFrigate),
+		}).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.armadasForNamespace),
+		}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("armadas"), report.Wrap("armada", r, r.Reporter)))
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func armadaMember(namespace, name, priority string, age time.Duration, phase shipv1beta1.FrigatePhase) *shipv1beta1.Frigate {
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			Labels:            map[string]string{"armada": "red"},
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Status: shipv1beta1.FrigateStatus{Phase: phase},
+	}
+	if priority != "" {
+		frigate.Annotations = map[string]string{shipv1beta1.ArmadaPriorityAnnotation: priority}
+	}
+	return frigate
+}
+
+func TestArmadaAdmission(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	maxRunning := int32(3)
+	armada := &shipv1beta1.Armada{
+		ObjectMeta: metav1.ObjectMeta{Name: "red", Generation: 1},
+		Spec: shipv1beta1.ArmadaSpec{
+			Selector:          &metav1.LabelSelector{MatchLabels: map[string]string{"armada": "red"}},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "on"}},
+			MaxRunning:        &maxRunning,
+		},
+	}
+	east := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "east", Labels: map[string]string{"fleet": "on"}}}
+	west := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "west", Labels: map[string]string{"fleet": "on"}}}
+	north := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "north"}}
+	reconciler := &ArmadaReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, armada, east, west, north,
+			armadaMember("east", "running", "", time.Hour, shipv1beta1.FrigateCompleted),
+			armadaMember("east", "old", "", 2*time.Hour, shipv1beta1.FrigatePending),
+			armadaMember("west", "young", "", time.Minute, shipv1beta1.FrigatePending),
+			armadaMember("west", "urgent", "10", time.Second, shipv1beta1.FrigatePending),
+			// not a member, namespace not selected
+			armadaMember("north", "other", "100", 3*time.Hour, shipv1beta1.FrigatePending),
+		),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "red"}}
+	if _, err := reconciler.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	result := &shipv1beta1.Armada{}
+	if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+		t.Fatalf("should get armada: %v", err)
+	}
+
+	// 1. the running member keeps its slot, then priority and age decide
+	expected := []string{"east/old", "east/running", "west/urgent"}
+	if !reflect.DeepEqual(result.Status.Admitted, expected) || result.Status.Total != 4 || result.Status.Running != 1 {
+		t.Errorf("expected %v admitted of 4 members, got %+v", expected, result.Status)
+	}
+	if ready := result.Status.Conditions[0]; ready.Reason != "AtCapacity" {
+		t.Errorf("expected AtCapacity, got %+v", ready)
+	}
+
+	// 2. the guard only lets admitted members leave Pending
+	guard := ArmadaGuard{Client: reconciler.Client}
+	start := lifecycle.Transition{From: string(shipv1beta1.FrigatePending), To: string(shipv1beta1.FrigateCompleted)}
+	for _, test := range []struct {
+		frigate    *shipv1beta1.Frigate
+		transition lifecycle.Transition
+		vetoed     bool
+	}{
+		{armadaMember("west", "urgent", "10", 0, shipv1beta1.FrigatePending), start, false},
+		{armadaMember("west", "young", "", 0, shipv1beta1.FrigatePending), start, true},
+		{armadaMember("north", "other", "", 0, shipv1beta1.FrigatePending), start, false},
+		// only leaving Pending needs a slot
+		{armadaMember("west", "young", "", 0, shipv1beta1.FrigatePending), lifecycle.Transition{From: "", To: string(shipv1beta1.FrigatePending)}, false},
+	} {
+		veto, err := guard.Check(ctx, test.frigate, test.transition)
+		if err != nil {
+			t.Fatalf("should check %s: %v", test.frigate.Name, err)
+		}
+		if (veto != nil) != test.vetoed || (veto != nil && veto.Reason != shipv1beta1.ReasonArmadaFull) {
+			t.Errorf("%s/%s %v: expected vetoed %v, got %+v", test.frigate.Namespace, test.frigate.Name, test.transition, test.vetoed, veto)
+		}
+	}
+}
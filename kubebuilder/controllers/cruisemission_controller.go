@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+const (
+	// CruiseMissionLabel is set on the Pods of a CruiseMission with its name
+	CruiseMissionLabel = "ship.danielfbm.github.io/cruisemission"
+
+	// missionBackoff is the delay before the first retry, it doubles
+	// with every failed attempt up to missionMaxBackoff like Jobs
+	missionBackoff    = 10 * time.Second
+	missionMaxBackoff = 6 * time.Minute
+)
+
+// CruiseMissionReconciler reconciles a CruiseMission object. Unlike
+// Frigates, which keep their Pods running, missions run one Pod per
+// attempt until one succeeds or they are out of retries or time
+type CruiseMissionReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Guards can veto phase transitions
+	Guards []lifecycle.Guard
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the CruiseMission CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=cruisemissions,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=cruisemissions/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
+
+func (r *CruiseMissionReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("cruisemission", req.NamespacedName)
+
+	mission := &shipv1beta1.CruiseMission{}
+	if err = r.Get(ctx, req.NamespacedName, mission); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	// finished missions are never run again, their Pods are kept for logs
+	if mission.DeletionTimestamp != nil || mission.Status.CompletionTime != nil {
+		return
+	}
+
+	now := time.Now()
+	missionCopy := mission.DeepCopy()
+	stepErr := lifecycle.RunSteps(ctx, "cruisemission", missionCopy, r.steps(now))
+	lifecycle.SetConditions("CruiseMission", missionCopy, stepErr)
+	if stepErr == nil {
+		missionCopy.Status.ObservedGeneration = mission.Generation
+	}
+	objdiff.Log(log, "reconcile changed cruisemission", mission, missionCopy)
+
+	// the checkpoint is persisted even when a step failed
+	if err = r.Status().Patch(ctx, missionCopy, client.MergeFrom(mission)); err != nil {
+		return
+	}
+	if err = stepErr; err != nil {
+		log.Error(err, "reconcile step failed", "step", missionCopy.Status.Checkpoint.Failed)
+		return
+	}
+	result.RequeueAfter = missionRequeue(missionCopy, now)
+	return
+}
+
+// steps of a CruiseMission reconcile, in order
+func (r *CruiseMissionReconciler) steps(now time.Time) []lifecycle.Step {
+	return []lifecycle.Step{
+		missionStep("attempts", func(ctx context.Context, mission *shipv1beta1.CruiseMission) error {
+			return r.runAttempts(ctx, mission, now)
+		}),
+		missionStep("phase", func(ctx context.Context, mission *shipv1beta1.CruiseMission) error {
+			return r.computePhase(ctx, mission, now)
+		}),
+	}
+}
+
+// missionStep adapts a step written for CruiseMissions to lifecycle.Step
+func missionStep(name string, run func(ctx context.Context, mission *shipv1beta1.CruiseMission) error) lifecycle.Step {
+	return lifecycle.Step{Name: name, Run: func(ctx context.Context, obj lifecycle.Object) error {
+		return run(ctx, obj.(*shipv1beta1.CruiseMission))
+	}}
+}
+
+// backoffLimit returns spec.backoffLimit or the default
+func backoffLimit(mission *shipv1beta1.CruiseMission) int32 {
+	if mission.Spec.BackoffLimit != nil {
+		return *mission.Spec.BackoffLimit
+	}
+	return shipv1beta1.DefaultBackoffLimit
+}
+
+// deadline returns when spec.activeDeadlineSeconds runs out, zero without one
+func deadline(mission *shipv1beta1.CruiseMission) time.Time {
+	if mission.Spec.ActiveDeadlineSeconds == nil || mission.Status.StartTime == nil {
+		return time.Time{}
+	}
+	return mission.Status.StartTime.Add(time.Duration(*mission.Spec.ActiveDeadlineSeconds) * time.Second)
+}
+
+// retryAt returns when the next attempt can be launched after a failure
+func retryAt(mission *shipv1beta1.CruiseMission) time.Time {
+	if mission.Status.Failed == 0 || mission.Status.LastFailureTime == nil {
+		return time.Time{}
+	}
+	backoff := missionBackoff
+	for i := int32(1); i < mission.Status.Failed && backoff < missionMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > missionMaxBackoff {
+		backoff = missionMaxBackoff
+	}
+	return mission.Status.LastFailureTime.Add(backoff)
+}
+
+// runAttempts counts the Pods of the mission by outcome and launches
+// the next attempt when none is running, no attempt succeeded and the
+// mission still has retries and time left. Running Pods are deleted
+// once the deadline passed
+func (r *CruiseMissionReconciler) runAttempts(ctx context.Context, mission *shipv1beta1.CruiseMission, now time.Time) error {
+	log := r.Log.WithValues("cruisemission", mission.Namespace+"/"+mission.Name)
+	if mission.Status.StartTime == nil {
+		mission.Status.StartTime = &metav1.Time{Time: now}
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(mission.Namespace), client.MatchingLabels{CruiseMissionLabel: mission.Name}); err != nil {
+		return err
+	}
+	var active []*corev1.Pod
+	var succeeded, failed int32
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !metav1.IsControlledBy(pod, mission) {
+			continue
+		}
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			succeeded++
+		case corev1.PodFailed:
+			failed++
+		default:
+			if pod.DeletionTimestamp == nil {
+				active = append(active, pod)
+			}
+		}
+	}
+	if failed > mission.Status.Failed {
+		mission.Status.LastFailureTime = &metav1.Time{Time: now}
+	}
+	mission.Status.Succeeded, mission.Status.Failed = succeeded, failed
+	mission.Status.Active = int32(len(active))
+
+	if end := deadline(mission); !end.IsZero() && !now.Before(end) {
+		for _, pod := range active {
+			objdiff.Log(log, "deleting mission pod past deadline", pod, nil)
+			if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		mission.Status.Active = 0
+		return nil
+	}
+	if succeeded > 0 || len(active) > 0 || failed > backoffLimit(mission) || now.Before(retryAt(mission)) {
+		return nil
+	}
+
+	pod, err := r.missionPod(mission, fmt.Sprintf("%s-%d", mission.Name, failed))
+	if err != nil {
+		return err
+	}
+	objdiff.Log(log, "launching mission pod", nil, pod)
+	if err = r.Create(ctx, pod); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	mission.Status.Active = 1
+	return nil
+}
+
+// missionPod builds the Pod of an attempt, owned by the mission
+func (r *CruiseMissionReconciler) missionPod(mission *shipv1beta1.CruiseMission, name string) (*corev1.Pod, error) {
+	template := mission.Spec.Template
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   mission.Namespace,
+			Labels:      map[string]string{},
+			Annotations: template.Annotations,
+		},
+		Spec: *template.Spec.DeepCopy(),
+	}
+	for k, v := range template.Labels {
+		pod.Labels[k] = v
+	}
+	pod.Labels[CruiseMissionLabel] = mission.Name
+	// retries are made by the controller with new Pods
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	if err := ctrl.SetControllerReference(mission, pod, r.Scheme); err != nil {
+		return nil, err
+	}
+	return pod, nil
+}
+
+// computePhase sets the phase from the attempts and records the
+// completion time once the mission finished
+func (r *CruiseMissionReconciler) computePhase(ctx context.Context, mission *shipv1beta1.CruiseMission, now time.Time) error {
+	phase, reason, message := shipv1beta1.CruiseMissionPending, "", ""
+	status := mission.Status
+	switch end := deadline(mission); {
+	case status.Succeeded > 0:
+		phase = shipv1beta1.CruiseMissionCompleted
+	case !end.IsZero() && !now.Before(end):
+		phase = shipv1beta1.CruiseMissionFailure
+		reason = shipv1beta1.ReasonDeadlineExceeded
+		message = fmt.Sprintf("mission was active longer than %ds", *mission.Spec.ActiveDeadlineSeconds)
+	case status.Failed > backoffLimit(mission):
+		phase = shipv1beta1.CruiseMissionFailure
+		reason = shipv1beta1.ReasonBackoffLimitExceeded
+		message = fmt.Sprintf("%d attempts failed, the backoff limit is %d", status.Failed, backoffLimit(mission))
+	case status.Active > 0:
+		phase = shipv1beta1.CruiseMissionRunning
+	case status.Failed > 0:
+		message = fmt.Sprintf("retrying after %d failed attempts", status.Failed)
+	}
+	if err := lifecycle.SetPhase(ctx, r.Guards, mission, string(phase), reason, message); err != nil {
+		return err
+	}
+	// a vetoed transition keeps the mission running
+	if p := mission.Status.Phase; p == shipv1beta1.CruiseMissionCompleted || p == shipv1beta1.CruiseMissionFailure {
+		mission.Status.CompletionTime = &metav1.Time{Time: now}
+	}
+	return nil
+}
+
+// missionRequeue returns when the mission must be looked at again
+// without an event: when its backoff or deadline runs out
+func missionRequeue(mission *shipv1beta1.CruiseMission, now time.Time) time.Duration {
+	if mission.Status.CompletionTime != nil {
+		return 0
+	}
+	var after time.Duration
+	for _, at := range []time.Time{deadline(mission), retryAt(mission)} {
+		if at.IsZero() || !at.After(now) {
+			continue
+		}
+		if wait := at.Sub(now); after == 0 || wait < after {
+			after = wait
+		}
+	}
+	return after
+}
+
+func (r *CruiseMissionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.CruiseMission{}).
+		Owns(&corev1.Pod{}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("cruisemissions"), r.Budget.Wrap("cruisemission", report.Wrap("cruisemission", r, r.Reporter))))
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestCruiseMissionRetries(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	limit := int32(1)
+	mission := &shipv1beta1.CruiseMission{
+		ObjectMeta: metav1.ObjectMeta{Name: "survey", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.CruiseMissionSpec{
+			BackoffLimit: &limit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "mission", Image: "mission:v1"}}},
+			},
+		},
+	}
+	reconciler := &CruiseMissionReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	now := time.Now()
+	run := func() {
+		if err := lifecycle.RunSteps(ctx, "cruisemission", mission, reconciler.steps(now)); err != nil {
+			t.Fatalf("should run steps: %v", err)
+		}
+	}
+	finish := func(name string, phase corev1.PodPhase) {
+		pod := &corev1.Pod{}
+		if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, pod); err != nil {
+			t.Fatalf("should get pod %s: %v", name, err)
+		}
+		pod.Status.Phase = phase
+		if err := reconciler.Update(ctx, pod); err != nil {
+			t.Fatalf("should update pod: %v", err)
+		}
+	}
+	pods := func() []corev1.Pod {
+		list := &corev1.PodList{}
+		if err := reconciler.List(ctx, list, client.InNamespace("default")); err != nil {
+			t.Fatalf("should list pods: %v", err)
+		}
+		return list.Items
+	}
+
+	// 1. the first attempt is launched and never restarted in place
+	run()
+	if mission.Status.Phase != shipv1beta1.CruiseMissionRunning || mission.Status.StartTime == nil {
+		t.Fatalf("expected a Running mission, got %+v", mission.Status)
+	}
+	created := pods()
+	if len(created) != 1 || created[0].Name != "survey-0" || created[0].Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Fatalf("expected pod survey-0, got %+v", created)
+	}
+
+	// 2. a failed attempt is retried once the backoff passed
+	finish("survey-0", corev1.PodFailed)
+	run()
+	if mission.Status.Phase != shipv1beta1.CruiseMissionPending || mission.Status.Failed != 1 || len(pods()) != 1 {
+		t.Fatalf("expected to wait for the backoff, got %+v", mission.Status)
+	}
+	if after := missionRequeue(mission, now); after != missionBackoff {
+		t.Errorf("expected a requeue after %v, got %v", missionBackoff, after)
+	}
+	now = now.Add(missionBackoff)
+	run()
+	if mission.Status.Phase != shipv1beta1.CruiseMissionRunning || len(pods()) != 2 {
+		t.Fatalf("expected a second attempt, got %+v", mission.Status)
+	}
+
+	// 3. failing past the backoff limit fails the mission
+	finish("survey-1", corev1.PodFailed)
+	run()
+	if mission.Status.Phase != shipv1beta1.CruiseMissionFailure || mission.Status.Reason != shipv1beta1.ReasonBackoffLimitExceeded {
+		t.Errorf("expected BackoffLimitExceeded, got %+v", mission.Status)
+	}
+	if mission.Status.CompletionTime == nil || len(pods()) != 2 {
+		t.Errorf("expected a completion time and no new pod, got %+v", mission.Status)
+	}
+}
+
+func TestCruiseMissionCompletion(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	deadlineSeconds := int64(60)
+	newMission := func(name string) *shipv1beta1.CruiseMission {
+		return &shipv1beta1.CruiseMission{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+			Spec: shipv1beta1.CruiseMissionSpec{
+				ActiveDeadlineSeconds: &deadlineSeconds,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "mission", Image: "mission:v1"}}},
+				},
+			},
+		}
+	}
+	reconciler := &CruiseMissionReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	now := time.Now()
+	run := func(mission *shipv1beta1.CruiseMission) {
+		if err := lifecycle.RunSteps(ctx, "cruisemission", mission, reconciler.steps(now)); err != nil {
+			t.Fatalf("should run steps: %v", err)
+		}
+	}
+
+	// 1. a succeeded attempt completes the mission
+	survey := newMission("survey")
+	run(survey)
+	pod := &corev1.Pod{}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: "survey-0"}, pod); err != nil {
+		t.Fatalf("should get pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodSucceeded
+	if err := reconciler.Update(ctx, pod); err != nil {
+		t.Fatalf("should update pod: %v", err)
+	}
+	run(survey)
+	if survey.Status.Phase != shipv1beta1.CruiseMissionCompleted || survey.Status.Succeeded != 1 || survey.Status.CompletionTime == nil {
+		t.Errorf("expected a Completed mission, got %+v", survey.Status)
+	}
+
+	// 2. a mission running past its deadline fails and its Pod is stopped
+	patrol := newMission("patrol")
+	run(patrol)
+	if after := missionRequeue(patrol, now); after != time.Minute {
+		t.Errorf("expected a requeue at the deadline, got %v", after)
+	}
+	now = now.Add(time.Minute)
+	run(patrol)
+	if patrol.Status.Phase != shipv1beta1.CruiseMissionFailure || patrol.Status.Reason != shipv1beta1.ReasonDeadlineExceeded {
+		t.Errorf("expected DeadlineExceeded, got %+v", patrol.Status)
+	}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: "patrol-0"}, &corev1.Pod{}); err == nil {
+		t.Errorf("the running pod should be deleted")
+	}
+}
+
+func TestRetryAt(t *testing.T) {
+	failure := metav1.Now()
+	mission := &shipv1beta1.CruiseMission{Status: shipv1beta1.CruiseMissionStatus{LastFailureTime: &failure}}
+	for failed, expected := range map[int32]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 4: 80 * time.Second, 10: missionMaxBackoff} {
+		mission.Status.Failed = failed
+		if got := retryAt(mission).Sub(failure.Time); got != expected {
+			t.Errorf("after %d failures expected %v, got %v", failed, expected, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// DestroyerReconciler reconciles a Destroyer object
+type DestroyerReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Guards can veto phase transitions
+	Guards []lifecycle.Guard
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the Destroyer CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=destroyers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=destroyers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch
+
+func (r *DestroyerReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("destroyer", req.NamespacedName)
+
+	destroyer := &shipv1beta1.Destroyer{}
+	if err = r.Get(ctx, req.NamespacedName, destroyer); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	if destroyer.DeletionTimestamp != nil {
+		return
+	}
+
+	destroyerCopy := destroyer.DeepCopy()
+	stepErr := lifecycle.RunSteps(ctx, "destroyer", destroyerCopy, r.steps())
+	lifecycle.SetConditions("Destroyer", destroyerCopy, stepErr)
+	if stepErr == nil {
+		destroyerCopy.Status.ObservedGeneration = destroyer.Generation
+	}
+	objdiff.Log(log, "reconcile changed destroyer", destroyer, destroyerCopy)
+
+	// the checkpoint is persisted even when a step failed
+	if err = r.Status().Patch(ctx, destroyerCopy, client.MergeFrom(destroyer)); err != nil {
+		return
+	}
+	if err = stepErr; err != nil {
+		log.Error(err, "reconcile step failed", "step", destroyerCopy.Status.Checkpoint.Failed)
+	}
+	return
+}
+
+// steps of a Destroyer reconcile, in order
+func (r *DestroyerReconciler) steps() []lifecycle.Step {
+	return []lifecycle.Step{
+		destroyerStep("phase", r.computePhase),
+	}
+}
+
+// destroyerStep adapts a step written for Destroyers to lifecycle.Step
+func destroyerStep(name string, run func(ctx context.Context, destroyer *shipv1beta1.Destroyer) error) lifecycle.Step {
+	return lifecycle.Step{Name: name, Run: func(ctx context.Context, obj lifecycle.Object) error {
+		return run(ctx, obj.(*shipv1beta1.Destroyer))
+	}}
+}
+
+// computePhase follows the escorted Frigate: the Destroyer is Completed
+// once the Frigate is and fails when the Frigate does not exist
+func (r *DestroyerReconciler) computePhase(ctx context.Context, destroyer *shipv1beta1.Destroyer) error {
+	phase, reason, message := shipv1beta1.DestroyerCompleted, "", ""
+	if escort := destroyer.Spec.Escort; escort != "" {
+		frigate := &shipv1beta1.Frigate{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: destroyer.Namespace, Name: escort}, frigate)
+		switch {
+		case errors.IsNotFound(err):
+			phase = shipv1beta1.DestroyerFailure
+			reason = shipv1beta1.ReasonEscortNotFound
+			message = fmt.Sprintf("escorted Frigate %q not found", escort)
+		case err != nil:
+			return err
+		case frigate.Status.Phase != shipv1beta1.FrigateCompleted:
+			phase = shipv1beta1.DestroyerPending
+			message = fmt.Sprintf("waiting for escorted Frigate %q to be Completed", escort)
+		}
+	}
+	return lifecycle.SetPhase(ctx, r.Guards, destroyer, string(phase), reason, message)
+}
+
+// destroyersForFrigate maps a Frigate to the Destroyers escorting it
+func (r *DestroyerReconciler) destroyersForFrigate(obj handler.MapObject) (requests []ctrl.Request) {
+	destroyers := &shipv1beta1.DestroyerList{}
+	if err := r.List(context.Background(), destroyers, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "listing destroyers", "frigate", obj.Meta.GetNamespace()+"/"+obj.Meta.GetName())
+		return
+	}
+	for i := range destroyers.Items {
+		destroyer := &destroyers.Items[i]
+		if destroyer.Spec.Escort != obj.Meta.GetName() {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: destroyer.Namespace, Name: destroyer.Name},
+		})
+	}
+	return
+}
+
+func (r *DestroyerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.Destroyer{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Frigate{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.destroyersForFrigate),
+		}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("destroyers"), r.Budget.Wrap("destroyer", report.Wrap("destroyer", r, r.Reporter))))
+}
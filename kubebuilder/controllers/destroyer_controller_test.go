@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDestroyerFollowsEscort(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	destroyer := &shipv1beta1.Destroyer{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"},
+		Spec:       shipv1beta1.DestroyerSpec{Escort: "frigate"},
+	}
+	reconciler := &DestroyerReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, destroyer),
+		Log:    logf.Log,
+		Scheme: scheme,
+		Guards: []lifecycle.Guard{lifecycle.HoldGuard{}},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	reconcile := func() *shipv1beta1.Destroyer {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Destroyer{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get destroyer: %v", err)
+		}
+		return result
+	}
+
+	// 1. a missing escort fails the Destroyer
+	result := reconcile()
+	if result.Status.Phase != shipv1beta1.DestroyerFailure || result.Status.Reason != shipv1beta1.ReasonEscortNotFound {
+		t.Errorf("expected Failure with EscortNotFound got %+v", result.Status)
+	}
+	if !conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionDegraded) {
+		t.Errorf("failed destroyer should be degraded, got %+v", result.Status.Conditions)
+	}
+
+	// 2. it waits for the escort to be Completed
+	frigate := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "frigate", Namespace: "default"}}
+	if err := reconciler.Create(ctx, frigate); err != nil {
+		t.Fatalf("should create frigate: %v", err)
+	}
+	if result = reconcile(); result.Status.Phase != shipv1beta1.DestroyerPending || result.Status.Reason != "" {
+		t.Errorf("expected Pending got %+v", result.Status)
+	}
+
+	// 3. and follows it, through the shared checkpoint and conditions
+	frigate.Status.Phase = shipv1beta1.FrigateCompleted
+	if err := reconciler.Update(ctx, frigate); err != nil {
+		t.Fatalf("should update frigate: %v", err)
+	}
+	result = reconcile()
+	if result.Status.Phase != shipv1beta1.DestroyerCompleted || !conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionReady) {
+		t.Errorf("expected Completed and Ready got %+v", result.Status)
+	}
+	if result.Status.Checkpoint == nil || result.Status.Checkpoint.Completed != "phase" {
+		t.Errorf("expected the phase step to be checkpointed, got %+v", result.Status.Checkpoint)
+	}
+
+	// 4. escorted Frigate events are mapped to the Destroyer
+	if requests := reconciler.destroyersForFrigate(handler.MapObject{Meta: frigate, Object: frigate}); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected a request for the destroyer, got %v", requests)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// FleetReconciler rolls up the status of the Frigates selected by a Fleet
+type FleetReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the Fleet CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=fleets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=fleets/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch
+
+func (r *FleetReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("fleet", req.NamespacedName)
+
+	fleet := &shipv1beta1.Fleet{}
+	if err = r.Get(ctx, req.NamespacedName, fleet); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	status := fleet.Status.DeepCopy()
+	status.ObservedGeneration = fleet.Generation
+	selector, selectorErr := metav1.LabelSelectorAsSelector(fleet.Spec.Selector)
+	if selectorErr != nil {
+		// retrying does not help until the spec is fixed
+		log.Error(selectorErr, "invalid selector")
+		conditions.SetStatusCondition(&status.Conditions, shipv1beta1.Condition{
+			Type:               shipv1beta1.ConditionReady,
+			Status:             shipv1beta1.ConditionFalse,
+			ObservedGeneration: fleet.Generation,
+			Reason:             "InvalidSelector",
+			Message:            selectorErr.Error(),
+		})
+	} else {
+		frigates := &shipv1beta1.FrigateList{}
+		if err = r.List(ctx, frigates, client.InNamespace(fleet.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return
+		}
+		rollupFleet(fleet.Generation, status, frigates.Items)
+	}
+
+	if reflect.DeepEqual(*status, fleet.Status) {
+		return
+	}
+	fleetCopy := fleet.DeepCopy()
+	fleetCopy.Status = *status
+	objdiff.Log(log, "reconcile changed fleet", fleet, fleetCopy)
+	if err = r.Status().Patch(ctx, fleetCopy, client.MergeFrom(fleet)); err != nil {
+		log.Error(err, "updating fleet status")
+	}
+	return
+}
+
+// rollupFleet counts the members by phase and sets the aggregate
+// Ready condition, which is True when every member is ready
+func rollupFleet(generation int64, status *shipv1beta1.FleetStatus, members []shipv1beta1.Frigate) {
+	status.Total, status.Ready, status.Failed = int32(len(members)), 0, 0
+	for i := range members {
+		switch members[i].Status.Phase {
+		case shipv1beta1.FrigateCompleted:
+			status.Ready++
+		case shipv1beta1.FrigateFailure:
+			status.Failed++
+		}
+	}
+
+	ready := shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionReady,
+		Status:             shipv1beta1.ConditionFalse,
+		ObservedGeneration: generation,
+	}
+	switch {
+	case status.Total == 0:
+		ready.Reason, ready.Message = "NoMembers", "no Frigate matches the selector"
+	case status.Failed > 0:
+		ready.Reason, ready.Message = "MembersFailed", fmt.Sprintf("%d of %d Frigates failed", status.Failed, status.Total)
+	case status.Ready < status.Total:
+		ready.Reason, ready.Message = "MembersNotReady", fmt.Sprintf("%d of %d Frigates ready", status.Ready, status.Total)
+	default:
+		ready.Status, ready.Reason, ready.Message = shipv1beta1.ConditionTrue, "AllMembersReady", fmt.Sprintf("%d Frigates ready", status.Total)
+	}
+	conditions.SetStatusCondition(&status.Conditions, ready)
+}
+
+// fleetsForFrigate maps a Frigate to the Fleets in its namespace
+// that select it. Updates map both the old and new labels so a
+// Frigate leaving a Fleet is also accounted for
+func (r *FleetReconciler) fleetsForFrigate(obj handler.MapObject) (requests []ctrl.Request) {
+	fleets := &shipv1beta1.FleetList{}
+	if err := r.List(context.Background(), fleets, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "listing fleets", "frigate", obj.Meta.GetNamespace()+"/"+obj.Meta.GetName())
+		return
+	}
+	frigateLabels := labels.Set(obj.Meta.GetLabels())
+	for i := range fleets.Items {
+		fleet := &fleets.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(fleet.Spec.Selector)
+		if err != nil || !selector.Matches(frigateLabels) {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: fleet.Namespace, Name: fleet.Name},
+		})
+	}
+	return
+}
+
+func (r *FleetReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.Fleet{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Frigate{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.fleetsForFrigate),
+		}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("fleets"), r.Budget.Wrap("fleet", report.Wrap("fleet", r, r.Reporter))))
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func fleetMember(name, fleet string, phase shipv1beta1.FrigatePhase) *shipv1beta1.Frigate {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"fleet": fleet}},
+		Status:     shipv1beta1.FrigateStatus{Phase: phase},
+	}
+}
+
+func TestFleetRollup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	fleet := &shipv1beta1.Fleet{
+		ObjectMeta: metav1.ObjectMeta{Name: "blue", Namespace: "default", Generation: 2},
+		Spec: shipv1beta1.FleetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"fleet": "blue"}},
+		},
+	}
+	reconciler := &FleetReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, fleet,
+			fleetMember("a", "blue", shipv1beta1.FrigateCompleted),
+			fleetMember("b", "blue", shipv1beta1.FrigateFailure),
+			fleetMember("c", "blue", shipv1beta1.FrigatePending),
+			fleetMember("d", "red", shipv1beta1.FrigateCompleted),
+		),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "blue"}}
+	reconcile := func() *shipv1beta1.Fleet {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Fleet{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get fleet: %v", err)
+		}
+		return result
+	}
+
+	// 1. only selected members are counted
+	result := reconcile()
+	if result.Status.Total != 3 || result.Status.Ready != 1 || result.Status.Failed != 1 {
+		t.Errorf("unexpected counts %+v", result.Status)
+	}
+	if result.Status.ObservedGeneration != 2 {
+		t.Errorf("expected observed generation 2 got %d", result.Status.ObservedGeneration)
+	}
+	ready := conditions.FindStatusCondition(result.Status.Conditions, shipv1beta1.ConditionReady)
+	if ready == nil || ready.Status != shipv1beta1.ConditionFalse || ready.Reason != "MembersFailed" {
+		t.Errorf("fleet with failed members should not be ready, got %+v", ready)
+	}
+
+	// 2. the fleet is ready once every member is
+	for _, name := range []string{"b", "c"} {
+		member := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, member); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		member.Status.Phase = shipv1beta1.FrigateCompleted
+		if err := reconciler.Update(ctx, member); err != nil {
+			t.Fatalf("should update frigate: %v", err)
+		}
+	}
+	result = reconcile()
+	if result.Status.Ready != 3 || !conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionReady) {
+		t.Errorf("fleet should be ready, got %+v", result.Status)
+	}
+
+	// 3. member events are mapped to the fleets selecting them
+	member := fleetMember("e", "blue", shipv1beta1.FrigatePending)
+	if requests := reconciler.fleetsForFrigate(handler.MapObject{Meta: member, Object: member}); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected a request for the blue fleet, got %v", requests)
+	}
+	member = fleetMember("f", "green", shipv1beta1.FrigatePending)
+	if requests := reconciler.fleetsForFrigate(handler.MapObject{Meta: member, Object: member}); len(requests) != 0 {
+		t.Errorf("unselected frigates should not be mapped, got %v", requests)
+	}
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+)
+
+// ArmadaGuard keeps Frigates in the Pending phase until every Armada
+// limiting them admits them in its status. Admission lags behind the
+// Armada controller, a Frigate waits at most one of its reconciles
+type ArmadaGuard struct {
+	Client client.Reader
+}
+
+var _ lifecycle.Guard = ArmadaGuard{}
+
+// Name implements lifecycle.Guard
+func (ArmadaGuard) Name() string { return "armada" }
+
+// Check implements lifecycle.Guard
+func (g ArmadaGuard) Check(ctx context.Context, obj lifecycle.Object, transition lifecycle.Transition) (*lifecycle.Veto, error) {
+	if frigateRunning(shipv1beta1.FrigatePhase(transition.From)) || !frigateRunning(shipv1beta1.FrigatePhase(transition.To)) {
+		return nil, nil
+	}
+	armadas := &shipv1beta1.ArmadaList{}
+	if err := g.Client.List(ctx, armadas); err != nil {
+		return nil, err
+	}
+	key := obj.GetNamespace() + "/" + obj.GetName()
+	var full []string
+	for i := range armadas.Items {
+		armada := &armadas.Items[i]
+		if armada.Spec.MaxRunning == nil || armadaAdmits(armada, key) {
+			continue
+		}
+		member, err := g.member(ctx, armada, obj)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			full = append(full, armada.Name)
+		}
+	}
+	if len(full) == 0 {
+		return nil, nil
+	}
+	return &lifecycle.Veto{
+		Reason:  shipv1beta1.ReasonArmadaFull,
+		Message: fmt.Sprintf("waiting for a running slot of Armadas %s", strings.Join(full, ", ")),
+	}, nil
+}
+
+// armadaAdmits is true when key is in the sorted status.admitted of armada
+func armadaAdmits(armada *shipv1beta1.Armada, key string) bool {
+	i := sort.SearchStrings(armada.Status.Admitted, key)
+	return i < len(armada.Status.Admitted) && armada.Status.Admitted[i] == key
+}
+
+// member is true when the selectors of armada match obj. Armadas with
+// invalid selectors have no members
+func (g ArmadaGuard) member(ctx context.Context, armada *shipv1beta1.Armada, obj lifecycle.Object) (bool, error) {
+	frigateSelector, namespaceSelector, err := armadaSelectors(armada)
+	if err != nil || !frigateSelector.Matches(labels.Set(obj.GetLabels())) {
+		return false, nil
+	}
+	if namespaceSelector.Empty() {
+		return true, nil
+	}
+	namespace := &corev1.Namespace{}
+	if err = g.Client.Get(ctx, types.NamespacedName{Name: obj.GetNamespace()}, namespace); err != nil {
+		return false, err
+	}
+	return namespaceSelector.Matches(labels.Set(namespace.Labels)), nil
+}
+
+// frigatesForArmada maps an Armada to the Frigates it admits so
+// waiting ones leave the Pending phase as soon as they get a slot
+func (r *FrigateReconciler) frigatesForArmada(obj handler.MapObject) (requests []ctrl.Request) {
+	armada, ok := obj.Object.(*shipv1beta1.Armada)
+	if !ok {
+		return
+	}
+	for _, key := range armada.Status.Admitted {
+		parts := strings.SplitN(key, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: parts[0], Name: parts[1]},
+		})
+	}
+	return
+}
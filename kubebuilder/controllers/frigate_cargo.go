@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+)
+
+const (
+	// TemplateHashAnnotation is set on the Pod template of the cargo
+	// Deployment with the hash of the Frigate cargo template
+	TemplateHashAnnotation = "ship.danielfbm.github.io/template-hash"
+	// ConfigHashAnnotation is set on the Pod template of the cargo
+	// Deployment with the hash of the data of spec.configRef
+	ConfigHashAnnotation = "ship.danielfbm.github.io/config-hash"
+	// ClassHashAnnotation is set on the Pod template of the cargo
+	// Deployment with the hash of the ShipClass defaults
+	ClassHashAnnotation = "ship.danielfbm.github.io/class-hash"
+)
+
+// templateHash identifies a cargo template
+func templateHash(template *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(template)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:5])
+}
+
+// cargoHash identifies the Pods of a Frigate, the config data and the
+// ShipClass defaults are part of it so edits to them replace the Pods
+func cargoHash(frigate *shipv1beta1.Frigate) string {
+	hash := templateHash(frigate.Spec.CargoTemplate)
+	if frigate.Status.ConfigHash == "" && frigate.Status.ClassHash == "" {
+		return hash
+	}
+	sum := sha256.Sum256([]byte(hash + frigate.Status.ConfigHash + frigate.Status.ClassHash))
+	return hex.EncodeToString(sum[:5])
+}
+
+// reconcileCargo runs the cargo template in a Deployment named after the
+// Frigate and owned by it, the Deployment is removed when the template
+// is unset. The template, config and ShipClass hashes are annotations of
+// its Pod template so an edit to any of them rolls out new Pods
+func (r *FrigateReconciler) reconcileCargo(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	// nothing was ever created
+	if frigate.Spec.CargoTemplate == nil && frigate.Status.TemplateHash == "" {
+		return nil
+	}
+	log := r.Log.WithValues("frigate", frigate.Namespace+"/"+frigate.Name)
+	current := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: frigate.Namespace, Name: frigate.Name}, current)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && !metav1.IsControlledBy(current, frigate) {
+		return fmt.Errorf("deployment %s/%s is not owned by frigate %s", current.Namespace, current.Name, frigate.Name)
+	}
+
+	if frigate.Spec.CargoTemplate == nil {
+		if exists && current.DeletionTimestamp == nil {
+			objdiff.Log(log, "deleting cargo deployment", current, nil)
+			if err := r.Delete(ctx, current); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		frigate.Status.ReadyReplicas = 0
+		frigate.Status.TemplateHash = ""
+		return nil
+	}
+	// Pods are not created before their config and class exist
+	if frigate.Spec.ConfigRef != nil && frigate.Status.ConfigHash == "" {
+		return nil
+	}
+	if frigate.Spec.FrigateClassName != "" && frigate.Status.ClassHash == "" {
+		return nil
+	}
+	class, err := r.shipClass(ctx, frigate)
+	if err != nil {
+		return err
+	}
+
+	desired := r.cargoDeployment(frigate, class)
+	if !exists {
+		if err := ctrl.SetControllerReference(frigate, desired, r.Scheme); err != nil {
+			return err
+		}
+		objdiff.Log(log, "creating cargo deployment", nil, desired)
+		if err := r.Create(ctx, desired); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		frigate.Status.Replicas = 0
+		frigate.Status.ReadyReplicas = 0
+		frigate.Status.TemplateHash = cargoHash(frigate)
+		return nil
+	}
+
+	if !cargoDeploymentUpToDate(current, desired) {
+		previous := current.DeepCopy()
+		// the selector is immutable, it is only set on create
+		current.Spec.Replicas = desired.Spec.Replicas
+		current.Spec.Template = desired.Spec.Template
+		objdiff.Log(log, "updating cargo deployment", previous, current)
+		if err := r.Patch(ctx, current, client.MergeFrom(previous)); err != nil {
+			return err
+		}
+	}
+	frigate.Status.Replicas = current.Status.Replicas
+	frigate.Status.ReadyReplicas = current.Status.ReadyReplicas
+	frigate.Status.TemplateHash = cargoHash(frigate)
+	return nil
+}
+
+// cargoDeployment builds the Deployment of the cargo template with the
+// defaults of its ShipClass and the config of spec.configRef
+func (r *FrigateReconciler) cargoDeployment(frigate *shipv1beta1.Frigate, class *shipv1beta1.ShipClass) *appsv1.Deployment {
+	template := frigate.Spec.CargoTemplate.DeepCopy()
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	for k, v := range frigateSelector(frigate) {
+		template.Labels[k] = v
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[TemplateHashAnnotation] = templateHash(frigate.Spec.CargoTemplate)
+	if frigate.Status.ConfigHash != "" {
+		template.Annotations[ConfigHashAnnotation] = frigate.Status.ConfigHash
+	}
+	if frigate.Status.ClassHash != "" {
+		template.Annotations[ClassHashAnnotation] = frigate.Status.ClassHash
+	}
+	classDefaults(&template.Spec, class)
+	if ref := frigate.Spec.ConfigRef; ref != nil {
+		for i := range template.Spec.InitContainers {
+			template.Spec.InitContainers[i].EnvFrom = append(template.Spec.InitContainers[i].EnvFrom, configEnv(ref))
+		}
+		for i := range template.Spec.Containers {
+			template.Spec.Containers[i].EnvFrom = append(template.Spec.Containers[i].EnvFrom, configEnv(ref))
+		}
+	}
+	replicas := desiredReplicas(frigate)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      frigate.Name,
+			Namespace: frigate.Namespace,
+			Labels:    frigateSelector(frigate),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: frigateSelector(frigate)},
+			Template: *template,
+		},
+	}
+}
+
+// cargoDeploymentUpToDate compares the fields the reconciler manages.
+// Every field set in the rendered Pod template must match the live one so
+// manual edits are reverted, fields left unset are defaulted by the API
+// server and added by other controllers, those are not drift
+func cargoDeploymentUpToDate(current, desired *appsv1.Deployment) bool {
+	if current.Spec.Replicas == nil || *current.Spec.Replicas != *desired.Spec.Replicas {
+		return false
+	}
+	return equality.Semantic.DeepDerivative(desired.Spec.Template, current.Spec.Template)
+}
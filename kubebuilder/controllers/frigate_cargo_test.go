@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestCargoDeployment(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	replicas := int32(2)
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{
+			Replicas: &replicas,
+			CargoTemplate: &corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "cargo"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "cargo", Image: "cargo:v1"}}},
+			},
+		},
+	}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	key := client.ObjectKey{Namespace: "default", Name: "some"}
+	deployment := func() *appsv1.Deployment {
+		result := &appsv1.Deployment{}
+		if err := reconciler.Get(ctx, key, result); err != nil {
+			t.Fatalf("should get deployment: %v", err)
+		}
+		return result
+	}
+	reconcile := func() {
+		if err := reconciler.reconcileCargo(ctx, frigate); err != nil {
+			t.Fatalf("should reconcile cargo: %v", err)
+		}
+	}
+
+	// 1. a Deployment of spec.replicas Pods is created from the template
+	reconcile()
+	v1 := templateHash(frigate.Spec.CargoTemplate)
+	created := deployment()
+	if frigate.Status.TemplateHash != v1 || *created.Spec.Replicas != 2 {
+		t.Fatalf("expected 2 replicas of %s, got %+v and %+v", v1, created.Spec, frigate.Status)
+	}
+	if created.Spec.Selector.MatchLabels[FrigateLabel] != "some" {
+		t.Errorf("unexpected selector %v", created.Spec.Selector)
+	}
+	template := created.Spec.Template
+	if template.Labels[FrigateLabel] != "some" || template.Labels["app"] != "cargo" || template.Annotations[TemplateHashAnnotation] != v1 {
+		t.Errorf("unexpected pod template metadata %+v", template.ObjectMeta)
+	}
+	if !metav1.IsControlledBy(created, frigate) {
+		t.Errorf("deployment should be owned by the frigate")
+	}
+
+	// 2. readiness is read from the Deployment status
+	created.Status = appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 1}
+	if err := reconciler.Update(ctx, created); err != nil {
+		t.Fatalf("should update deployment: %v", err)
+	}
+	reconcile()
+	if frigate.Status.Replicas != 2 || frigate.Status.ReadyReplicas != 1 {
+		t.Errorf("expected 1 of 2 replicas ready, got %+v", frigate.Status)
+	}
+
+	// 3. a template edit changes the hash annotation so the Deployment
+	// rolls out new Pods, scaling only changes the replicas
+	frigate.Spec.CargoTemplate.Spec.Containers[0].Image = "cargo:v2"
+	replicas = 3
+	reconcile()
+	v2 := templateHash(frigate.Spec.CargoTemplate)
+	if v2 == v1 {
+		t.Fatalf("template edits should change the hash")
+	}
+	updated := deployment()
+	if updated.Spec.Template.Annotations[TemplateHashAnnotation] != v2 || updated.Spec.Template.Spec.Containers[0].Image != "cargo:v2" {
+		t.Errorf("expected the pod template of %s, got %+v", v2, updated.Spec.Template)
+	}
+	if *updated.Spec.Replicas != 3 || frigate.Status.TemplateHash != v2 {
+		t.Errorf("expected 3 replicas of %s, got %d and %+v", v2, *updated.Spec.Replicas, frigate.Status)
+	}
+
+	// 4. manual edits of the pod template are reverted, fields the API
+	// server defaults are not drift
+	edited := deployment()
+	edited.Spec.Template.Spec.Containers[0].Image = "cargo:debug"
+	if err := reconciler.Update(ctx, edited); err != nil {
+		t.Fatalf("should edit deployment: %v", err)
+	}
+	reconcile()
+	restored := deployment()
+	if image := restored.Spec.Template.Spec.Containers[0].Image; image != "cargo:v2" {
+		t.Errorf("expected the image to be restored, got %s", image)
+	}
+	restored.Spec.Template.Spec.Containers[0].ImagePullPolicy = corev1.PullIfNotPresent
+	restored.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	if err := reconciler.Update(ctx, restored); err != nil {
+		t.Fatalf("should default deployment: %v", err)
+	}
+	defaulted := deployment()
+	reconcile()
+	if current := deployment(); current.ResourceVersion != defaulted.ResourceVersion {
+		t.Errorf("defaulted fields should not be patched, got %+v", current.Spec.Template.Spec)
+	}
+
+	// 5. config and class hashes are part of the pod template
+	frigate.Status.ConfigHash = "config"
+	frigate.Status.ClassHash = "class"
+	reconcile()
+	annotations := deployment().Spec.Template.Annotations
+	if annotations[ConfigHashAnnotation] != "config" || annotations[ClassHashAnnotation] != "class" {
+		t.Errorf("expected the config and class hashes, got %v", annotations)
+	}
+	frigate.Status.ConfigHash = ""
+	frigate.Status.ClassHash = ""
+
+	// 6. removing the template removes the Deployment
+	frigate.Spec.CargoTemplate = nil
+	reconcile()
+	if err := reconciler.Get(ctx, key, &appsv1.Deployment{}); !errors.IsNotFound(err) {
+		t.Errorf("expected the deployment to be deleted, got %v", err)
+	}
+	if frigate.Status.TemplateHash != "" || frigate.Status.ReadyReplicas != 0 {
+		t.Errorf("expected the cargo status to be cleared, got %+v", frigate.Status)
+	}
+}
+
+func TestCargoDeploymentNotOwned(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{
+			CargoTemplate: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cargo", Image: "cargo:v1"}}},
+			},
+		},
+	}
+	other := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"}}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, other),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+
+	// Deployments created by someone else are never taken over
+	if err := reconciler.reconcileCargo(context.TODO(), frigate); err == nil {
+		t.Errorf("expected an error for a deployment not owned by the frigate")
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// childTracker keeps the status of the Pods of every Frigate up to date
+// from Pod watch events, so reconciles read it from memory instead of
+// listing Pods. Pods belong to the Frigate named in their FrigateLabel
+type childTracker struct {
+	lock     sync.Mutex
+	children map[types.NamespacedName]map[string]shipv1beta1.ChildStatus
+}
+
+var _ handler.EventHandler = &childTracker{}
+
+func newChildTracker() *childTracker {
+	return &childTracker{children: map[types.NamespacedName]map[string]shipv1beta1.ChildStatus{}}
+}
+
+// Create implements handler.EventHandler
+func (t *childTracker) Create(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+	t.observe(e.Object, q)
+}
+
+// Update implements handler.EventHandler
+func (t *childTracker) Update(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	// the Pod could have been relabeled to another Frigate
+	if oldKey, ok := childOwner(e.ObjectOld); ok {
+		if newKey, _ := childOwner(e.ObjectNew); newKey != oldKey {
+			t.forget(e.ObjectOld, q)
+		}
+	}
+	t.observe(e.ObjectNew, q)
+}
+
+// Delete implements handler.EventHandler
+func (t *childTracker) Delete(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	t.forget(e.Object, q)
+}
+
+// Generic implements handler.EventHandler
+func (t *childTracker) Generic(e event.GenericEvent, q workqueue.RateLimitingInterface) {
+	t.observe(e.Object, q)
+}
+
+// observe records the status of a Pod and enqueues its Frigate when it changed
+func (t *childTracker) observe(obj runtime.Object, q workqueue.RateLimitingInterface) {
+	pod, ok := obj.(*corev1.Pod)
+	key, owned := childOwner(obj)
+	if !ok || !owned {
+		return
+	}
+	child := podChildStatus(pod)
+
+	t.lock.Lock()
+	children := t.children[key]
+	if children == nil {
+		children = map[string]shipv1beta1.ChildStatus{}
+		t.children[key] = children
+	}
+	previous, known := children[pod.Name]
+	children[pod.Name] = child
+	t.lock.Unlock()
+
+	if !known || previous != child {
+		q.Add(reconcile.Request{NamespacedName: key})
+	}
+}
+
+// forget removes a Pod and enqueues its Frigate
+func (t *childTracker) forget(obj runtime.Object, q workqueue.RateLimitingInterface) {
+	pod, ok := obj.(*corev1.Pod)
+	key, owned := childOwner(obj)
+	if !ok || !owned {
+		return
+	}
+
+	t.lock.Lock()
+	delete(t.children[key], pod.Name)
+	if len(t.children[key]) == 0 {
+		delete(t.children, key)
+	}
+	t.lock.Unlock()
+
+	q.Add(reconcile.Request{NamespacedName: key})
+}
+
+// list returns at most max children of a Frigate, the ones not ready
+// first so failures are never cut off, then by name
+func (t *childTracker) list(key types.NamespacedName, max int) []shipv1beta1.ChildStatus {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if len(t.children[key]) == 0 {
+		return nil
+	}
+	list := make([]shipv1beta1.ChildStatus, 0, len(t.children[key]))
+	for _, child := range t.children[key] {
+		list = append(list, child)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Ready != list[j].Ready {
+			return !list[i].Ready
+		}
+		return list[i].Name < list[j].Name
+	})
+	if len(list) > max {
+		list = list[:max]
+	}
+	return list
+}
+
+// childOwner returns the Frigate of a Pod
+func childOwner(obj runtime.Object) (types.NamespacedName, bool) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Labels[FrigateLabel] == "" {
+		return types.NamespacedName{}, false
+	}
+	return types.NamespacedName{Namespace: pod.Namespace, Name: pod.Labels[FrigateLabel]}, true
+}
+
+// podChildStatus rolls up the readiness and the most relevant
+// container reason of a Pod
+func podChildStatus(pod *corev1.Pod) shipv1beta1.ChildStatus {
+	child := shipv1beta1.ChildStatus{Name: pod.Name}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			child.Ready = condition.Status == corev1.ConditionTrue
+		}
+	}
+	for _, container := range pod.Status.ContainerStatuses {
+		switch {
+		case container.State.Waiting != nil && container.State.Waiting.Reason != "":
+			child.Reason = container.State.Waiting.Reason
+		case container.State.Terminated != nil:
+			child.Reason = container.State.Terminated.Reason
+		case container.LastTerminationState.Terminated != nil:
+			child.Reason = container.LastTerminationState.Terminated.Reason
+		}
+		if child.Reason != "" {
+			break
+		}
+	}
+	return child
+}
+
+// computeChildren copies the tracked Pods into status
+func (r *FrigateReconciler) computeChildren(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	if r.children == nil {
+		return nil
+	}
+	frigate.Status.Children = r.children.list(types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Name}, r.MaxChildren)
+	return nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFrigateChildrenAreOwned(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	replicas := int32(2)
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{
+			Foo:      "bar",
+			Replicas: &replicas,
+			CargoTemplate: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cargo", Image: "cargo:v1"}}},
+			},
+			Expose: &shipv1beta1.ExposeSpec{Ports: []shipv1beta1.ExposePort{{Port: 80}}},
+		},
+	}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	children := func() []metav1.Object {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		deployments := &appsv1.DeploymentList{}
+		services := &corev1.ServiceList{}
+		if err := reconciler.List(ctx, deployments, client.InNamespace("default")); err != nil {
+			t.Fatalf("should list deployments: %v", err)
+		}
+		if err := reconciler.List(ctx, services, client.InNamespace("default")); err != nil {
+			t.Fatalf("should list services: %v", err)
+		}
+		var objects []metav1.Object
+		for i := range deployments.Items {
+			objects = append(objects, &deployments.Items[i])
+		}
+		for i := range services.Items {
+			objects = append(objects, &services.Items[i])
+		}
+		return objects
+	}
+
+	// 1. every child is controlled by the Frigate so deleting it
+	// garbage collects them
+	created := children()
+	if len(created) != 2 {
+		t.Fatalf("expected a deployment and a service, got %d objects", len(created))
+	}
+	for _, child := range created {
+		if !metav1.IsControlledBy(child, frigate) {
+			t.Errorf("%s should be controlled by the frigate, got %+v", child.GetName(), child.GetOwnerReferences())
+		}
+	}
+
+	// 2. deleted children are recreated by the next reconcile, the
+	// controller owns them to be triggered
+	for _, child := range created {
+		if err := reconciler.Delete(ctx, child.(runtime.Object)); err != nil {
+			t.Fatalf("should delete %s: %v", child.GetName(), err)
+		}
+	}
+	if recreated := children(); len(recreated) != 2 {
+		t.Errorf("expected the children to be recreated, got %d objects", len(recreated))
+	}
+}
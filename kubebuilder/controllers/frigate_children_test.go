@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func childPod(name, frigate string, ready bool, waiting string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{FrigateLabel: frigate}}}
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+	if waiting != "" {
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}},
+		}}
+	}
+	return pod
+}
+
+func TestChildTracker(t *testing.T) {
+	tracker := newChildTracker()
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	key := types.NamespacedName{Namespace: "default", Name: "some"}
+
+	// 1. pods are tracked from events, not ready ones first
+	for _, pod := range []*corev1.Pod{
+		childPod("some-a", "some", true, ""),
+		childPod("some-b", "some", false, "CrashLoopBackOff"),
+		childPod("some-c", "some", true, ""),
+		childPod("other-a", "other", true, ""),
+	} {
+		tracker.Create(event.CreateEvent{Meta: pod, Object: pod}, queue)
+	}
+	expected := []shipv1beta1.ChildStatus{
+		{Name: "some-b", Ready: false, Reason: "CrashLoopBackOff"},
+		{Name: "some-a", Ready: true},
+		{Name: "some-c", Ready: true},
+	}
+	if children := tracker.list(key, 10); !reflect.DeepEqual(children, expected) {
+		t.Errorf("expected %+v got %+v", expected, children)
+	}
+	if queue.Len() != 2 {
+		t.Errorf("both frigates should be enqueued, got %d", queue.Len())
+	}
+
+	// 2. the list is capped
+	if children := tracker.list(key, 2); len(children) != 2 || children[0].Name != "some-b" {
+		t.Errorf("should keep the first 2 children, got %+v", children)
+	}
+
+	// 3. updates without changes do not enqueue
+	drain(queue)
+	old, pod := childPod("some-a", "some", true, ""), childPod("some-a", "some", true, "")
+	tracker.Update(event.UpdateEvent{MetaOld: old, ObjectOld: old, MetaNew: pod, ObjectNew: pod}, queue)
+	if queue.Len() != 0 {
+		t.Errorf("unchanged pod should not enqueue")
+	}
+
+	// 4. deleted pods are forgotten
+	tracker.Delete(event.DeleteEvent{Meta: pod, Object: pod}, queue)
+	if children := tracker.list(key, 10); len(children) != 2 || queue.Len() != 1 {
+		t.Errorf("deleted pod should be forgotten and enqueue its frigate, got %+v", children)
+	}
+}
+
+func drain(queue workqueue.RateLimitingInterface) {
+	for queue.Len() > 0 {
+		item, _ := queue.Get()
+		queue.Done(item)
+	}
+}
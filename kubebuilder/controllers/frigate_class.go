@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// frigateClassField indexes Frigates by their spec.frigateClassName
+const frigateClassField = ".spec.frigateClassName"
+
+// frigateClassIndex is the client.IndexerFunc of frigateClassField
+func frigateClassIndex(obj runtime.Object) []string {
+	frigate, ok := obj.(*shipv1beta1.Frigate)
+	if !ok || frigate.Spec.FrigateClassName == "" {
+		return nil
+	}
+	return []string{frigate.Spec.FrigateClassName}
+}
+
+// classController returns the controller name of the ShipClasses
+// handled by this reconciler
+func (r *FrigateReconciler) classController() string {
+	if r.ClassController != "" {
+		return r.ClassController
+	}
+	return shipv1beta1.DefaultShipClassController
+}
+
+// shipClass returns the ShipClass of frigate, nil when the Frigate has
+// no class or the class does not exist
+func (r *FrigateReconciler) shipClass(ctx context.Context, frigate *shipv1beta1.Frigate) (*shipv1beta1.ShipClass, error) {
+	if frigate.Spec.FrigateClassName == "" {
+		return nil, nil
+	}
+	class := &shipv1beta1.ShipClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: frigate.Spec.FrigateClassName}, class); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return class, nil
+}
+
+// ownsClass tells if Frigates of class are handled by this reconciler.
+// Frigates without a class are, so are the ones of a missing class
+// which are reported Pending until it is created
+func (r *FrigateReconciler) ownsClass(class *shipv1beta1.ShipClass) bool {
+	return class == nil || class.Spec.Controller == r.classController()
+}
+
+// loadClass records the hash of the defaults of the ShipClass, it is
+// left empty while the class does not exist so no Pods are created
+// without its defaults
+func (r *FrigateReconciler) loadClass(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	frigate.Status.ClassHash = ""
+	class, err := r.shipClass(ctx, frigate)
+	if err != nil || class == nil {
+		return err
+	}
+	raw, err := json.Marshal([]interface{}{class.Spec.Image, class.Spec.Resources})
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	frigate.Status.ClassHash = hex.EncodeToString(sum[:5])
+	return nil
+}
+
+// classPending returns a reason and message while the ShipClass of
+// the Frigate is missing, both are empty otherwise
+func classPending(frigate *shipv1beta1.Frigate) (reason, message string) {
+	if name := frigate.Spec.FrigateClassName; name != "" && frigate.Status.ClassHash == "" {
+		return shipv1beta1.ReasonShipClassNotFound, fmt.Sprintf("ShipClass %q not found", name)
+	}
+	return
+}
+
+// classDefaults sets the image and resources of the ShipClass on the
+// containers leaving them empty
+func classDefaults(spec *corev1.PodSpec, class *shipv1beta1.ShipClass) {
+	if class == nil {
+		return
+	}
+	apply := func(container *corev1.Container) {
+		if container.Image == "" {
+			container.Image = class.Spec.Image
+		}
+		if container.Resources.Limits == nil && container.Resources.Requests == nil {
+			container.Resources = *class.Spec.Resources.DeepCopy()
+		}
+	}
+	for i := range spec.InitContainers {
+		apply(&spec.InitContainers[i])
+	}
+	for i := range spec.Containers {
+		apply(&spec.Containers[i])
+	}
+}
+
+// classRequeue shortens after to the reconcile interval of the ShipClass
+func classRequeue(class *shipv1beta1.ShipClass, after time.Duration) time.Duration {
+	if class == nil || class.Spec.ReconcileInterval == nil || class.Spec.ReconcileInterval.Duration <= 0 {
+		return after
+	}
+	if interval := class.Spec.ReconcileInterval.Duration; after == 0 || interval < after {
+		return interval
+	}
+	return after
+}
+
+// frigatesForClass maps a ShipClass to the Frigates of the class so
+// changes to its defaults or controller are picked up
+func (r *FrigateReconciler) frigatesForClass(obj handler.MapObject) (requests []ctrl.Request) {
+	frigates := &shipv1beta1.FrigateList{}
+	if err := r.List(context.Background(), frigates, client.MatchingFields{frigateClassField: obj.Meta.GetName()}); err != nil {
+		r.Log.Error(err, "listing frigates", "shipclass", obj.Meta.GetName())
+		return
+	}
+	for i := range frigates.Items {
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: frigates.Items[i].Namespace, Name: frigates.Items[i].Name},
+		})
+	}
+	return
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFrigateShipClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{
+			FrigateClassName: "fast",
+			CargoTemplate: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cargo"}}},
+			},
+		},
+	}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	reconcile := func() (ctrl.Result, *shipv1beta1.Frigate) {
+		res, err := reconciler.Reconcile(req)
+		if err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		return res, result
+	}
+	deployments := func() []appsv1.Deployment {
+		list := &appsv1.DeploymentList{}
+		if err := reconciler.List(ctx, list, client.InNamespace("default")); err != nil {
+			t.Fatalf("should list deployments: %v", err)
+		}
+		return list.Items
+	}
+
+	// 1. no Deployment is created while the ShipClass is missing
+	_, result := reconcile()
+	if result.Status.Phase != shipv1beta1.FrigatePending || result.Status.Reason != shipv1beta1.ReasonShipClassNotFound {
+		t.Errorf("expected Pending with ShipClassNotFound, got %+v", result.Status)
+	}
+	if created := deployments(); len(created) != 0 {
+		t.Errorf("expected no deployments, got %d", len(created))
+	}
+
+	// 2. once it exists its defaults are merged into the pod template
+	class := &shipv1beta1.ShipClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "fast"},
+		Spec: shipv1beta1.ShipClassSpec{
+			Controller: shipv1beta1.DefaultShipClassController,
+			Image:      "cargo:v1",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			},
+			ReconcileInterval: &metav1.Duration{Duration: time.Minute},
+		},
+	}
+	if err := reconciler.Create(ctx, class); err != nil {
+		t.Fatalf("should create shipclass: %v", err)
+	}
+	res, result := reconcile()
+	if result.Status.Phase != shipv1beta1.FrigateCompleted || result.Status.ClassHash == "" {
+		t.Errorf("expected Completed with a class hash, got %+v", result.Status)
+	}
+	if res.RequeueAfter != time.Minute {
+		t.Errorf("expected a requeue after the class interval, got %v", res.RequeueAfter)
+	}
+	created := deployments()
+	if len(created) != 1 {
+		t.Fatalf("expected 1 deployment, got %d", len(created))
+	}
+	template := created[0].Spec.Template
+	container := template.Spec.Containers[0]
+	if container.Image != "cargo:v1" || container.Resources.Requests.Cpu().String() != "100m" {
+		t.Errorf("pods should use the class defaults, got %+v", container)
+	}
+	if template.Annotations[ClassHashAnnotation] != result.Status.ClassHash {
+		t.Errorf("expected the class hash on the pod template, got %v", template.Annotations)
+	}
+
+	// 3. Frigates of classes handled by another controller are ignored
+	class.Spec.Controller = "example.com/other"
+	class.Spec.Image = "cargo:v2"
+	if err := reconciler.Update(ctx, class); err != nil {
+		t.Fatalf("should update shipclass: %v", err)
+	}
+	res, ignored := reconcile()
+	if ignored.Status.ClassHash != result.Status.ClassHash || res.RequeueAfter != 0 {
+		t.Errorf("frigate should be left alone, got %+v and %+v", ignored.Status, res)
+	}
+	if left := deployments(); len(left) != 1 || left[0].Spec.Template.Spec.Containers[0].Image != "cargo:v1" {
+		t.Errorf("deployment should be left alone")
+	}
+}
+
+func TestClassRequeue(t *testing.T) {
+	class := &shipv1beta1.ShipClass{Spec: shipv1beta1.ShipClassSpec{ReconcileInterval: &metav1.Duration{Duration: time.Minute}}}
+	for _, test := range []struct {
+		class    *shipv1beta1.ShipClass
+		after    time.Duration
+		expected time.Duration
+	}{
+		{nil, 0, 0},
+		{nil, time.Hour, time.Hour},
+		{class, 0, time.Minute},
+		{class, time.Hour, time.Minute},
+		{class, time.Second, time.Second},
+	} {
+		if got := classRequeue(test.class, test.after); got != test.expected {
+			t.Errorf("classRequeue(%v) expected %v, got %v", test.after, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/trigger"
+)
+
+// coldStart orders the initial sync after a restart.
+// Every existing Frigate arrives as a create event when the informer
+// lists them, settled ones are held back for a window so Frigates still
+// converging or being deleted are first in the queue, then the held back
+// ones are enqueued behind them
+type coldStart struct {
+	Window time.Duration
+	Log    logr.Logger
+
+	triggers *trigger.Channel
+
+	lock     sync.Mutex
+	done     bool
+	deferred []types.NamespacedName
+}
+
+func newColdStart(window time.Duration, log logr.Logger) *coldStart {
+	return &coldStart{
+		Window:   window,
+		Log:      log,
+		triggers: trigger.NewChannel(100, func() trigger.Object { return &shipv1beta1.Frigate{} }),
+	}
+}
+
+// settled returns true for Frigates that do not need urgent attention:
+// not being deleted, with the latest spec observed and a Completed phase
+func settled(frigate *shipv1beta1.Frigate) bool {
+	return frigate.DeletionTimestamp == nil &&
+		frigate.Status.ObservedGeneration == frigate.Generation &&
+		frigate.Status.Phase == shipv1beta1.FrigateCompleted
+}
+
+// Predicate holds back create events of settled Frigates during the window
+func (c *coldStart) Predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			frigate, ok := e.Object.(*shipv1beta1.Frigate)
+			if !ok || !settled(frigate) {
+				return true
+			}
+			c.lock.Lock()
+			defer c.lock.Unlock()
+			if c.done {
+				return true
+			}
+			c.deferred = append(c.deferred, types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Name})
+			return false
+		},
+	}
+}
+
+// Start implements manager.Runnable, it ends the cold start after
+// the window and enqueues the Frigates held back
+func (c *coldStart) Start(stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return nil
+	case <-time.After(c.Window):
+	}
+
+	c.lock.Lock()
+	c.done = true
+	deferred := c.deferred
+	c.deferred = nil
+	c.lock.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+	c.Log.Info("cold start finished, enqueuing settled frigates", "count", len(deferred))
+	for _, key := range deferred {
+		if err := c.triggers.Enqueue(ctx, key); err != nil {
+			// stopping
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func coldStartFrigate(name string, phase shipv1beta1.FrigatePhase, generation, observed int64) *shipv1beta1.Frigate {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: generation},
+		Status:     shipv1beta1.FrigateStatus{Phase: phase, ObservedGeneration: observed},
+	}
+}
+
+func TestColdStartOrdering(t *testing.T) {
+	coldStart := newColdStart(10*time.Millisecond, logf.Log)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	stop := make(chan struct{})
+	defer close(stop)
+
+	src := coldStart.triggers.Source().(*source.Channel)
+	src.InjectStopChannel(stop)
+	if err := src.Start(&handler.EnqueueRequestForObject{}, queue); err != nil {
+		t.Fatalf("should start source: %v", err)
+	}
+
+	// the informer lists existing frigates in no particular order
+	deleting := coldStartFrigate("deleting", shipv1beta1.FrigateCompleted, 1, 1)
+	now := metav1.Now()
+	deleting.DeletionTimestamp = &now
+	frigates := []*shipv1beta1.Frigate{
+		coldStartFrigate("settled-a", shipv1beta1.FrigateCompleted, 1, 1),
+		coldStartFrigate("new", "", 1, 0),
+		coldStartFrigate("settled-b", shipv1beta1.FrigateCompleted, 3, 3),
+		deleting,
+		coldStartFrigate("edited", shipv1beta1.FrigateCompleted, 2, 1),
+		coldStartFrigate("settled-c", shipv1beta1.FrigateCompleted, 1, 1),
+	}
+	urgent := map[string]bool{"new": true, "deleting": true, "edited": true}
+
+	predicate := coldStart.Predicate()
+	for _, frigate := range frigates {
+		if predicate.Create(event.CreateEvent{Meta: frigate, Object: frigate}) {
+			queue.Add(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Name}})
+		}
+	}
+	if err := coldStart.Start(stop); err != nil {
+		t.Fatalf("should finish cold start: %v", err)
+	}
+
+	// every frigate is reconciled once, urgent ones first
+	var order []string
+	for len(order) < len(frigates) {
+		item, _ := queue.Get()
+		order = append(order, item.(reconcile.Request).Name)
+		queue.Done(item)
+	}
+	for i, name := range order {
+		if urgent[name] != (i < len(urgent)) {
+			t.Errorf("frigates needing attention should be reconciled first, got %v", order)
+			break
+		}
+	}
+
+	// after the cold start nothing is held back
+	late := coldStartFrigate("late", shipv1beta1.FrigateCompleted, 1, 1)
+	if !predicate.Create(event.CreateEvent{Meta: late, Object: late}) {
+		t.Errorf("settled frigates should not be held back after the cold start")
+	}
+}
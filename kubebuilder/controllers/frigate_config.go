@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// configRefField indexes Frigates by the kind/name of their spec.configRef
+const configRefField = ".spec.configRef"
+
+// configRefIndex is the client.IndexerFunc of configRefField
+func configRefIndex(obj runtime.Object) []string {
+	frigate, ok := obj.(*shipv1beta1.Frigate)
+	if !ok || frigate.Spec.ConfigRef == nil {
+		return nil
+	}
+	return []string{frigate.Spec.ConfigRef.Kind + "/" + frigate.Spec.ConfigRef.Name}
+}
+
+// loadConfig records the hash of the data of spec.configRef, it is left
+// empty while the object does not exist so no Pods are created without it
+func (r *FrigateReconciler) loadConfig(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	ref := frigate.Spec.ConfigRef
+	frigate.Status.ConfigHash = ""
+	if ref == nil {
+		return nil
+	}
+	var data interface{}
+	key := types.NamespacedName{Namespace: frigate.Namespace, Name: ref.Name}
+	switch ref.Kind {
+	case "ConfigMap":
+		configMap := &corev1.ConfigMap{}
+		if err := r.Get(ctx, key, configMap); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		data = []interface{}{configMap.Data, configMap.BinaryData}
+	case "Secret":
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, key, secret); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+		data = secret.Data
+	default:
+		return fmt.Errorf("unsupported configRef kind %q", ref.Kind)
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(raw)
+	frigate.Status.ConfigHash = hex.EncodeToString(sum[:5])
+	return nil
+}
+
+// configPending returns a reason and message while the object referenced
+// by spec.configRef is missing, both are empty otherwise
+func configPending(frigate *shipv1beta1.Frigate) (reason, message string) {
+	if ref := frigate.Spec.ConfigRef; ref != nil && frigate.Status.ConfigHash == "" {
+		return shipv1beta1.ReasonConfigNotFound, fmt.Sprintf("%s %q not found", ref.Kind, ref.Name)
+	}
+	return
+}
+
+// configEnv exposes the data of spec.configRef as environment variables
+func configEnv(ref *shipv1beta1.ConfigReference) corev1.EnvFromSource {
+	if ref.Kind == "Secret" {
+		return corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+		}}
+	}
+	return corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+	}}
+}
+
+// frigatesForConfig maps a ConfigMap or Secret to the Frigates
+// referencing it so edits to the data roll out new Pods
+func (r *FrigateReconciler) frigatesForConfig(kind string) handler.ToRequestsFunc {
+	return func(obj handler.MapObject) (requests []ctrl.Request) {
+		frigates := &shipv1beta1.FrigateList{}
+		if err := r.List(context.Background(), frigates, client.InNamespace(obj.Meta.GetNamespace()),
+			client.MatchingFields{configRefField: kind + "/" + obj.Meta.GetName()}); err != nil {
+			r.Log.Error(err, "listing frigates", "config", kind+" "+obj.Meta.GetNamespace()+"/"+obj.Meta.GetName())
+			return
+		}
+		for i := range frigates.Items {
+			requests = append(requests, ctrl.Request{
+				NamespacedName: types.NamespacedName{Namespace: frigates.Items[i].Namespace, Name: frigates.Items[i].Name},
+			})
+		}
+		return
+	}
+}
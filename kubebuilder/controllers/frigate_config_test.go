@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestConfigRefIndex(t *testing.T) {
+	frigate := &shipv1beta1.Frigate{Spec: shipv1beta1.FrigateSpec{
+		ConfigRef: &shipv1beta1.ConfigReference{Kind: "Secret", Name: "creds"},
+	}}
+	if values := configRefIndex(frigate); !reflect.DeepEqual(values, []string{"Secret/creds"}) {
+		t.Errorf("unexpected index values %v", values)
+	}
+	if values := configRefIndex(&shipv1beta1.Frigate{}); values != nil {
+		t.Errorf("frigates without configRef should not be indexed, got %v", values)
+	}
+}
+
+func TestFrigateConfigRef(t *testing.T) {
+	scheme := runtime.NewScheme()
+	appsv1.AddToScheme(scheme)
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{
+			ConfigRef: &shipv1beta1.ConfigReference{Kind: "ConfigMap", Name: "settings"},
+			CargoTemplate: &corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "cargo", Image: "cargo:v1"}}},
+			},
+		},
+	}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	reconcile := func() *shipv1beta1.Frigate {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		return result
+	}
+	deployments := func() []appsv1.Deployment {
+		list := &appsv1.DeploymentList{}
+		if err := reconciler.List(ctx, list, client.InNamespace("default")); err != nil {
+			t.Fatalf("should list deployments: %v", err)
+		}
+		return list.Items
+	}
+
+	// 1. no Deployment is created while the ConfigMap is missing
+	result := reconcile()
+	if result.Status.Phase != shipv1beta1.FrigatePending || result.Status.Reason != shipv1beta1.ReasonConfigNotFound {
+		t.Errorf("expected Pending with ConfigNotFound, got %+v", result.Status)
+	}
+	if created := deployments(); len(created) != 0 {
+		t.Errorf("expected no deployments, got %d", len(created))
+	}
+
+	// 2. once it exists the Pods load it as environment variables
+	settings := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"},
+		Data:       map[string]string{"SPEED": "10"},
+	}
+	if err := reconciler.Create(ctx, settings); err != nil {
+		t.Fatalf("should create configmap: %v", err)
+	}
+	result = reconcile()
+	if result.Status.Phase != shipv1beta1.FrigateCompleted || result.Status.ConfigHash == "" {
+		t.Errorf("expected Completed with a config hash, got %+v", result.Status)
+	}
+	created := deployments()
+	if len(created) != 1 {
+		t.Fatalf("expected 1 deployment, got %d", len(created))
+	}
+	envFrom := created[0].Spec.Template.Spec.Containers[0].EnvFrom
+	if len(envFrom) != 1 || envFrom[0].ConfigMapRef == nil || envFrom[0].ConfigMapRef.Name != "settings" {
+		t.Errorf("pods should load the configmap, got %+v", envFrom)
+	}
+
+	// 3. editing the data changes the config hash of the pod template so
+	// the Deployment rolls out new Pods
+	hash := result.Status.TemplateHash
+	settings.Data["SPEED"] = "20"
+	if err := reconciler.Update(ctx, settings); err != nil {
+		t.Fatalf("should update configmap: %v", err)
+	}
+	if result = reconcile(); result.Status.TemplateHash == hash {
+		t.Errorf("config edits should change the pod hash")
+	}
+	rolling := deployments()
+	if len(rolling) != 1 || rolling[0].Spec.Template.Annotations[ConfigHashAnnotation] != result.Status.ConfigHash {
+		t.Errorf("expected the new config hash on the pod template, got %+v", rolling)
+	}
+}
@@ -2,14 +2,31 @@ package controllers
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"time"
 
 	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/cloudevents"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/pressure"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/trigger"
+	toolscache "k8s.io/client-go/tools/cache"
 )
 
 // FrigateReconciler reconciles a Frigate object
@@ -17,10 +34,53 @@ type FrigateReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
+
+	// Events publishes lifecycle transitions, optional
+	Events *cloudevents.Publisher
+	// TombstoneTTL keeps a FrigateTombstone for deleted Frigates
+	// so they can be restored, disabled when zero
+	TombstoneTTL time.Duration
+
+	// Guards can veto phase transitions
+	Guards []lifecycle.Guard
+	// Triggers enqueue Frigates from outside the controller, optional
+	Triggers *trigger.Channel
+	// MaxChildren caps the Pods reported in status, Pods are not
+	// watched when zero
+	MaxChildren int
+	// ColdStartWindow holds back settled Frigates after a restart so
+	// the ones needing attention are reconciled first, disabled when zero
+	ColdStartWindow time.Duration
+	// ClassController is the controller name of the ShipClasses handled,
+	// Frigates of other classes are ignored. Defaults to
+	// shipv1beta1.DefaultShipClassController
+	ClassController string
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the Frigate CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+	// Pressure postpones the deletion of expired Frigates while the
+	// API server is under pressure, optional
+	Pressure pressure.Shedder
+
+	children *childTracker
 }
 
 // +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetombstones,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=harbors,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=shipclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=armadas,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 
 func (r *FrigateReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
 	ctx := context.Background()
@@ -36,25 +96,233 @@ func (r *FrigateReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err
 		}
 		return
 	}
+	r = r.dryRun(frigate, log)
+	class, err := r.shipClass(ctx, frigate)
+	if err != nil {
+		return
+	}
+	if !r.ownsClass(class) {
+		log.V(1).Info("ignoring frigate of another controller", "shipclass", class.Name, "controller", class.Spec.Controller)
+		return
+	}
+
+	if frigate.DeletionTimestamp != nil {
+		err = r.finalize(ctx, frigate)
+		return
+	}
+	if frigate.Spec.Suspend {
+		err = r.suspend(ctx, frigate)
+		return
+	}
 
 	frigateCopy := frigate.DeepCopy()
+	stepErr := lifecycle.RunSteps(ctx, "frigate", frigateCopy, r.steps())
+	lifecycle.SetConditions("Frigate", frigateCopy, stepErr)
+	resume(frigateCopy)
+	objdiff.Log(log, "reconcile changed frigate", frigate, frigateCopy)
+	recordPhaseTransition(frigate, frigateCopy, metav1.Now())
+	if stepErr == nil {
+		frigateCopy.Status.ObservedGeneration = frigate.Generation
+		recordFirstTimes(frigateCopy, metav1.Now())
+	}
+
+	// status is a subresource, metadata changes like finalizers
+	// and the status are patched separately so spec edits made
+	// while reconciling are never overwritten by this copy
+	if !reflect.DeepEqual(frigate.ObjectMeta, frigateCopy.ObjectMeta) {
+		status := frigateCopy.Status
+		if err = r.Patch(ctx, frigateCopy, client.MergeFrom(frigate)); err != nil {
+			return
+		}
+		frigateCopy.Status = status
+	}
+	// the checkpoint is persisted even when a step failed
+	base := frigateCopy.DeepCopy()
+	base.Status = frigate.Status
+	if err = r.Status().Patch(ctx, frigateCopy, client.MergeFrom(base)); err != nil {
+		return
+	}
+	if err = stepErr; err != nil {
+		log.Error(err, "reconcile step failed", "step", frigateCopy.Status.Checkpoint.Failed)
+		return
+	}
+	observeFirstTimes(frigate, frigateCopy)
+	observePhaseTransition(frigate, frigateCopy)
+	r.publishTransition(ctx, frigate, frigateCopy)
+	if result.RequeueAfter, err = r.expire(ctx, frigateCopy, time.Now()); err != nil {
+		return
+	}
+	result.RequeueAfter = classRequeue(class, result.RequeueAfter)
+	return
+}
+
+// steps of a Frigate reconcile, in order
+func (r *FrigateReconciler) steps() []lifecycle.Step {
+	return []lifecycle.Step{
+		frigateStep("finalizers", r.ensureFinalizers),
+		frigateStep("replicas", r.computeReplicas),
+		// the ShipClass and config can change without a spec edit
+		alwaysStep(frigateStep("class", r.loadClass)),
+		alwaysStep(frigateStep("config", r.loadConfig)),
+		frigateStep("cargo", r.reconcileCargo),
+		frigateStep("service", r.reconcileService),
+		frigateStep("children", r.computeChildren),
+		frigateStep("phase", r.computePhase),
+		frigateStep("labels", r.mirrorLabels),
+	}
+}
+
+// alwaysStep marks s to run even when resuming after it
+func alwaysStep(s lifecycle.Step) lifecycle.Step {
+	s.Always = true
+	return s
+}
+
+// frigateStep adapts a step written for Frigates to lifecycle.Step
+func frigateStep(name string, run func(ctx context.Context, frigate *shipv1beta1.Frigate) error) lifecycle.Step {
+	return lifecycle.Step{Name: name, Run: func(ctx context.Context, obj lifecycle.Object) error {
+		return run(ctx, obj.(*shipv1beta1.Frigate))
+	}}
+}
+
+// ensureFinalizers adds the finalizers required before any other work
+func (r *FrigateReconciler) ensureFinalizers(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	if r.TombstoneTTL > 0 {
+		addFinalizer(frigate, TombstoneFinalizer)
+	}
+	return nil
+}
+
+// computePhase sets the status phase unless a guard vetoes the transition
+func (r *FrigateReconciler) computePhase(ctx context.Context, frigate *shipv1beta1.Frigate) error {
 	// this logic is simple enough, the point being
 	// how to write unit tests (check _test.go file)
-	if req.Name == "another" {
-		frigateCopy.Status.Phase = "Failure"
-	} else {
-		frigateCopy.Status.Phase = "Completed"
+	phase, reason, message := shipv1beta1.FrigateCompleted, "", ""
+	if frigate.Name == "another" {
+		phase = shipv1beta1.FrigateFailure
+		reason = shipv1beta1.ReasonNameReserved
+		message = fmt.Sprintf("the name %q is reserved and cannot be used by a Frigate", frigate.Name)
+	} else if classReason, classMessage := classPending(frigate); classReason != "" {
+		phase, reason, message = shipv1beta1.FrigatePending, classReason, classMessage
+	} else if configReason, configMessage := configPending(frigate); configReason != "" {
+		phase, reason, message = shipv1beta1.FrigatePending, configReason, configMessage
+	} else if frigate.Status.Phase != shipv1beta1.FrigateCompleted {
+		// only Frigates docked at a Ready Harbor become Completed
+		harborReason, harborMessage, err := r.harborPending(ctx, frigate)
+		if err != nil {
+			return err
+		}
+		if harborReason != "" {
+			phase, reason, message = shipv1beta1.FrigatePending, harborReason, harborMessage
+		}
 	}
 
-	err = r.Update(ctx, frigateCopy)
-	return
+	return lifecycle.SetPhase(ctx, r.Guards, frigate, string(phase), reason, message)
+}
+
+// publishTransition emits lifecycle CloudEvents when the phase changed
+func (r *FrigateReconciler) publishTransition(ctx context.Context, before, after *shipv1beta1.Frigate) {
+	if r.Events == nil || before.Status.Phase == after.Status.Phase {
+		return
+	}
+	data := frigateEventData(after)
+	data.PreviousPhase = string(before.Status.Phase)
+	if before.Status.Phase == "" {
+		if err := r.Events.Publish(ctx, cloudevents.FrigateCreated, data); err != nil {
+			r.Log.Error(err, "publishing created event", "frigate", data.Name, "namespace", data.Namespace)
+		}
+	}
+	if err := r.Events.Publish(ctx, cloudevents.FrigatePhaseChanged, data); err != nil {
+		r.Log.Error(err, "publishing phase changed event", "frigate", data.Name, "namespace", data.Namespace)
+	}
+}
+
+func frigateEventData(frigate *shipv1beta1.Frigate) cloudevents.FrigateData {
+	return cloudevents.FrigateData{
+		Namespace: frigate.Namespace,
+		Name:      frigate.Name,
+		UID:       string(frigate.UID),
+		Phase:     string(frigate.Status.Phase),
+	}
+}
+
+// publishDeletes emits deleted CloudEvents from the shared informer
+// deletions never reach Reconcile with the object so they are taken
+// straight from the watch
+func (r *FrigateReconciler) publishDeletes(mgr ctrl.Manager) error {
+	informer, err := mgr.GetCache().GetInformer(&shipv1beta1.Frigate{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			frigate, ok := obj.(*shipv1beta1.Frigate)
+			// followers keep a warm cache too, only the leader publishes
+			if !ok || !r.Events.Running() {
+				return
+			}
+			if err := r.Events.Publish(context.Background(), cloudevents.FrigateDeleted, frigateEventData(frigate)); err != nil {
+				r.Log.Error(err, "publishing deleted event", "frigate", frigate.Name, "namespace", frigate.Namespace)
+			}
+		},
+	})
+	return nil
 }
 
 func (r *FrigateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Client = mgr.GetClient()
 	r.Scheme = mgr.GetScheme()
 
-	return ctrl.NewControllerManagedBy(mgr).
+	if r.Events != nil {
+		if err := r.publishDeletes(mgr); err != nil {
+			return err
+		}
+	}
+
+	if err := mgr.GetFieldIndexer().IndexField(&shipv1beta1.Frigate{}, configRefField, configRefIndex); err != nil {
+		return err
+	}
+	if err := mgr.GetFieldIndexer().IndexField(&shipv1beta1.Frigate{}, frigateClassField, frigateClassIndex); err != nil {
+		return err
+	}
+
+	builder := ctrl.NewControllerManagedBy(mgr).
 		For(&shipv1beta1.Frigate{}).
-		Complete(r)
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Harbor{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.frigatesForHarbor),
+		}).
+		Watches(&source.Kind{Type: &shipv1beta1.ShipClass{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.frigatesForClass),
+		}).
+		Watches(&source.Kind{Type: &shipv1beta1.Armada{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.frigatesForArmada),
+		}).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: r.frigatesForConfig("ConfigMap"),
+		}).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: r.frigatesForConfig("Secret"),
+		})
+	if r.Triggers != nil {
+		builder = builder.Watches(r.Triggers.Source(), &handler.EnqueueRequestForObject{})
+	}
+	if r.MaxChildren > 0 {
+		r.children = newChildTracker()
+		builder = builder.Watches(&source.Kind{Type: &corev1.Pod{}}, r.children)
+	}
+	if r.ColdStartWindow > 0 {
+		coldStart := newColdStart(r.ColdStartWindow, r.Log.WithName("coldstart"))
+		if err := mgr.Add(coldStart); err != nil {
+			return err
+		}
+		builder = builder.
+			WithEventFilter(coldStart.Predicate()).
+			Watches(coldStart.triggers.Source(), &handler.EnqueueRequestForObject{})
+	}
+	return builder.Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("frigates"), r.Budget.Wrap("frigate", report.Wrap("frigate", r, r.Reporter))))
 }
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+/*
+The workshop repeats that a reconcile loop must be idempotent: running it
+again on an object it already handled should not change anything.
+Instead of trusting a couple of hand picked examples, here we let
+testing/quick generate random valid Frigates and check that a second
+Reconcile produces exactly the same state as the first one.
+
+These tests use the fake client, so they run without envtest.
+*/
+
+// randomFrigate is a quick.Generator for valid Frigate objects
+type randomFrigate struct {
+	Frigate *shipv1beta1.Frigate
+}
+
+// names reused by the generator, "another" is special cased by the reconciler
+var randomFrigateNames = []string{"some", "another", "frigate-a", "frigate-b", "x"}
+
+// Generate implements quick.Generator
+func (randomFrigate) Generate(rand *rand.Rand, size int) reflect.Value {
+	foo := make([]byte, rand.Intn(size+1))
+	for i := range foo {
+		foo[i] = byte('a' + rand.Intn(26))
+	}
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      randomFrigateNames[rand.Intn(len(randomFrigateNames))],
+			Namespace: "default",
+		},
+		Spec: shipv1beta1.FrigateSpec{Foo: string(foo)},
+	}
+	// some objects already went through a reconcile in a previous life
+	if rand.Intn(2) == 0 {
+		frigate.Status.Phase = []shipv1beta1.FrigatePhase{"", shipv1beta1.FrigateCompleted, shipv1beta1.FrigateFailure}[rand.Intn(3)]
+	}
+	return reflect.ValueOf(randomFrigate{Frigate: frigate})
+}
+
+func TestReconcileIsIdempotent(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := shipv1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("building scheme: %v", err)
+	}
+
+	property := func(input randomFrigate) bool {
+		ctx := context.TODO()
+		reconciler := &FrigateReconciler{
+			Client: fake.NewFakeClientWithScheme(scheme, input.Frigate.DeepCopy()),
+			Log:    logf.Log,
+			Scheme: scheme,
+		}
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: input.Frigate.Namespace, Name: input.Frigate.Name}}
+
+		first, err := reconcileAndGet(ctx, reconciler, req)
+		if err != nil {
+			t.Logf("first reconcile of %+v: %v", input.Frigate, err)
+			return false
+		}
+		second, err := reconcileAndGet(ctx, reconciler, req)
+		if err != nil {
+			t.Logf("second reconcile of %+v: %v", input.Frigate, err)
+			return false
+		}
+
+		if !reflect.DeepEqual(first.Spec, second.Spec) || !reflect.DeepEqual(first.Status, second.Status) {
+			t.Logf("reconcile is not idempotent for %+v:\nfirst:  %+v\nsecond: %+v", input.Frigate, first, second)
+			return false
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+		t.Error(err)
+	}
+}
+
+// reconcileAndGet runs one reconcile and returns the stored Frigate afterwards
+func reconcileAndGet(ctx context.Context, reconciler *FrigateReconciler, req ctrl.Request) (*shipv1beta1.Frigate, error) {
+	if _, err := reconciler.Reconcile(req); err != nil {
+		return nil, err
+	}
+	frigate := &shipv1beta1.Frigate{}
+	err := reconciler.Get(ctx, req.NamespacedName, frigate)
+	return frigate, err
+}
@@ -3,10 +3,12 @@ package controllers
 import (
 	"context"
 	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -52,6 +54,8 @@ var _ = Describe("Reconcile", func() {
 		k8sclient = k8sClient
 		stop = make(chan struct{})
 		ctx = context.TODO()
+		// metrics and webhook ports are allocated per test
+		opts = testManagerOptions()
 
 		// Create and start manager
 		manager, err = ctrl.NewManager(config, opts)
@@ -107,7 +111,38 @@ var _ = Describe("Reconcile", func() {
 	// and can validate the result directly
 	It("should have a Completed phase", func() {
 		Expect(result).ToNot(BeNil(), "should have a result")
-		Expect(result.Status.Phase).To(Equal("Completed"))
+		Expect(result.Status.Phase).To(Equal(shipv1beta1.FrigateCompleted))
+		Expect(result.Status.ObservedGeneration).To(Equal(result.Generation))
+		Expect(result.Status.Replicas).To(Equal(DefaultReplicas))
+		Expect(result.Status.StartTime).ToNot(BeNil())
+		Expect(result.Status.LastTransitionTime).ToNot(BeNil())
+		Expect(result.Labels).To(HaveKeyWithValue(PhaseLabel, string(shipv1beta1.FrigateCompleted)))
+		Expect(conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionReady)).To(BeTrue())
+		Expect(conditions.IsStatusConditionFalse(result.Status.Conditions, shipv1beta1.ConditionDegraded)).To(BeTrue())
+	})
+
+	// spec and status are written through different endpoints
+	// so editing the spec while the controller writes status
+	// never loses either of them
+	It("should keep spec edits made while reconciling", func() {
+		objKey := client.ObjectKey{Namespace: frigate.Namespace, Name: frigate.Name}
+		for _, foo := range []string{"a", "b", "c"} {
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := k8sclient.Get(ctx, objKey, result); err != nil {
+					return err
+				}
+				result.Spec.Foo = foo
+				return k8sclient.Update(ctx, result)
+			})
+			Expect(err).ToNot(HaveOccurred(), "editing spec")
+		}
+
+		Eventually(func() bool {
+			err = k8sclient.Get(ctx, objKey, result)
+			return err == nil && result.Status.ObservedGeneration == result.Generation
+		}, 5*time.Second).Should(BeTrue(), "status should catch up with the last spec")
+		Expect(result.Spec.Foo).To(Equal("c"))
+		Expect(result.Status.Phase).To(Equal(shipv1beta1.FrigateCompleted))
 	})
 
 	// How to reuse all the above code and add a new test case?
@@ -126,7 +161,11 @@ var _ = Describe("Reconcile", func() {
 
 		It("should have a Failure phase", func() {
 			Expect(result).ToNot(BeNil(), "should have a result")
-			Expect(result.Status.Phase).To(Equal("Failure"))
+			Expect(result.Status.Phase).To(Equal(shipv1beta1.FrigateFailure))
+			Expect(result.Status.Reason).To(Equal(shipv1beta1.ReasonNameReserved))
+			Expect(result.Status.Message).ToNot(BeEmpty())
+			Expect(conditions.IsStatusConditionFalse(result.Status.Conditions, shipv1beta1.ConditionReady)).To(BeTrue())
+			Expect(conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionDegraded)).To(BeTrue())
 		})
 	})
 })
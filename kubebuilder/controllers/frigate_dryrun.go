@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/go-logr/logr"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/readonly"
+)
+
+// DryRunAnnotation set to "true" on a Frigate makes its reconciles log
+// the writes they would make, i.e. to check what a new ShipClass or
+// controller version does to it before letting it go ahead
+const DryRunAnnotation = "ship.danielfbm.github.io/dry-run"
+
+// dryRun returns the reconciler to use for frigate: itself, or a copy
+// dropping every write and publishing no events when frigate asks for
+// a dry run
+func (r *FrigateReconciler) dryRun(frigate *shipv1beta1.Frigate, log logr.Logger) *FrigateReconciler {
+	if frigate.Annotations[DryRunAnnotation] != "true" {
+		return r
+	}
+	dry := *r
+	dry.Client = &readonly.Client{Client: r.Client, Log: log.WithName("dry-run")}
+	dry.Events = nil
+	return &dry
+}
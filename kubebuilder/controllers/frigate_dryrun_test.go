@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDryRunFrigateIsNotWritten(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "some",
+			Namespace:   "default",
+			Annotations: map[string]string{DryRunAnnotation: "true"},
+		},
+	}
+	reconciler := &FrigateReconciler{
+		Client:       fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:          logf.Log,
+		Scheme:       scheme,
+		TombstoneTTL: time.Hour,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	reconcile := func() *shipv1beta1.Frigate {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		return result
+	}
+
+	// 1. the finalizer and status are computed but not written
+	result := reconcile()
+	if result.Status.Phase != "" || len(result.Finalizers) != 0 || len(result.Status.Conditions) != 0 {
+		t.Errorf("dry run frigate should not be changed, got %+v", result)
+	}
+
+	// 2. removing the annotation applies the changes
+	delete(result.Annotations, DryRunAnnotation)
+	if err := reconciler.Update(ctx, result); err != nil {
+		t.Fatalf("should update frigate: %v", err)
+	}
+	result = reconcile()
+	if result.Status.Phase != shipv1beta1.FrigateCompleted || !hasFinalizer(result, TombstoneFinalizer) {
+		t.Errorf("frigate should be reconciled without the annotation, got %+v", result)
+	}
+}
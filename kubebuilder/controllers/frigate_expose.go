@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+)
+
+// reconcileService creates the Service of spec.expose, named after the
+// Frigate and selecting its cargo Pods, and reports its cluster IP and
+// the address of its load balancer. Edits to the Service drifting from
+// spec.expose are reverted. The Service is deleted when spec.expose is
+// unset
+func (r *FrigateReconciler) reconcileService(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	// nothing was ever created
+	if frigate.Spec.Expose == nil && frigate.Status.ServiceName == "" {
+		return nil
+	}
+	log := r.Log.WithValues("frigate", frigate.Namespace+"/"+frigate.Name)
+	service := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Name}, service)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+	if exists && !metav1.IsControlledBy(service, frigate) {
+		return fmt.Errorf("Service %s exists and is not controlled by the Frigate", frigate.Name)
+	}
+
+	if frigate.Spec.Expose == nil {
+		if exists {
+			objdiff.Log(log, "deleting service", service, nil)
+			if err = r.Delete(ctx, service); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		frigate.Status.ServiceName, frigate.Status.ClusterIP, frigate.Status.ExternalAddress = "", "", ""
+		return nil
+	}
+
+	if !exists {
+		service = &corev1.Service{ObjectMeta: metav1.ObjectMeta{
+			Name:      frigate.Name,
+			Namespace: frigate.Namespace,
+		}}
+		exposeService(frigate, service)
+		if err = ctrl.SetControllerReference(frigate, service, r.Scheme); err != nil {
+			return err
+		}
+		objdiff.Log(log, "creating service", nil, service)
+		if err = r.Create(ctx, service); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	} else {
+		desired := service.DeepCopy()
+		exposeService(frigate, desired)
+		if !reflect.DeepEqual(service.Spec, desired.Spec) || !reflect.DeepEqual(service.Labels, desired.Labels) {
+			objdiff.Log(log, "updating service", service, desired)
+			if err = r.Patch(ctx, desired, client.MergeFrom(service)); err != nil {
+				return err
+			}
+			service = desired
+		}
+	}
+	frigate.Status.ServiceName = service.Name
+	frigate.Status.ClusterIP = ""
+	if service.Spec.ClusterIP != corev1.ClusterIPNone {
+		frigate.Status.ClusterIP = service.Spec.ClusterIP
+	}
+	frigate.Status.ExternalAddress = externalAddress(service)
+	return nil
+}
+
+// exposeService sets the spec of service from spec.expose and its
+// selector labels, node ports and the cluster IP already allocated and
+// labels added by others are kept
+func exposeService(frigate *shipv1beta1.Frigate, service *corev1.Service) {
+	expose := frigate.Spec.Expose
+	serviceType := expose.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	nodePorts := map[string]int32{}
+	for _, port := range service.Spec.Ports {
+		nodePorts[port.Name] = port.NodePort
+	}
+
+	ports := make([]corev1.ServicePort, 0, len(expose.Ports))
+	for _, port := range expose.Ports {
+		target := port.TargetPort
+		if target == 0 {
+			target = port.Port
+		}
+		servicePort := corev1.ServicePort{
+			Name:       port.Name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port.Port,
+			TargetPort: intstr.FromInt(int(target)),
+		}
+		if serviceType != corev1.ServiceTypeClusterIP {
+			servicePort.NodePort = nodePorts[port.Name]
+		}
+		ports = append(ports, servicePort)
+	}
+
+	if service.Labels == nil {
+		service.Labels = map[string]string{}
+	}
+	for k, v := range frigateSelector(frigate) {
+		service.Labels[k] = v
+	}
+	service.Spec.Type = serviceType
+	service.Spec.Selector = frigateSelector(frigate)
+	service.Spec.Ports = ports
+	service.Spec.LoadBalancerSourceRanges = nil
+	if serviceType == corev1.ServiceTypeLoadBalancer && len(expose.LoadBalancerSourceRanges) > 0 {
+		service.Spec.LoadBalancerSourceRanges = append([]string(nil), expose.LoadBalancerSourceRanges...)
+	}
+}
+
+// externalAddress returns the IP or hostname of the load balancer
+// of a LoadBalancer Service, empty until it is provisioned
+func externalAddress(service *corev1.Service) string {
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return ""
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			return ingress.IP
+		}
+		if ingress.Hostname != "" {
+			return ingress.Hostname
+		}
+	}
+	return ""
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFrigateExpose(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateSpec{Expose: &shipv1beta1.ExposeSpec{
+			Type:                     corev1.ServiceTypeLoadBalancer,
+			Ports:                    []shipv1beta1.ExposePort{{Name: "http", Port: 80, TargetPort: 8080}},
+			LoadBalancerSourceRanges: []string{"203.0.113.0/24"},
+		}},
+	}
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "some"}
+	reconcile := func() *corev1.Service {
+		if err := reconciler.reconcileService(ctx, frigate); err != nil {
+			t.Fatalf("should reconcile service: %v", err)
+		}
+		service := &corev1.Service{}
+		if err := reconciler.Get(ctx, key, service); err != nil {
+			t.Fatalf("should get service: %v", err)
+		}
+		return service
+	}
+
+	// 1. the Service is created with the source ranges of the load balancer
+	service := reconcile()
+	if service.Spec.Type != corev1.ServiceTypeLoadBalancer || !reflect.DeepEqual(service.Spec.LoadBalancerSourceRanges, []string{"203.0.113.0/24"}) {
+		t.Errorf("unexpected service spec %+v", service.Spec)
+	}
+	if port := service.Spec.Ports[0]; port.Port != 80 || port.TargetPort.IntValue() != 8080 || service.Spec.Selector[FrigateLabel] != "some" {
+		t.Errorf("unexpected ports %+v or selector %v", service.Spec.Ports, service.Spec.Selector)
+	}
+	if !metav1.IsControlledBy(service, frigate) || frigate.Status.ServiceName != "some" || frigate.Status.ExternalAddress != "" {
+		t.Errorf("expected an owned service without address, got %+v", frigate.Status)
+	}
+
+	// 2. the addresses are reported once allocated
+	service.Spec.ClusterIP = "10.96.0.7"
+	service.Spec.Ports[0].NodePort = 30080
+	service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+	if err := reconciler.Update(ctx, service); err != nil {
+		t.Fatalf("should update service: %v", err)
+	}
+	reconcile()
+	if frigate.Status.ExternalAddress != "lb.example.com" || frigate.Status.ClusterIP != "10.96.0.7" {
+		t.Errorf("expected the cluster IP and load balancer hostname, got %+v", frigate.Status)
+	}
+
+	// 3. edits drifting from spec.expose are reverted, others are kept
+	service = reconcile()
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(9090)
+	service.Spec.Selector = map[string]string{"app": "other"}
+	delete(service.Labels, FrigateLabel)
+	service.Labels["team"] = "payments"
+	if err := reconciler.Update(ctx, service); err != nil {
+		t.Fatalf("should update service: %v", err)
+	}
+	service = reconcile()
+	if service.Spec.Ports[0].TargetPort.IntValue() != 8080 || service.Spec.Selector[FrigateLabel] != "some" || service.Spec.Selector["app"] != "" {
+		t.Errorf("expected the spec to be repaired, got %+v", service.Spec)
+	}
+	if service.Labels[FrigateLabel] != "some" || service.Labels["team"] != "payments" || service.Spec.ClusterIP != "10.96.0.7" {
+		t.Errorf("expected the selector labels back and the others kept, got %v and %+v", service.Labels, service.Spec)
+	}
+
+	// 4. spec changes are propagated, allocated node ports are kept
+	frigate.Spec.Expose.Type = corev1.ServiceTypeNodePort
+	frigate.Spec.Expose.LoadBalancerSourceRanges = nil
+	service = reconcile()
+	if service.Spec.Type != corev1.ServiceTypeNodePort || service.Spec.LoadBalancerSourceRanges != nil || service.Spec.Ports[0].NodePort != 30080 {
+		t.Errorf("unexpected service spec %+v", service.Spec)
+	}
+	if frigate.Status.ExternalAddress != "" {
+		t.Errorf("node ports have no external address, got %q", frigate.Status.ExternalAddress)
+	}
+
+	// 5. unsetting expose deletes the Service
+	frigate.Spec.Expose = nil
+	if err := reconciler.reconcileService(ctx, frigate); err != nil {
+		t.Fatalf("should reconcile service: %v", err)
+	}
+	if err := reconciler.Get(ctx, key, &corev1.Service{}); !errors.IsNotFound(err) || frigate.Status.ServiceName != "" || frigate.Status.ClusterIP != "" {
+		t.Errorf("service should be deleted, got %v and %+v", err, frigate.Status)
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+)
+
+// harborPending returns a reason and message while the Harbor referenced
+// by frigate is missing or not Ready, both are empty otherwise
+func (r *FrigateReconciler) harborPending(ctx context.Context, frigate *shipv1beta1.Frigate) (reason, message string, err error) {
+	ref := frigate.Spec.HarborRef
+	if ref == nil {
+		return
+	}
+	harbor := &shipv1beta1.Harbor{}
+	if err = r.Get(ctx, types.NamespacedName{Namespace: frigate.Namespace, Name: ref.Name}, harbor); err != nil {
+		if errors.IsNotFound(err) {
+			return shipv1beta1.ReasonHarborNotFound, fmt.Sprintf("Harbor %q not found", ref.Name), nil
+		}
+		return
+	}
+	if !conditions.IsStatusConditionTrue(harbor.Status.Conditions, shipv1beta1.ConditionReady) {
+		return shipv1beta1.ReasonHarborNotReady, fmt.Sprintf("waiting for Harbor %q to be Ready", ref.Name), nil
+	}
+	return
+}
+
+// frigatesForHarbor maps a Harbor to the Frigates docking at it
+// so they are reconciled when its status changes
+func (r *FrigateReconciler) frigatesForHarbor(obj handler.MapObject) (requests []ctrl.Request) {
+	frigates := &shipv1beta1.FrigateList{}
+	if err := r.List(context.Background(), frigates, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "listing frigates", "harbor", obj.Meta.GetNamespace()+"/"+obj.Meta.GetName())
+		return
+	}
+	for i := range frigates.Items {
+		frigate := &frigates.Items[i]
+		if frigate.Spec.HarborRef == nil || frigate.Spec.HarborRef.Name != obj.Meta.GetName() {
+			continue
+		}
+		requests = append(requests, ctrl.Request{
+			NamespacedName: types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Name},
+		})
+	}
+	return
+}
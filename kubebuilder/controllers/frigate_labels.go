@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"context"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// PhaseLabel mirrors status.phase on the Frigate. Field selectors on
+// custom resources only support metadata.name and metadata.namespace in
+// the apiextensions version used here, a label lets the API server
+// filter Frigates by phase instead of every client listing them all,
+// i.e. kubectl get frigates -l ship.danielfbm.github.io/phase=Failure.
+// spec.selectableFields needs apiextensions.k8s.io/v1 CRDs and
+// Kubernetes 1.30, the v1beta1 CRDs of this project predate both
+const PhaseLabel = "ship.danielfbm.github.io/phase"
+
+// mirrorLabels copies status fields to labels used for filtering
+func (r *FrigateReconciler) mirrorLabels(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	phase := string(frigate.Status.Phase)
+	if phase == "" || frigate.Labels[PhaseLabel] == phase {
+		return nil
+	}
+	if frigate.Labels == nil {
+		frigate.Labels = map[string]string{}
+	}
+	frigate.Labels[PhaseLabel] = phase
+	return nil
+}
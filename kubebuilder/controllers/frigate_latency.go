@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+var (
+	// buckets from 100ms to ~7min, provisioning latency as seen by users
+	latencyBuckets = prometheus.ExponentialBuckets(0.1, 2, 13)
+
+	firstReconcileLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ship_frigate_first_reconcile_seconds",
+		Help:    "Time from Frigate creation to its first successful reconcile",
+		Buckets: latencyBuckets,
+	})
+	firstReadyLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ship_frigate_first_ready_seconds",
+		Help:    "Time from Frigate creation to the first time it is Completed",
+		Buckets: latencyBuckets,
+	})
+	phaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ship_frigate_phase_seconds",
+		Help:    "Time Frigates spent in a phase before moving to the next one",
+		Buckets: latencyBuckets,
+	}, []string{"phase"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(firstReconcileLatency, firstReadyLatency, phaseDuration)
+}
+
+// recordFirstTimes sets the first reconciled and first ready times
+// the first time the reconcile succeeds and the Frigate is Completed
+func recordFirstTimes(frigate *shipv1beta1.Frigate, now metav1.Time) {
+	if frigate.Status.FirstReconciledAt == nil {
+		frigate.Status.FirstReconciledAt = &now
+	}
+	if frigate.Status.FirstReadyAt == nil && frigate.Status.Phase == shipv1beta1.FrigateCompleted {
+		frigate.Status.FirstReadyAt = &now
+	}
+}
+
+// observeFirstTimes exports the latencies recorded in after, must be
+// called once they are persisted so each Frigate is observed once
+func observeFirstTimes(before, after *shipv1beta1.Frigate) {
+	created := after.CreationTimestamp.Time
+	if before.Status.FirstReconciledAt == nil && after.Status.FirstReconciledAt != nil {
+		firstReconcileLatency.Observe(after.Status.FirstReconciledAt.Sub(created).Seconds())
+	}
+	if before.Status.FirstReadyAt == nil && after.Status.FirstReadyAt != nil {
+		firstReadyLatency.Observe(after.Status.FirstReadyAt.Sub(created).Seconds())
+	}
+}
+
+// recordPhaseTransition stamps the phase change from before to after
+func recordPhaseTransition(before, after *shipv1beta1.Frigate, now metav1.Time) {
+	if before.Status.Phase == after.Status.Phase {
+		return
+	}
+	after.Status.LastTransitionTime = &now
+	if after.Status.StartTime == nil {
+		after.Status.StartTime = &now
+	}
+}
+
+// observePhaseTransition exports how long the Frigate stayed in its
+// previous phase, must be called once after is persisted
+func observePhaseTransition(before, after *shipv1beta1.Frigate) {
+	if before.Status.Phase == after.Status.Phase || after.Status.LastTransitionTime == nil {
+		return
+	}
+	// a Frigate without a phase is waiting for the controller
+	phase, since := before.Status.Phase, after.CreationTimestamp
+	if phase == "" {
+		phase = shipv1beta1.FrigatePending
+	}
+	if before.Status.LastTransitionTime != nil {
+		since = *before.Status.LastTransitionTime
+	}
+	phaseDuration.WithLabelValues(string(phase)).Observe(after.Status.LastTransitionTime.Sub(since.Time).Seconds())
+}
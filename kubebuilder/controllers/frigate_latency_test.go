@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordFirstTimes(t *testing.T) {
+	created := time.Now().Add(-time.Minute)
+	frigate := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created)}}
+
+	// 1. reconciled but not ready yet
+	first := metav1.NewTime(created.Add(time.Second))
+	recordFirstTimes(frigate, first)
+	if frigate.Status.FirstReconciledAt == nil || !frigate.Status.FirstReconciledAt.Equal(&first) || frigate.Status.FirstReadyAt != nil {
+		t.Errorf("only the first reconcile should be recorded, got %+v", frigate.Status)
+	}
+
+	// 2. ready later, the first reconcile is kept
+	frigate.Status.Phase = shipv1beta1.FrigateCompleted
+	ready := metav1.NewTime(created.Add(5 * time.Second))
+	recordFirstTimes(frigate, ready)
+	if !frigate.Status.FirstReconciledAt.Equal(&first) || frigate.Status.FirstReadyAt == nil || !frigate.Status.FirstReadyAt.Equal(&ready) {
+		t.Errorf("first ready should be recorded once, got %+v", frigate.Status)
+	}
+
+	// 3. later reconciles do not move the times
+	recordFirstTimes(frigate, metav1.Now())
+	if !frigate.Status.FirstReadyAt.Equal(&ready) {
+		t.Errorf("first ready should not change, got %v", frigate.Status.FirstReadyAt)
+	}
+}
+
+func TestRecordPhaseTransition(t *testing.T) {
+	before := &shipv1beta1.Frigate{}
+	after := before.DeepCopy()
+
+	// 1. no phase change, nothing recorded
+	recordPhaseTransition(before, after, metav1.Now())
+	if after.Status.StartTime != nil || after.Status.LastTransitionTime != nil {
+		t.Errorf("nothing should be recorded without a phase change, got %+v", after.Status)
+	}
+
+	// 2. the first phase sets both times
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	after.Status.Phase = shipv1beta1.FrigateRunning
+	recordPhaseTransition(before, after, started)
+	if !after.Status.StartTime.Equal(&started) || !after.Status.LastTransitionTime.Equal(&started) {
+		t.Errorf("first phase should set start and transition times, got %+v", after.Status)
+	}
+
+	// 3. later transitions keep the start time
+	before, after = after, after.DeepCopy()
+	completed := metav1.Now()
+	after.Status.Phase = shipv1beta1.FrigateCompleted
+	recordPhaseTransition(before, after, completed)
+	if !after.Status.StartTime.Equal(&started) || !after.Status.LastTransitionTime.Equal(&completed) {
+		t.Errorf("start time should be kept and transition time moved, got %+v", after.Status)
+	}
+}
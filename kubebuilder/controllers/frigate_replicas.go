@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+const (
+	// FrigateLabel is set on the replicas of a Frigate with its name
+	// and is used as the scale subresource selector
+	FrigateLabel = "ship.danielfbm.github.io/frigate"
+	// DefaultReplicas is used when spec.replicas is not set
+	DefaultReplicas int32 = 1
+)
+
+// desiredReplicas returns spec.replicas or the default
+func desiredReplicas(frigate *shipv1beta1.Frigate) int32 {
+	if frigate.Spec.Replicas == nil {
+		return DefaultReplicas
+	}
+	return *frigate.Spec.Replicas
+}
+
+// frigateSelector returns the labels of the replicas of a Frigate
+func frigateSelector(frigate *shipv1beta1.Frigate) labels.Set {
+	return labels.Set{FrigateLabel: frigate.Name}
+}
+
+// computeReplicas reports the replicas and selector in status
+// so kubectl scale and autoscalers can read them.
+// Frigates without a cargo template do not run Pods, the desired
+// count is reported as is
+func (r *FrigateReconciler) computeReplicas(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	frigate.Status.Replicas = desiredReplicas(frigate)
+	frigate.Status.Selector = frigateSelector(frigate).String()
+	return nil
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputeReplicas(t *testing.T) {
+	r := &FrigateReconciler{}
+	frigate := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"}}
+
+	// 1. defaults to a single replica
+	if err := r.computeReplicas(context.TODO(), frigate); err != nil {
+		t.Fatalf("should compute replicas: %v", err)
+	}
+	if frigate.Status.Replicas != DefaultReplicas || frigate.Status.Selector != FrigateLabel+"=some" {
+		t.Errorf("unexpected status %+v", frigate.Status)
+	}
+
+	// 2. scaled through spec.replicas, including to zero
+	zero := int32(0)
+	frigate.Spec.Replicas = &zero
+	if err := r.computeReplicas(context.TODO(), frigate); err != nil {
+		t.Fatalf("should compute replicas: %v", err)
+	}
+	if frigate.Status.Replicas != 0 {
+		t.Errorf("should scale to zero, got %d", frigate.Status.Replicas)
+	}
+}
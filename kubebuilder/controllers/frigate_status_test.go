@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// specRacingClient edits the Frigate spec right before its status
+// is written, like a user running kubectl edit during a reconcile
+type specRacingClient struct {
+	client.Client
+	foo string
+}
+
+func (c *specRacingClient) Status() client.StatusWriter {
+	return &specRacingStatusWriter{client: c}
+}
+
+type specRacingStatusWriter struct {
+	client *specRacingClient
+}
+
+func (w *specRacingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	if err := w.editSpec(ctx, obj); err != nil {
+		return err
+	}
+	return w.client.Client.Status().Update(ctx, obj, opts...)
+}
+
+func (w *specRacingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := w.editSpec(ctx, obj); err != nil {
+		return err
+	}
+	return w.client.Client.Status().Patch(ctx, obj, patch, opts...)
+}
+
+func (w *specRacingStatusWriter) editSpec(ctx context.Context, obj runtime.Object) error {
+	key, err := client.ObjectKeyFromObject(obj)
+	if err != nil {
+		return err
+	}
+	current := &shipv1beta1.Frigate{}
+	if err = w.client.Client.Get(ctx, key, current); err != nil {
+		return err
+	}
+	current.Spec.Foo = w.client.foo
+	return w.client.Client.Update(ctx, current)
+}
+
+func TestStatusWriteKeepsConcurrentSpecEdits(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"},
+		Spec:       shipv1beta1.FrigateSpec{Foo: "foo"},
+	}
+	reconciler := &FrigateReconciler{
+		Client: &specRacingClient{Client: fake.NewFakeClientWithScheme(scheme, frigate), foo: "edited"},
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	if _, err := reconciler.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+
+	result := &shipv1beta1.Frigate{}
+	if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+		t.Fatalf("should get frigate: %v", err)
+	}
+	// the status write only carries status, the spec edit survives
+	if result.Spec.Foo != "edited" {
+		t.Errorf("spec edit was overwritten by the status write, got foo %q", result.Spec.Foo)
+	}
+	if result.Status.Phase != shipv1beta1.FrigateCompleted {
+		t.Errorf("status should be written, got %+v", result.Status)
+	}
+}
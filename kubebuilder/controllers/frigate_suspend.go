@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+)
+
+// suspend records that a Frigate with spec.suspend is left alone,
+// nothing but the Suspended condition is written
+func (r *FrigateReconciler) suspend(ctx context.Context, frigate *shipv1beta1.Frigate) error {
+	suspended := shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionSuspended,
+		Status:             shipv1beta1.ConditionTrue,
+		ObservedGeneration: frigate.Generation,
+		Reason:             "Suspended",
+		Message:            "spec.suspend is set, the Frigate is not reconciled",
+	}
+	if existing := conditions.FindStatusCondition(frigate.Status.Conditions, suspended.Type); existing != nil &&
+		existing.Status == suspended.Status && existing.ObservedGeneration == suspended.ObservedGeneration {
+		return nil
+	}
+	frigateCopy := frigate.DeepCopy()
+	conditions.SetStatusCondition(&frigateCopy.Status.Conditions, suspended)
+	return r.Status().Patch(ctx, frigateCopy, client.MergeFrom(frigate))
+}
+
+// resume flips the Suspended condition of a Frigate no longer suspended,
+// it is not added to Frigates that were never suspended
+func resume(frigate *shipv1beta1.Frigate) {
+	if !conditions.IsStatusConditionTrue(frigate.Status.Conditions, shipv1beta1.ConditionSuspended) {
+		return
+	}
+	conditions.SetStatusCondition(&frigate.Status.Conditions, shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionSuspended,
+		Status:             shipv1beta1.ConditionFalse,
+		ObservedGeneration: frigate.Generation,
+		Reason:             "Resumed",
+	})
+}
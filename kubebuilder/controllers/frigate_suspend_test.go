@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestSuspendedFrigateIsLeftAlone(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"},
+		Spec:       shipv1beta1.FrigateSpec{Suspend: true},
+	}
+	reconciler := &FrigateReconciler{
+		Client:       fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:          logf.Log,
+		Scheme:       scheme,
+		TombstoneTTL: time.Hour,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+	reconcile := func() *shipv1beta1.Frigate {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		return result
+	}
+
+	// 1. only the Suspended condition is written
+	result := reconcile()
+	if result.Status.Phase != "" || len(result.Finalizers) != 0 || len(result.Labels) != 0 {
+		t.Errorf("suspended frigate should not be changed, got %+v", result)
+	}
+	if len(result.Status.Conditions) != 1 || !conditions.IsStatusConditionTrue(result.Status.Conditions, shipv1beta1.ConditionSuspended) {
+		t.Errorf("expected only the Suspended condition, got %+v", result.Status.Conditions)
+	}
+
+	// 2. reconciles resume once it is flipped back
+	result.Spec.Suspend = false
+	if err := reconciler.Update(ctx, result); err != nil {
+		t.Fatalf("should update frigate: %v", err)
+	}
+	result = reconcile()
+	if result.Status.Phase != shipv1beta1.FrigateCompleted || !hasFinalizer(result, TombstoneFinalizer) {
+		t.Errorf("resumed frigate should be reconciled, got %+v", result)
+	}
+	suspended := conditions.FindStatusCondition(result.Status.Conditions, shipv1beta1.ConditionSuspended)
+	if suspended == nil || suspended.Status != shipv1beta1.ConditionFalse || suspended.Reason != "Resumed" {
+		t.Errorf("expected Suspended False with Resumed, got %+v", suspended)
+	}
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// TombstoneFinalizer holds Frigate deletion until a tombstone is written
+	TombstoneFinalizer = "ship.danielfbm.github.io/tombstone"
+	// FrigateNameLabel is set on tombstones with the deleted Frigate name
+	FrigateNameLabel = "ship.danielfbm.github.io/frigate-name"
+)
+
+// finalize writes a tombstone for a Frigate being deleted
+// and releases the finalizer
+func (r *FrigateReconciler) finalize(ctx context.Context, frigate *shipv1beta1.Frigate) (err error) {
+	if !hasFinalizer(frigate, TombstoneFinalizer) {
+		return
+	}
+	// tombstones could have been disabled after the finalizer
+	// was added, in this case the Frigate is just released
+	if r.TombstoneTTL > 0 {
+		tombstone := NewTombstone(frigate, r.TombstoneTTL)
+		if err = r.Create(ctx, tombstone); err != nil && !errors.IsAlreadyExists(err) {
+			return
+		}
+		r.Log.Info("tombstone written", "frigate", frigate.Name, "namespace", frigate.Namespace, "tombstone", tombstone.Name)
+	}
+
+	released := frigate.DeepCopy()
+	removeFinalizer(released, TombstoneFinalizer)
+	err = r.Patch(ctx, released, client.MergeFrom(frigate))
+	return
+}
+
+// NewTombstone builds the tombstone of a deleted Frigate. Unlike the
+// cargo Deployment and the Service it is not owned by the Frigate, it has to
+// survive the garbage collection of the Frigate to restore it
+func NewTombstone(frigate *shipv1beta1.Frigate, ttl time.Duration) *shipv1beta1.FrigateTombstone {
+	deletedAt := metav1.Now()
+	if frigate.DeletionTimestamp != nil {
+		deletedAt = *frigate.DeletionTimestamp
+	}
+	uid := string(frigate.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	return &shipv1beta1.FrigateTombstone{
+		ObjectMeta: metav1.ObjectMeta{
+			// uid suffix keeps tombstones of recreated Frigates apart
+			Name:      fmt.Sprintf("%s-%s", frigate.Name, uid),
+			Namespace: frigate.Namespace,
+			Labels:    map[string]string{FrigateNameLabel: frigate.Name},
+		},
+		Spec: shipv1beta1.FrigateTombstoneSpec{
+			FrigateName: frigate.Name,
+			FrigateUID:  string(frigate.UID),
+			Snapshot: shipv1beta1.FrigateSnapshot{
+				Labels:      frigate.Labels,
+				Annotations: frigate.Annotations,
+				Spec:        *frigate.Spec.DeepCopy(),
+				Status:      *frigate.Status.DeepCopy(),
+			},
+			DeletedAt: deletedAt,
+			ExpiresAt: metav1.NewTime(deletedAt.Add(ttl)),
+		},
+	}
+}
+
+func hasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func addFinalizer(obj metav1.Object, finalizer string) {
+	if !hasFinalizer(obj, finalizer) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), finalizer))
+	}
+}
+
+func removeFinalizer(obj metav1.Object, finalizer string) {
+	finalizers := []string{}
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	obj.SetFinalizers(finalizers)
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFinalizerWritesTombstone(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "some",
+			Namespace:   "default",
+			UID:         "12345678-abcd",
+			Labels:      map[string]string{"team": "blue"},
+			Annotations: map[string]string{"note": "keep"},
+		},
+		Spec: shipv1beta1.FrigateSpec{Foo: "bar"},
+	}
+	reconciler := &FrigateReconciler{
+		Client:       fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:          logf.Log,
+		Scheme:       scheme,
+		TombstoneTTL: time.Hour,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}}
+
+	// 1. the finalizer is added before anything else
+	if _, err := reconciler.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	current := &shipv1beta1.Frigate{}
+	if err := reconciler.Get(ctx, req.NamespacedName, current); err != nil {
+		t.Fatalf("should get frigate: %v", err)
+	}
+	if !hasFinalizer(current, TombstoneFinalizer) {
+		t.Fatalf("expected the tombstone finalizer, got %v", current.Finalizers)
+	}
+
+	// 2. deleting it writes a tombstone and releases the Frigate, the
+	// fake client does not handle finalizers so the deletion is simulated
+	deletedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+	current.DeletionTimestamp = &deletedAt
+	if err := reconciler.Update(ctx, current); err != nil {
+		t.Fatalf("should mark frigate deleted: %v", err)
+	}
+	if _, err := reconciler.Reconcile(req); err != nil {
+		t.Fatalf("should finalize: %v", err)
+	}
+	current = &shipv1beta1.Frigate{}
+	if err := reconciler.Get(ctx, req.NamespacedName, current); err != nil {
+		t.Fatalf("should get frigate: %v", err)
+	}
+	if hasFinalizer(current, TombstoneFinalizer) {
+		t.Errorf("finalizer should be released, got %v", current.Finalizers)
+	}
+
+	tombstones := &shipv1beta1.FrigateTombstoneList{}
+	if err := reconciler.List(ctx, tombstones, client.InNamespace("default"), client.MatchingLabels{FrigateNameLabel: "some"}); err != nil {
+		t.Fatalf("should list tombstones: %v", err)
+	}
+	if len(tombstones.Items) != 1 {
+		t.Fatalf("expected 1 tombstone, got %d", len(tombstones.Items))
+	}
+	tombstone := tombstones.Items[0]
+	if tombstone.Name != "some-12345678" || tombstone.Spec.FrigateUID != "12345678-abcd" {
+		t.Errorf("unexpected tombstone %s of %s", tombstone.Name, tombstone.Spec.FrigateUID)
+	}
+	snapshot := tombstone.Spec.Snapshot
+	if snapshot.Spec.Foo != "bar" || snapshot.Labels["team"] != "blue" || snapshot.Annotations["note"] != "keep" {
+		t.Errorf("snapshot should keep the frigate, got %+v", snapshot)
+	}
+	if !tombstone.Spec.DeletedAt.Equal(&deletedAt) || !tombstone.Spec.ExpiresAt.Time.Equal(deletedAt.Add(time.Hour)) {
+		t.Errorf("expected the tombstone to expire an hour after %v, got %+v", deletedAt, tombstone.Spec)
+	}
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestComputePhaseGuards(t *testing.T) {
+	quota := lifecycle.GuardFunc{GuardName: "quota", Func: func(ctx context.Context, obj lifecycle.Object, transition lifecycle.Transition) (*lifecycle.Veto, error) {
+		if transition.To == lifecycle.Completed && obj.GetLabels()["quota"] == "exceeded" {
+			return &lifecycle.Veto{Reason: "QuotaExceeded", Message: "no more Completed frigates"}, nil
+		}
+		return nil, nil
+	}}
+	r := &FrigateReconciler{Guards: []lifecycle.Guard{lifecycle.HoldGuard{}, quota}}
+	frigate := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{
+		Name:        "some",
+		Labels:      map[string]string{"quota": "exceeded"},
+		Annotations: map[string]string{lifecycle.HoldAnnotation: "dry dock inspection"},
+	}}
+
+	// 1. every veto is aggregated in the condition and the phase is kept
+	if err := r.computePhase(context.TODO(), frigate); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	vetoed := conditions.FindStatusCondition(frigate.Status.Conditions, shipv1beta1.ConditionTransitionVetoed)
+	if frigate.Status.Phase != "" || vetoed == nil || vetoed.Status != shipv1beta1.ConditionTrue || vetoed.Reason != "OnHold" {
+		t.Fatalf("transition should be vetoed, got phase %q condition %+v", frigate.Status.Phase, vetoed)
+	}
+	if !strings.Contains(vetoed.Message, "hold: dry dock inspection") || !strings.Contains(vetoed.Message, "quota: no more") {
+		t.Errorf("message should list every veto, got %q", vetoed.Message)
+	}
+
+	// 2. once the guards allow it the transition happens
+	frigate.Annotations, frigate.Labels = nil, nil
+	if err := r.computePhase(context.TODO(), frigate); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if frigate.Status.Phase != shipv1beta1.FrigateCompleted || !conditions.IsStatusConditionFalse(frigate.Status.Conditions, shipv1beta1.ConditionTransitionVetoed) {
+		t.Errorf("transition should be allowed, got %+v", frigate.Status)
+	}
+}
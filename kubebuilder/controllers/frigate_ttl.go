@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// finished returns true for Frigates in a final phase
+func finished(frigate *shipv1beta1.Frigate) bool {
+	return frigate.Status.Phase == shipv1beta1.FrigateCompleted || frigate.Status.Phase == shipv1beta1.FrigateFailure
+}
+
+// sheddingRequeue postpones non-critical work while the API server
+// is under pressure
+const sheddingRequeue = time.Minute
+
+// expire deletes a finished Frigate once spec.ttlSecondsAfterFinished
+// elapsed since it reached its phase, before that it returns how long
+// to wait. The deletion goes through the finalizers like any other and
+// is postponed while the API server is under pressure
+func (r *FrigateReconciler) expire(ctx context.Context, frigate *shipv1beta1.Frigate, now time.Time) (time.Duration, error) {
+	if frigate.Spec.TTLSecondsAfterFinished == nil || !finished(frigate) || frigate.Status.LastTransitionTime == nil {
+		return 0, nil
+	}
+	ttl := time.Duration(*frigate.Spec.TTLSecondsAfterFinished) * time.Second
+	if remaining := frigate.Status.LastTransitionTime.Add(ttl).Sub(now); remaining > 0 {
+		return remaining, nil
+	}
+	if r.Pressure != nil && r.Pressure.Shedding() {
+		r.Log.V(1).Info("shedding load, postponing the expiry", "frigate", frigate.Namespace+"/"+frigate.Name)
+		return sheddingRequeue, nil
+	}
+
+	r.Log.Info("frigate finished and expired", "frigate", frigate.Namespace+"/"+frigate.Name,
+		"phase", frigate.Status.Phase, "finishedAt", frigate.Status.LastTransitionTime)
+	// a Frigate recreated with the same name is not deleted
+	err := r.Delete(ctx, frigate, &client.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &frigate.UID}})
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		err = nil
+	}
+	return 0, err
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFinishedFrigateExpires(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	ttl := int32(60)
+	reconciler := &FrigateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme,
+			&shipv1beta1.Frigate{
+				ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"},
+				Spec:       shipv1beta1.FrigateSpec{TTLSecondsAfterFinished: &ttl},
+			},
+			&shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "kept", Namespace: "default"}},
+		),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	get := func(name string) (*shipv1beta1.Frigate, error) {
+		frigate := &shipv1beta1.Frigate{}
+		return frigate, reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, frigate)
+	}
+
+	// 1. a Frigate reaching Completed is requeued for its TTL
+	result, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}})
+	if err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	if result.RequeueAfter <= 0 || result.RequeueAfter > time.Minute {
+		t.Errorf("expected a requeue within the ttl, got %v", result.RequeueAfter)
+	}
+	frigate, err := get("some")
+	if err != nil || frigate.Status.Phase != shipv1beta1.FrigateCompleted {
+		t.Fatalf("expected a Completed frigate, got %+v %v", frigate, err)
+	}
+
+	// 2. it is deleted once the TTL elapsed
+	if remaining, err := reconciler.expire(ctx, frigate, frigate.Status.LastTransitionTime.Add(time.Minute)); err != nil || remaining != 0 {
+		t.Fatalf("should expire: %v %v", remaining, err)
+	}
+	if _, err = get("some"); !errors.IsNotFound(err) {
+		t.Errorf("expired frigate should be deleted, got %v", err)
+	}
+
+	// 3. Frigates without a TTL are kept
+	result, err = reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "kept"}})
+	if err != nil || result.RequeueAfter != 0 {
+		t.Errorf("expected no requeue, got %v %v", result, err)
+	}
+	if frigate, err = get("kept"); err != nil {
+		t.Fatalf("should get frigate: %v", err)
+	}
+	if remaining, err := reconciler.expire(ctx, frigate, time.Now().Add(time.Hour)); err != nil || remaining != 0 {
+		t.Errorf("should not expire: %v %v", remaining, err)
+	}
+	if _, err = get("kept"); err != nil {
+		t.Errorf("frigate without a ttl should be kept: %v", err)
+	}
+}
+
+// shedding is a pressure.Shedder with a fixed answer
+type shedding bool
+
+func (s shedding) Shedding() bool {
+	return bool(s)
+}
+
+func TestExpiryWhileShedding(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	ttl := int32(60)
+	finishedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	frigate := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default"},
+		Spec:       shipv1beta1.FrigateSpec{TTLSecondsAfterFinished: &ttl},
+		Status:     shipv1beta1.FrigateStatus{Phase: shipv1beta1.FrigateCompleted, LastTransitionTime: &finishedAt},
+	}
+	pressure := shedding(true)
+	reconciler := &FrigateReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, frigate),
+		Log:      logf.Log,
+		Scheme:   scheme,
+		Pressure: &pressure,
+	}
+	key := types.NamespacedName{Namespace: "default", Name: "some"}
+
+	// 1. the deletion is postponed while shedding load
+	if remaining, err := reconciler.expire(ctx, frigate, time.Now()); err != nil || remaining != sheddingRequeue {
+		t.Fatalf("expected the expiry to be postponed, got %v %v", remaining, err)
+	}
+	if err := reconciler.Get(ctx, key, &shipv1beta1.Frigate{}); err != nil {
+		t.Errorf("frigate should be kept while shedding: %v", err)
+	}
+
+	// 2. it is deleted once the pressure subsided
+	pressure = false
+	if remaining, err := reconciler.expire(ctx, frigate, time.Now()); err != nil || remaining != 0 {
+		t.Fatalf("should expire: %v %v", remaining, err)
+	}
+	if err := reconciler.Get(ctx, key, &shipv1beta1.Frigate{}); !errors.IsNotFound(err) {
+		t.Errorf("expired frigate should be deleted, got %v", err)
+	}
+}
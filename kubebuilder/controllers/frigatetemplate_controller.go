@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// FrigateTemplateReconciler creates the Frigates of the instances of a
+// FrigateTemplate and keeps their spec in sync with the template
+type FrigateTemplateReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the FrigateTemplate CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetemplates/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch;create;patch;delete
+
+func (r *FrigateTemplateReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("frigatetemplate", req.NamespacedName)
+
+	template := &shipv1beta1.FrigateTemplate{}
+	if err = r.Get(ctx, req.NamespacedName, template); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	// instances are garbage collected with the template
+	if template.DeletionTimestamp != nil {
+		return
+	}
+
+	frigates := &shipv1beta1.FrigateList{}
+	if err = r.List(ctx, frigates, client.InNamespace(template.Namespace), client.MatchingLabels{shipv1beta1.FrigateTemplateLabel: template.Name}); err != nil {
+		return
+	}
+	owned := map[string]*shipv1beta1.Frigate{}
+	for i := range frigates.Items {
+		if metav1.IsControlledBy(&frigates.Items[i], template) {
+			owned[frigates.Items[i].Name] = &frigates.Items[i]
+		}
+	}
+
+	status := template.Status.DeepCopy()
+	status.ObservedGeneration = template.Generation
+	status.Instances = nil
+	failed := 0
+	for _, instance := range template.Spec.Instances {
+		var instanceStatus shipv1beta1.InstanceStatus
+		if instanceStatus, err = r.instantiate(ctx, template, instance, owned[instance.Name]); err != nil {
+			return
+		}
+		if instanceStatus.Error != "" {
+			failed++
+		}
+		delete(owned, instance.Name)
+		status.Instances = append(status.Instances, instanceStatus)
+	}
+	// Frigates of removed instances
+	for _, frigate := range owned {
+		objdiff.Log(log, "deleting frigate of removed instance", frigate, nil)
+		if err = r.Delete(ctx, frigate); err != nil && !errors.IsNotFound(err) {
+			return
+		}
+		err = nil
+	}
+
+	ready := shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionReady,
+		Status:             shipv1beta1.ConditionTrue,
+		ObservedGeneration: template.Generation,
+		Reason:             "Instantiated",
+		Message:            fmt.Sprintf("%d Frigates instantiated", len(status.Instances)),
+	}
+	if failed > 0 {
+		ready.Status, ready.Reason = shipv1beta1.ConditionFalse, "InstantiationFailed"
+		ready.Message = fmt.Sprintf("%d of %d instances failed", failed, len(status.Instances))
+	}
+	conditions.SetStatusCondition(&status.Conditions, ready)
+
+	if reflect.DeepEqual(*status, template.Status) {
+		return
+	}
+	templateCopy := template.DeepCopy()
+	templateCopy.Status = *status
+	objdiff.Log(log, "reconcile changed frigatetemplate", template, templateCopy)
+	err = r.Status().Patch(ctx, templateCopy, client.MergeFrom(template))
+	return
+}
+
+// instantiate creates the Frigate of instance or updates its spec and
+// labels from the template. current is the Frigate controlled by the
+// template, nil when it does not exist yet. Errors retrying does not fix
+// until the template changes are reported in the status of the instance
+func (r *FrigateTemplateReconciler) instantiate(ctx context.Context, template *shipv1beta1.FrigateTemplate, instance shipv1beta1.TemplateInstance, current *shipv1beta1.Frigate) (shipv1beta1.InstanceStatus, error) {
+	log := r.Log.WithValues("frigatetemplate", template.Namespace+"/"+template.Name)
+	status := shipv1beta1.InstanceStatus{Name: instance.Name}
+	desired, err := template.Instantiate(instance)
+	if err != nil {
+		status.Error = err.Error()
+		return status, nil
+	}
+
+	if current == nil {
+		if err = ctrl.SetControllerReference(template, desired, r.Scheme); err != nil {
+			return status, err
+		}
+		objdiff.Log(log, "creating frigate", nil, desired)
+		if err = r.Create(ctx, desired); errors.IsAlreadyExists(err) {
+			status.Error = fmt.Sprintf("Frigate %s exists and is not controlled by the template", instance.Name)
+			return status, nil
+		}
+		return status, err
+	}
+
+	status.Phase = current.Status.Phase
+	// labels added by others, i.e. ShipLabelPolicies, are kept
+	updated := current.DeepCopy()
+	updated.Spec = desired.Spec
+	if updated.Labels == nil {
+		updated.Labels = map[string]string{}
+	}
+	for key, value := range desired.Labels {
+		updated.Labels[key] = value
+	}
+	if reflect.DeepEqual(current.Spec, updated.Spec) && reflect.DeepEqual(current.Labels, updated.Labels) {
+		return status, nil
+	}
+	objdiff.Log(log, "updating frigate", current, updated)
+	if err = r.Patch(ctx, updated, client.MergeFrom(current)); errors.IsForbidden(err) {
+		// i.e. the template changed a field that is immutable
+		status.Error = err.Error()
+		return status, nil
+	}
+	return status, err
+}
+
+func (r *FrigateTemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.FrigateTemplate{}).
+		Owns(&shipv1beta1.Frigate{}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("frigatetemplates"), r.Budget.Wrap("frigatetemplate", report.Wrap("frigatetemplate", r, r.Reporter))))
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFrigateTemplateInstances(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	template := &shipv1beta1.FrigateTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "patrol", Namespace: "default", UID: "1234"},
+		Spec: shipv1beta1.FrigateTemplateSpec{
+			Parameters: []shipv1beta1.TemplateParameter{{Name: "CALLSIGN", Required: true}},
+			Template: shipv1beta1.FrigateInstanceTemplate{
+				Spec: shipv1beta1.FrigateSpec{Foo: "$(CALLSIGN)"},
+			},
+			Instances: []shipv1beta1.TemplateInstance{
+				{Name: "alpha", Parameters: map[string]string{"CALLSIGN": "alpha"}},
+				{Name: "bravo", Parameters: map[string]string{"CALLSIGN": "bravo"}},
+				// someone else's Frigate
+				{Name: "taken", Parameters: map[string]string{"CALLSIGN": "taken"}},
+			},
+		},
+	}
+	taken := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "taken", Namespace: "default"}}
+	reconciler := &FrigateTemplateReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, template, taken),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "patrol"}}
+	reconcile := func() *shipv1beta1.FrigateTemplate {
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.FrigateTemplate{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get template: %v", err)
+		}
+		return result
+	}
+	frigate := func(name string) (*shipv1beta1.Frigate, error) {
+		result := &shipv1beta1.Frigate{}
+		err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: name}, result)
+		return result, err
+	}
+
+	// 1. a Frigate is created per instance, conflicts are reported
+	result := reconcile()
+	alpha, err := frigate("alpha")
+	if err != nil || alpha.Spec.Foo != "alpha" || !metav1.IsControlledBy(alpha, template) {
+		t.Fatalf("expected an owned frigate alpha, got %+v: %v", alpha, err)
+	}
+	if len(result.Status.Instances) != 3 || result.Status.Instances[2].Error == "" {
+		t.Errorf("expected the conflict to be reported, got %+v", result.Status.Instances)
+	}
+	if ready := result.Status.Conditions[0]; ready.Status != shipv1beta1.ConditionFalse || ready.Reason != "InstantiationFailed" {
+		t.Errorf("expected Ready False, got %+v", ready)
+	}
+
+	// 2. template changes are applied and removed instances deleted
+	result.Spec.Template.Spec.Foo = "$(CALLSIGN)-2"
+	result.Spec.Instances = result.Spec.Instances[:1]
+	if err := reconciler.Update(ctx, result); err != nil {
+		t.Fatalf("should update template: %v", err)
+	}
+	result = reconcile()
+	if alpha, _ = frigate("alpha"); alpha.Spec.Foo != "alpha-2" {
+		t.Errorf("expected the spec to be updated, got %q", alpha.Spec.Foo)
+	}
+	if _, err = frigate("bravo"); !errors.IsNotFound(err) {
+		t.Errorf("bravo should be deleted, got %v", err)
+	}
+	if _, err = frigate("taken"); err != nil {
+		t.Errorf("frigates not controlled by the template should be kept, got %v", err)
+	}
+	if ready := result.Status.Conditions[0]; ready.Status != shipv1beta1.ConditionTrue || len(result.Status.Instances) != 1 {
+		t.Errorf("expected Ready True with one instance, got %+v", result.Status)
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/pressure"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// FrigateTombstoneReconciler removes expired tombstones
+type FrigateTombstoneReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the FrigateTombstone CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+	// Pressure postpones the removal of expired tombstones while the
+	// API server is under pressure, optional
+	Pressure pressure.Shedder
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetombstones,verbs=get;list;watch;create;update;patch;delete
+
+func (r *FrigateTombstoneReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("frigatetombstone", req.NamespacedName)
+
+	tombstone := &shipv1beta1.FrigateTombstone{}
+	if err = r.Get(ctx, req.NamespacedName, tombstone); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	// not expired yet, come back when it does
+	if remaining := time.Until(tombstone.Spec.ExpiresAt.Time); remaining > 0 {
+		result.RequeueAfter = remaining
+		return
+	}
+
+	if r.Pressure != nil && r.Pressure.Shedding() {
+		log.V(1).Info("shedding load, postponing the removal")
+		result.RequeueAfter = sheddingRequeue
+		return
+	}
+	log.Info("tombstone expired", "frigate", tombstone.Spec.FrigateName, "expiresAt", tombstone.Spec.ExpiresAt)
+	if err = r.Delete(ctx, tombstone); errors.IsNotFound(err) {
+		err = nil
+	}
+	return
+}
+
+func (r *FrigateTombstoneReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.FrigateTombstone{}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("frigatetombstones"), r.Budget.Wrap("frigatetombstone", report.Wrap("frigatetombstone", r, r.Reporter))))
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTombstoneExpiry(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	tombstone := &shipv1beta1.FrigateTombstone{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-1234", Namespace: "default"},
+		Spec: shipv1beta1.FrigateTombstoneSpec{
+			FrigateName: "some",
+			ExpiresAt:   metav1.NewTime(time.Now().Add(time.Hour)),
+		},
+	}
+	reconciler := &FrigateTombstoneReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, tombstone),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some-1234"}}
+
+	// 1. tombstones are kept until they expire
+	result, err := reconciler.Reconcile(req)
+	if err != nil || result.RequeueAfter <= 0 || result.RequeueAfter > time.Hour {
+		t.Fatalf("expected a requeue at the expiry, got %+v %v", result, err)
+	}
+	if err = reconciler.Get(ctx, req.NamespacedName, tombstone); err != nil {
+		t.Fatalf("tombstone should be kept: %v", err)
+	}
+
+	// 2. and removed once they did
+	tombstone.Spec.ExpiresAt = metav1.NewTime(time.Now().Add(-time.Second))
+	if err = reconciler.Update(ctx, tombstone); err != nil {
+		t.Fatalf("should update tombstone: %v", err)
+	}
+	if result, err = reconciler.Reconcile(req); err != nil || result.RequeueAfter != 0 {
+		t.Fatalf("should remove the tombstone, got %+v %v", result, err)
+	}
+	if err = reconciler.Get(ctx, req.NamespacedName, &shipv1beta1.FrigateTombstone{}); !errors.IsNotFound(err) {
+		t.Errorf("expired tombstone should be removed, got %v", err)
+	}
+
+	// 3. tombstones already gone are ignored
+	if _, err = reconciler.Reconcile(req); err != nil {
+		t.Errorf("missing tombstones should be ignored: %v", err)
+	}
+}
+
+func TestTombstoneExpiryWhileShedding(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	tombstone := &shipv1beta1.FrigateTombstone{
+		ObjectMeta: metav1.ObjectMeta{Name: "some-1234", Namespace: "default"},
+		Spec: shipv1beta1.FrigateTombstoneSpec{
+			FrigateName: "some",
+			ExpiresAt:   metav1.NewTime(time.Now().Add(-time.Minute)),
+		},
+	}
+	pressure := shedding(true)
+	reconciler := &FrigateTombstoneReconciler{
+		Client:   fake.NewFakeClientWithScheme(scheme, tombstone),
+		Log:      logf.Log,
+		Scheme:   scheme,
+		Pressure: &pressure,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some-1234"}}
+
+	// 1. expired tombstones are kept while shedding load
+	result, err := reconciler.Reconcile(req)
+	if err != nil || result.RequeueAfter != sheddingRequeue {
+		t.Fatalf("expected the removal to be postponed, got %+v %v", result, err)
+	}
+	if err = reconciler.Get(ctx, req.NamespacedName, &shipv1beta1.FrigateTombstone{}); err != nil {
+		t.Errorf("tombstone should be kept while shedding: %v", err)
+	}
+
+	// 2. they are removed once the pressure subsided
+	pressure = false
+	if _, err = reconciler.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	if err = reconciler.Get(ctx, req.NamespacedName, &shipv1beta1.FrigateTombstone{}); !errors.IsNotFound(err) {
+		t.Errorf("expired tombstone should be removed, got %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/payload"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+const (
+	// TransferredFromAnnotation is set on Frigates created by a
+	// FrigateTransfer with the namespace/name of the source
+	TransferredFromAnnotation = "ship.danielfbm.github.io/transferred-from"
+	// DefaultTransferTombstoneTTL is used when tombstones are disabled
+	DefaultTransferTombstoneTTL = 24 * time.Hour
+
+	// serviceAccountMountPath is where the service account token volume,
+	// which is bound to the source namespace, is mounted
+	serviceAccountMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// FrigateTransferReconciler moves Frigates to another namespace
+type FrigateTransferReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Guards can veto phase transitions
+	Guards []lifecycle.Guard
+	// TombstoneTTL of the tombstone written for the source,
+	// DefaultTransferTombstoneTTL when zero
+	TombstoneTTL time.Duration
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the FrigateTransfer CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetransfers,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetransfers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigatetombstones,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *FrigateTransferReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("frigatetransfer", req.NamespacedName)
+
+	transfer := &shipv1beta1.FrigateTransfer{}
+	if err = r.Get(ctx, req.NamespacedName, transfer); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	// transfers run once, a finished one is kept as a record
+	if transfer.DeletionTimestamp != nil ||
+		transfer.Status.Phase == shipv1beta1.FrigateTransferCompleted ||
+		transfer.Status.Phase == shipv1beta1.FrigateTransferFailure {
+		return
+	}
+
+	transferCopy := transfer.DeepCopy()
+	stepErr := lifecycle.RunSteps(ctx, "frigatetransfer", transferCopy, r.steps())
+	lifecycle.SetConditions("FrigateTransfer", transferCopy, stepErr)
+	if stepErr == nil {
+		transferCopy.Status.ObservedGeneration = transfer.Generation
+	}
+	objdiff.Log(log, "reconcile changed frigatetransfer", transfer, transferCopy)
+
+	// the checkpoint is persisted even when a step failed
+	if err = r.Status().Patch(ctx, transferCopy, client.MergeFrom(transfer)); err != nil {
+		return
+	}
+	if err = stepErr; err != nil {
+		log.Error(err, "reconcile step failed", "step", transferCopy.Status.Checkpoint.Failed)
+	}
+	return
+}
+
+// steps of a transfer, in order. The source is only deleted once the
+// target and its data exist, every step can be run again after a failure
+func (r *FrigateTransferReconciler) steps() []lifecycle.Step {
+	return []lifecycle.Step{
+		{Name: "start", Run: func(ctx context.Context, obj lifecycle.Object) error {
+			if phase := obj.GetPhase(); phase != "" && phase != lifecycle.Pending {
+				return nil
+			}
+			return lifecycle.SetPhase(ctx, r.Guards, obj, lifecycle.Running, "", "")
+		}},
+		transferStep("accept", r.checkTarget),
+		transferStep("snapshot", r.snapshot),
+		transferStep("target", r.recreate),
+		transferStep("payloads", r.copyPayloads),
+		transferStep("children", r.moveChildren),
+		transferStep("source", r.deleteSource),
+		transferStep("phase", func(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+			return lifecycle.SetPhase(ctx, r.Guards, transfer, lifecycle.Completed, "",
+				fmt.Sprintf("moved to %s/%s", transfer.Spec.TargetNamespace, transferTargetName(transfer)))
+		}),
+	}
+}
+
+// transferStep adapts a step written for FrigateTransfers to lifecycle.Step.
+// Steps only run while the transfer is Running, so nothing is done for
+// a held transfer and nothing more after a failure
+func transferStep(name string, run func(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error) lifecycle.Step {
+	return lifecycle.Step{Name: name, Run: func(ctx context.Context, obj lifecycle.Object) error {
+		transfer := obj.(*shipv1beta1.FrigateTransfer)
+		if transfer.Status.Phase != shipv1beta1.FrigateTransferRunning {
+			return nil
+		}
+		return run(ctx, transfer)
+	}}
+}
+
+// fail moves the transfer to Failure. When a guard refuses it an error
+// is returned instead, the transfer cannot go on either way
+func (r *FrigateTransferReconciler) fail(ctx context.Context, transfer *shipv1beta1.FrigateTransfer, reason, message string) error {
+	if err := lifecycle.SetPhase(ctx, r.Guards, transfer, lifecycle.Failure, reason, message); err != nil {
+		return err
+	}
+	if transfer.Status.Phase != shipv1beta1.FrigateTransferFailure {
+		return fmt.Errorf("%s: %s", reason, message)
+	}
+	return nil
+}
+
+// transferTargetName is the name of the Frigate in the target namespace
+func transferTargetName(transfer *shipv1beta1.FrigateTransfer) string {
+	if transfer.Spec.TargetName != "" {
+		return transfer.Spec.TargetName
+	}
+	return transfer.Spec.Source
+}
+
+// checkTarget verifies the target namespace accepts transfers
+func (r *FrigateTransferReconciler) checkTarget(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	namespace := &corev1.Namespace{}
+	err := r.Get(ctx, types.NamespacedName{Name: transfer.Spec.TargetNamespace}, namespace)
+	switch {
+	case errors.IsNotFound(err):
+		return r.fail(ctx, transfer, shipv1beta1.ReasonTransferNotAccepted,
+			fmt.Sprintf("namespace %q not found", transfer.Spec.TargetNamespace))
+	case err != nil:
+		return err
+	case namespace.Labels[shipv1beta1.AcceptTransfersLabel] != "true":
+		return r.fail(ctx, transfer, shipv1beta1.ReasonTransferNotAccepted,
+			fmt.Sprintf("namespace %q is not labeled %s=true", transfer.Spec.TargetNamespace, shipv1beta1.AcceptTransfersLabel))
+	}
+	return nil
+}
+
+// snapshot writes the tombstone of the source, it holds everything
+// needed to recreate the Frigate and to restore it if the transfer is undone
+func (r *FrigateTransferReconciler) snapshot(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	if transfer.Status.Tombstone != "" {
+		return nil
+	}
+	source := &shipv1beta1.Frigate{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: transfer.Namespace, Name: transfer.Spec.Source}, source); err != nil {
+		if errors.IsNotFound(err) {
+			return r.fail(ctx, transfer, shipv1beta1.ReasonSourceNotFound,
+				fmt.Sprintf("Frigate %q not found", transfer.Spec.Source))
+		}
+		return err
+	}
+
+	ttl := r.TombstoneTTL
+	if ttl <= 0 {
+		ttl = DefaultTransferTombstoneTTL
+	}
+	tombstone := NewTombstone(source, ttl)
+	if err := r.Create(ctx, tombstone); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	transfer.Status.SourceUID = string(source.UID)
+	transfer.Status.Tombstone = tombstone.Name
+	return nil
+}
+
+// recreate creates the target Frigate from the snapshot
+func (r *FrigateTransferReconciler) recreate(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	tombstone := &shipv1beta1.FrigateTombstone{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: transfer.Namespace, Name: transfer.Status.Tombstone}, tombstone); err != nil {
+		return err
+	}
+	from := transfer.Namespace + "/" + transfer.Spec.Source
+	key := types.NamespacedName{Namespace: transfer.Spec.TargetNamespace, Name: transferTargetName(transfer)}
+
+	existing := &shipv1beta1.Frigate{}
+	err := r.Get(ctx, key, existing)
+	switch {
+	case err == nil && existing.Annotations[TransferredFromAnnotation] != from:
+		return r.fail(ctx, transfer, shipv1beta1.ReasonTargetExists,
+			fmt.Sprintf("Frigate %s already exists", key))
+	case err == nil:
+		// created by a previous attempt
+		return nil
+	case !errors.IsNotFound(err):
+		return err
+	}
+
+	snapshot := tombstone.Spec.Snapshot
+	target := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        key.Name,
+			Namespace:   key.Namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{TransferredFromAnnotation: from},
+		},
+		Spec: *snapshot.Spec.DeepCopy(),
+	}
+	for k, v := range snapshot.Labels {
+		// mirrored again once the target is reconciled
+		if k != PhaseLabel {
+			target.Labels[k] = v
+		}
+	}
+	for k, v := range snapshot.Annotations {
+		if k != TransferredFromAnnotation {
+			target.Annotations[k] = v
+		}
+	}
+	objdiff.Log(r.Log, "creating target frigate", nil, target, "frigatetransfer", transfer.Namespace+"/"+transfer.Name)
+	return r.Create(ctx, target)
+}
+
+// copyPayloads copies the payload ConfigMaps of the source to the
+// target, they are owned by the target and keep their payload name
+func (r *FrigateTransferReconciler) copyPayloads(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	configMaps := &corev1.ConfigMapList{}
+	if err := r.List(ctx, configMaps,
+		client.InNamespace(transfer.Namespace),
+		client.MatchingLabels{payload.OwnerLabel: transfer.Status.SourceUID},
+	); err != nil {
+		return err
+	}
+	if len(configMaps.Items) == 0 {
+		return nil
+	}
+
+	target := &shipv1beta1.Frigate{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: transfer.Spec.TargetNamespace, Name: transferTargetName(transfer)}, target); err != nil {
+		return err
+	}
+	store := &payload.Store{Client: r.Client, Scheme: r.Scheme}
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if _, err := store.Put(ctx, target, configMap.Labels[payload.NameLabel], configMap.BinaryData[payload.DataKey]); err != nil {
+			return err
+		}
+	}
+	transfer.Status.Payloads = int32(len(configMaps.Items))
+	return nil
+}
+
+// moveChildren recreates the bare Pods of the source in the target
+// namespace and deletes the originals. Pods with a controller are
+// recreated by it and are left alone
+func (r *FrigateTransferReconciler) moveChildren(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods,
+		client.InNamespace(transfer.Namespace),
+		client.MatchingLabels{FrigateLabel: transfer.Spec.Source},
+	); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if metav1.GetControllerOf(pod) != nil {
+			r.Log.Info("leaving controlled pod", "pod", pod.Name, "namespace", pod.Namespace)
+			continue
+		}
+		moved := transferredPod(pod, transfer.Spec.TargetNamespace, transferTargetName(transfer))
+		objdiff.Log(r.Log, "moving child pod", pod, moved, "frigatetransfer", transfer.Namespace+"/"+transfer.Name)
+		if err := r.Create(ctx, moved); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		transfer.Status.Children++
+	}
+	return nil
+}
+
+// transferredPod is a copy of pod for the target namespace. Fields set
+// by the scheduler and the service account token, which only works in
+// the source namespace, are left for the API server to set again
+func transferredPod(pod *corev1.Pod, namespace, frigate string) *corev1.Pod {
+	moved := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pod.Name,
+			Namespace:   namespace,
+			Labels:      map[string]string{},
+			Annotations: pod.Annotations,
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	for k, v := range pod.Labels {
+		moved.Labels[k] = v
+	}
+	moved.Labels[FrigateLabel] = frigate
+	moved.Spec.NodeName = ""
+
+	tokenVolumes := map[string]bool{}
+	stripTokenMounts := func(containers []corev1.Container) {
+		for i := range containers {
+			mounts := containers[i].VolumeMounts[:0]
+			for _, mount := range containers[i].VolumeMounts {
+				if mount.MountPath == serviceAccountMountPath {
+					tokenVolumes[mount.Name] = true
+					continue
+				}
+				mounts = append(mounts, mount)
+			}
+			containers[i].VolumeMounts = mounts
+		}
+	}
+	stripTokenMounts(moved.Spec.InitContainers)
+	stripTokenMounts(moved.Spec.Containers)
+	volumes := moved.Spec.Volumes[:0]
+	for _, volume := range moved.Spec.Volumes {
+		if !tokenVolumes[volume.Name] {
+			volumes = append(volumes, volume)
+		}
+	}
+	moved.Spec.Volumes = volumes
+	return moved
+}
+
+// deleteSource deletes the source Frigate. The uid precondition keeps
+// a Frigate recreated with the same name since the snapshot
+func (r *FrigateTransferReconciler) deleteSource(ctx context.Context, transfer *shipv1beta1.FrigateTransfer) error {
+	source := &shipv1beta1.Frigate{}
+	source.Namespace, source.Name = transfer.Namespace, transfer.Spec.Source
+	uid := types.UID(transfer.Status.SourceUID)
+	err := r.Delete(ctx, source, &client.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if errors.IsNotFound(err) || errors.IsConflict(err) {
+		err = nil
+	}
+	return err
+}
+
+func (r *FrigateTransferReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.FrigateTransfer{}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("frigatetransfers"), r.Budget.Wrap("frigatetransfer", report.Wrap("frigatetransfer", r, r.Reporter))))
+}
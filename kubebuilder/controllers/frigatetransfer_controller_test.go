@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/payload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestFrigateTransfer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	north := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "north",
+		Labels: map[string]string{shipv1beta1.AcceptTransfersLabel: "true"},
+	}}
+	south := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "south"}}
+	source := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some", Namespace: "default", UID: "1234",
+			Labels: map[string]string{"fleet": "blue", PhaseLabel: "Completed"},
+		},
+		Spec: shipv1beta1.FrigateSpec{Foo: "bar"},
+	}
+	report := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-report-payload", Namespace: "default",
+			Labels: map[string]string{payload.OwnerLabel: "1234", payload.NameLabel: "report"},
+		},
+		BinaryData: map[string][]byte{payload.DataKey: []byte("all good")},
+	}
+	bare := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crew", Namespace: "default", Labels: map[string]string{FrigateLabel: "some"}},
+		Spec: corev1.PodSpec{
+			NodeName: "node-1",
+			Volumes:  []corev1.Volume{{Name: "default-token-abcde"}, {Name: "data"}},
+			Containers: []corev1.Container{{Name: "crew", VolumeMounts: []corev1.VolumeMount{
+				{Name: "default-token-abcde", MountPath: serviceAccountMountPath},
+				{Name: "data", MountPath: "/data"},
+			}}},
+		},
+	}
+	isController := true
+	controlled := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "crew-abcde", Namespace: "default", Labels: map[string]string{FrigateLabel: "some"},
+		OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "crew", UID: "5678", Controller: &isController}},
+	}}
+	transfer := &shipv1beta1.FrigateTransfer{
+		ObjectMeta: metav1.ObjectMeta{Name: "move", Namespace: "default"},
+		Spec:       shipv1beta1.FrigateTransferSpec{Source: "some", TargetNamespace: "north"},
+	}
+	reconciler := &FrigateTransferReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, north, south, source, report, bare, controlled, transfer),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	reconcile := func(name string) *shipv1beta1.FrigateTransfer {
+		req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}}
+		if _, err := reconciler.Reconcile(req); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		result := &shipv1beta1.FrigateTransfer{}
+		if err := reconciler.Get(ctx, req.NamespacedName, result); err != nil {
+			t.Fatalf("should get transfer: %v", err)
+		}
+		return result
+	}
+
+	// 1. the Frigate is recreated in the target with its data and bare Pods
+	result := reconcile("move")
+	if result.Status.Phase != shipv1beta1.FrigateTransferCompleted {
+		t.Fatalf("expected Completed got %+v", result.Status)
+	}
+	if result.Status.Payloads != 1 || result.Status.Children != 1 {
+		t.Errorf("expected 1 payload and 1 child moved, got %+v", result.Status)
+	}
+	target := &shipv1beta1.Frigate{}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "north", Name: "some"}, target); err != nil {
+		t.Fatalf("should get target: %v", err)
+	}
+	if target.Spec.Foo != "bar" || target.Labels["fleet"] != "blue" || target.Labels[PhaseLabel] != "" {
+		t.Errorf("target should have the source spec and labels, got %+v", target)
+	}
+	if target.Annotations[TransferredFromAnnotation] != "default/some" {
+		t.Errorf("target should be annotated with its source, got %v", target.Annotations)
+	}
+	copied := &corev1.ConfigMap{}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "north", Name: "some-report-payload"}, copied); err != nil {
+		t.Fatalf("should copy payload: %v", err)
+	}
+	if string(copied.BinaryData[payload.DataKey]) != "all good" {
+		t.Errorf("unexpected payload %q", copied.BinaryData[payload.DataKey])
+	}
+	moved := &corev1.Pod{}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "north", Name: "crew"}, moved); err != nil {
+		t.Fatalf("should recreate pod: %v", err)
+	}
+	if moved.Spec.NodeName != "" || len(moved.Spec.Volumes) != 1 || len(moved.Spec.Containers[0].VolumeMounts) != 1 {
+		t.Errorf("node and token should be dropped, got %+v", moved.Spec)
+	}
+
+	// 2. the source is tombstoned and removed, controlled Pods are left alone
+	tombstone := &shipv1beta1.FrigateTombstone{}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: result.Status.Tombstone}, tombstone); err != nil {
+		t.Fatalf("should tombstone source: %v", err)
+	}
+	if tombstone.Spec.Snapshot.Spec.Foo != "bar" {
+		t.Errorf("tombstone should snapshot the source, got %+v", tombstone.Spec.Snapshot)
+	}
+	for _, key := range []types.NamespacedName{{Namespace: "default", Name: "some"}, {Namespace: "default", Name: "crew"}} {
+		var obj runtime.Object = &corev1.Pod{}
+		if key.Name == "some" {
+			obj = &shipv1beta1.Frigate{}
+		}
+		if err := reconciler.Get(ctx, key, obj); !errors.IsNotFound(err) {
+			t.Errorf("%s should be deleted, got %v", key, err)
+		}
+	}
+	if err := reconciler.Get(ctx, types.NamespacedName{Namespace: "default", Name: "crew-abcde"}, &corev1.Pod{}); err != nil {
+		t.Errorf("controlled pod should be kept: %v", err)
+	}
+
+	// 3. transfers fail for namespaces not accepting them and existing targets
+	for _, tt := range []struct {
+		name   string
+		spec   shipv1beta1.FrigateTransferSpec
+		reason string
+	}{
+		{"refused", shipv1beta1.FrigateTransferSpec{Source: "other", TargetNamespace: "south"}, shipv1beta1.ReasonTransferNotAccepted},
+		{"missing", shipv1beta1.FrigateTransferSpec{Source: "other", TargetNamespace: "west"}, shipv1beta1.ReasonTransferNotAccepted},
+		{"taken", shipv1beta1.FrigateTransferSpec{Source: "other", TargetNamespace: "north", TargetName: "some"}, shipv1beta1.ReasonTargetExists},
+		{"gone", shipv1beta1.FrigateTransferSpec{Source: "some", TargetNamespace: "north"}, shipv1beta1.ReasonSourceNotFound},
+	} {
+		other := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "4321"}}
+		if err := reconciler.Create(ctx, other); err != nil && !errors.IsAlreadyExists(err) {
+			t.Fatalf("should create frigate: %v", err)
+		}
+		if err := reconciler.Create(ctx, &shipv1beta1.FrigateTransfer{
+			ObjectMeta: metav1.ObjectMeta{Name: tt.name, Namespace: "default"},
+			Spec:       tt.spec,
+		}); err != nil {
+			t.Fatalf("should create transfer: %v", err)
+		}
+		if result := reconcile(tt.name); result.Status.Phase != shipv1beta1.FrigateTransferFailure || result.Status.Reason != tt.reason {
+			t.Errorf("%s: expected Failure with %s got %+v", tt.name, tt.reason, result.Status)
+		}
+	}
+	if err := reconciler.Get(ctx, client.ObjectKey{Namespace: "default", Name: "other"}, &shipv1beta1.Frigate{}); err != nil {
+		t.Errorf("failed transfers should keep their source: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// HarborReconciler counts the Frigates docked at a Harbor and reports
+// it Ready while it is within capacity
+type HarborReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the Harbor CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=harbors,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=harbors/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch
+
+func (r *HarborReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("harbor", req.NamespacedName)
+
+	harbor := &shipv1beta1.Harbor{}
+	if err = r.Get(ctx, req.NamespacedName, harbor); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	frigates := &shipv1beta1.FrigateList{}
+	if err = r.List(ctx, frigates, client.InNamespace(harbor.Namespace)); err != nil {
+		return
+	}
+	status := harbor.Status.DeepCopy()
+	status.ObservedGeneration = harbor.Generation
+	status.Docked = 0
+	for i := range frigates.Items {
+		if ref := frigates.Items[i].Spec.HarborRef; ref != nil && ref.Name == harbor.Name {
+			status.Docked++
+		}
+	}
+
+	ready := shipv1beta1.Condition{
+		Type:               shipv1beta1.ConditionReady,
+		Status:             shipv1beta1.ConditionTrue,
+		ObservedGeneration: harbor.Generation,
+		Reason:             "Available",
+		Message:            fmt.Sprintf("%d Frigates docked", status.Docked),
+	}
+	if harbor.Spec.Capacity > 0 && status.Docked > harbor.Spec.Capacity {
+		ready.Status, ready.Reason = shipv1beta1.ConditionFalse, "OverCapacity"
+		ready.Message = fmt.Sprintf("%d Frigates docked for a capacity of %d", status.Docked, harbor.Spec.Capacity)
+	}
+	conditions.SetStatusCondition(&status.Conditions, ready)
+
+	if reflect.DeepEqual(*status, harbor.Status) {
+		return
+	}
+	harborCopy := harbor.DeepCopy()
+	harborCopy.Status = *status
+	objdiff.Log(log, "reconcile changed harbor", harbor, harborCopy)
+	if err = r.Status().Patch(ctx, harborCopy, client.MergeFrom(harbor)); err != nil {
+		log.Error(err, "updating harbor status")
+	}
+	return
+}
+
+// harborForFrigate maps a Frigate to the Harbor it docks at. Updates
+// map both the old and new reference so the previous Harbor is updated too
+func harborForFrigate(obj handler.MapObject) []ctrl.Request {
+	frigate, ok := obj.Object.(*shipv1beta1.Frigate)
+	if !ok || frigate.Spec.HarborRef == nil {
+		return nil
+	}
+	return []ctrl.Request{{
+		NamespacedName: types.NamespacedName{Namespace: frigate.Namespace, Name: frigate.Spec.HarborRef.Name},
+	}}
+}
+
+func (r *HarborReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.Harbor{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Frigate{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(harborForFrigate),
+		}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("harbors"), r.Budget.Wrap("harbor", report.Wrap("harbor", r, r.Reporter))))
+}
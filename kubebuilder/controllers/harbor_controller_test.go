@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func dockedFrigate(name, harbor string) *shipv1beta1.Frigate {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       shipv1beta1.FrigateSpec{HarborRef: &shipv1beta1.HarborReference{Name: harbor}},
+	}
+}
+
+func TestHarborDocking(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	harbor := &shipv1beta1.Harbor{
+		ObjectMeta: metav1.ObjectMeta{Name: "north", Namespace: "default"},
+		Spec:       shipv1beta1.HarborSpec{Capacity: 1},
+	}
+	waiting := dockedFrigate("waiting", "north")
+	c := fake.NewFakeClientWithScheme(scheme, harbor, waiting, dockedFrigate("crowd", "north"), dockedFrigate("elsewhere", "south"))
+	harbors := &HarborReconciler{Client: c, Log: logf.Log, Scheme: scheme}
+	frigates := &FrigateReconciler{Client: c, Log: logf.Log, Scheme: scheme}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "north"}}
+
+	// 1. a Harbor over capacity is not Ready
+	if _, err := harbors.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	result := &shipv1beta1.Harbor{}
+	if err := c.Get(ctx, req.NamespacedName, result); err != nil {
+		t.Fatalf("should get harbor: %v", err)
+	}
+	if result.Status.Docked != 2 || !conditions.IsStatusConditionFalse(result.Status.Conditions, shipv1beta1.ConditionReady) {
+		t.Errorf("harbor should be over capacity, got %+v", result.Status)
+	}
+
+	// 2. and its Frigates are not Completed
+	if err := frigates.computePhase(ctx, waiting); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if waiting.Status.Phase != shipv1beta1.FrigatePending || waiting.Status.Reason != shipv1beta1.ReasonHarborNotReady {
+		t.Errorf("frigate should wait for the harbor, got %+v", waiting.Status)
+	}
+	missing := dockedFrigate("missing", "west")
+	if err := frigates.computePhase(ctx, missing); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if missing.Status.Phase != shipv1beta1.FrigatePending || missing.Status.Reason != shipv1beta1.ReasonHarborNotFound {
+		t.Errorf("frigate should wait for a missing harbor, got %+v", missing.Status)
+	}
+
+	// 3. the Frigate is Completed once the Harbor is Ready
+	result.Spec.Capacity = 0
+	if err := c.Update(ctx, result); err != nil {
+		t.Fatalf("should update harbor: %v", err)
+	}
+	if _, err := harbors.Reconcile(req); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	if err := frigates.computePhase(ctx, waiting); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if waiting.Status.Phase != shipv1beta1.FrigateCompleted || waiting.Status.Reason != "" {
+		t.Errorf("frigate should be Completed, got %+v", waiting.Status)
+	}
+
+	// 4. Harbor status changes requeue the docked Frigates
+	requests := frigates.frigatesForHarbor(handler.MapObject{Meta: result, Object: result})
+	if len(requests) != 2 {
+		t.Errorf("expected the 2 docked frigates, got %v", requests)
+	}
+	if requests := harborForFrigate(handler.MapObject{Meta: waiting, Object: waiting}); len(requests) != 1 || requests[0] != req {
+		t.Errorf("expected a request for the harbor, got %v", requests)
+	}
+}
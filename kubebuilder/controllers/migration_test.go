@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/util/retry"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// migrationFixtures holds Frigates as they were stored by previous
+// releases, one directory per release in the order they shipped.
+// When a change to the api types or the controller alters what gets
+// stored, add a directory with objects written by the release before it
+const migrationFixtures = "testdata/migration"
+
+// migrationFixture is a stored object and the outcome the current
+// controller should reach once it reconciles it
+type migrationFixture struct {
+	Path   string `json:"-"`
+	Expect struct {
+		Phase  string `json:"phase"`
+		Reason string `json:"reason,omitempty"`
+	} `json:"expect"`
+	Object json.RawMessage `json:"object"`
+}
+
+// release returns the directory of the fixture, used as namespace
+func (f migrationFixture) release() string {
+	return filepath.Base(filepath.Dir(f.Path))
+}
+
+func loadMigrationFixtures() ([]migrationFixture, error) {
+	paths, err := filepath.Glob(filepath.Join(migrationFixtures, "*", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	fixtures := make([]migrationFixture, 0, len(paths))
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fixture := migrationFixture{Path: path}
+		if err = json.Unmarshal(data, &fixture); err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// TestMigrationFixturesDecode fails when a field stored by a previous
+// release is no longer part of the api types, it would be silently
+// dropped on the next update made by the controller
+func TestMigrationFixturesDecode(t *testing.T) {
+	fixtures, err := loadMigrationFixtures()
+	if err != nil {
+		t.Fatalf("should load fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatalf("no fixtures found in %s", migrationFixtures)
+	}
+	for _, fixture := range fixtures {
+		decoder := json.NewDecoder(bytes.NewReader(fixture.Object))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&shipv1beta1.Frigate{}); err != nil {
+			t.Errorf("%s: %v", fixture.Path, err)
+		}
+	}
+}
+
+var _ = Describe("Migration", func() {
+	fixtures, err := loadMigrationFixtures()
+	if err != nil {
+		panic(err)
+	}
+
+	var (
+		ctx  context.Context
+		stop chan struct{}
+	)
+
+	BeforeEach(func() {
+		ctx = context.TODO()
+		stop = make(chan struct{})
+	})
+
+	AfterEach(func() {
+		close(stop)
+	})
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+		It("reconciles "+fixture.Path, func() {
+			namespace := "migration-" + strings.ReplaceAll(fixture.release(), ".", "-")
+			err := k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+			if !errors.IsAlreadyExists(err) {
+				Expect(err).ToNot(HaveOccurred(), "creating namespace")
+			}
+
+			// the object is written as stored, status included,
+			// before the current controller is started
+			stored := &unstructured.Unstructured{}
+			Expect(stored.UnmarshalJSON(fixture.Object)).To(Succeed(), "decoding fixture")
+			stored.SetNamespace(namespace)
+			status := stored.Object["status"]
+			Expect(k8sClient.Create(ctx, stored)).To(Succeed(), "creating stored frigate")
+			defer k8sClient.Delete(ctx, stored)
+			err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: stored.GetName()}, stored); err != nil {
+					return err
+				}
+				stored.Object["status"] = status
+				return k8sClient.Status().Update(ctx, stored)
+			})
+			Expect(err).ToNot(HaveOccurred(), "writing stored status")
+
+			manager, err := ctrl.NewManager(cfg, testManagerOptions())
+			Expect(err).ToNot(HaveOccurred(), "building manager")
+			Expect((&FrigateReconciler{Log: logf.Log}).SetupWithManager(manager)).To(Succeed(), "building controller")
+			go func() {
+				Expect(manager.Start(stop)).ToNot(HaveOccurred(), "starting manager")
+			}()
+
+			result := &shipv1beta1.Frigate{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, client.ObjectKey{Namespace: namespace, Name: stored.GetName()}, result)
+				return err == nil && result.Status.ObservedGeneration == result.Generation
+			}, 5*time.Second).Should(BeTrue(), "waiting for the current controller")
+			Expect(result.Status.Phase).To(Equal(fixture.Expect.Phase))
+			Expect(result.Status.Reason).To(Equal(fixture.Expect.Reason))
+		})
+	}
+})
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/labelpolicy"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+// ShipLabelPolicyReconciler repairs the labels ShipLabelPolicies require
+// on Frigates. Reconciles are per namespace, the name of the request
+// is ignored
+type ShipLabelPolicyReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+	// CRDs pauses reconciles while the ShipLabelPolicy CRD is missing, optional
+	CRDs *crdwatch.Watcher
+	// Budget limits the reconcile time of each namespace, optional
+	Budget *budget.Budget
+}
+
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=shiplabelpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ship.danielfbm.github.io,resources=frigates,verbs=get;list;watch;patch
+
+func (r *ShipLabelPolicyReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("namespace", req.Namespace)
+
+	policies := &shipv1beta1.ShipLabelPolicyList{}
+	if err = r.List(ctx, policies, client.InNamespace(req.Namespace)); err != nil || len(policies.Items) == 0 {
+		return
+	}
+	frigates := &shipv1beta1.FrigateList{}
+	if err = r.List(ctx, frigates, client.InNamespace(req.Namespace)); err != nil {
+		return
+	}
+	for i := range frigates.Items {
+		frigate := &frigates.Items[i]
+		var obj map[string]interface{}
+		if obj, err = runtime.DefaultUnstructuredConverter.ToUnstructured(frigate); err != nil {
+			return
+		}
+		required, conflicts := labelpolicy.Required(policies.Items, obj)
+		if len(conflicts) > 0 {
+			log.Info("conflicting label policies", "frigate", frigate.Name, "ignored", conflicts)
+		}
+		missing := labelpolicy.Missing(frigate.Labels, required)
+		if len(missing) == 0 {
+			continue
+		}
+		frigateCopy := frigate.DeepCopy()
+		if frigateCopy.Labels == nil {
+			frigateCopy.Labels = map[string]string{}
+		}
+		for key, value := range missing {
+			frigateCopy.Labels[key] = value
+		}
+		objdiff.Log(log, "repairing frigate labels", frigate, frigateCopy)
+		if err = r.Patch(ctx, frigateCopy, client.MergeFrom(frigate)); err != nil {
+			log.Error(err, "patching frigate labels", "frigate", frigate.Name)
+			return
+		}
+	}
+	return
+}
+
+// namespaceForFrigate maps a Frigate to the reconcile of its namespace
+func namespaceForFrigate(obj handler.MapObject) []ctrl.Request {
+	return []ctrl.Request{{NamespacedName: types.NamespacedName{Namespace: obj.Meta.GetNamespace()}}}
+}
+
+func (r *ShipLabelPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&shipv1beta1.ShipLabelPolicy{}).
+		Watches(&source.Kind{Type: &shipv1beta1.Frigate{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(namespaceForFrigate),
+		}).
+		Complete(r.CRDs.Pause(shipv1beta1.GroupVersion.WithResource("shiplabelpolicies"), r.Budget.Wrap("shiplabelpolicy", report.Wrap("shiplabelpolicy", r, r.Reporter))))
+}
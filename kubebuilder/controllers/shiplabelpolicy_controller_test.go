@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestShipLabelPolicy(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	policy := &shipv1beta1.ShipLabelPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "tiers", Namespace: "default"},
+		Spec: shipv1beta1.ShipLabelPolicySpec{Rules: []shipv1beta1.LabelRule{{
+			Match:  map[string]string{"spec.harborRef.name": "north"},
+			Labels: map[string]string{"tier": "premium"},
+		}}},
+	}
+	north := dockedFrigate("docked", "north")
+	north.Labels = map[string]string{"tier": "basic", "fleet": "blue"}
+	other := dockedFrigate("other", "north")
+	other.Namespace = "other"
+	reconciler := &ShipLabelPolicyReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, policy, north, other, dockedFrigate("elsewhere", "south")),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	labels := func(namespace, name string) map[string]string {
+		frigate := &shipv1beta1.Frigate{}
+		if err := reconciler.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, frigate); err != nil {
+			t.Fatalf("should get frigate: %v", err)
+		}
+		return frigate.Labels
+	}
+
+	// 1. matching Frigates are repaired, other labels are kept
+	if _, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default"}}); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	if got := labels("default", "docked"); got["tier"] != "premium" || got["fleet"] != "blue" {
+		t.Errorf("expected tier=premium and fleet=blue, got %v", got)
+	}
+	if got := labels("default", "elsewhere"); len(got) != 0 {
+		t.Errorf("unmatched frigate should not be labelled, got %v", got)
+	}
+
+	// 2. namespaces without policies are left alone
+	if _, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "other"}}); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	if got := labels("other", "other"); len(got) != 0 {
+		t.Errorf("frigates of other namespaces should not be labelled, got %v", got)
+	}
+}
@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"fmt"
+	"net"
 	"path/filepath"
 	"testing"
 
@@ -8,12 +10,14 @@ import (
 	. "github.com/onsi/gomega"
 
 	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/config/crd"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	mgr "sigs.k8s.io/controller-runtime/pkg/manager"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -35,12 +39,21 @@ func TestAPIs(t *testing.T) {
 var _ = BeforeSuite(func(done Done) {
 	logf.SetLogger(zap.LoggerTo(GinkgoWriter, true))
 
+	By("checking generated CRDs are up to date")
+	// CRDs are embedded at build time, if the api types changed
+	// since the last `make manifests` the suite would run against
+	// an outdated schema and fail in confusing ways
+	err := crd.CheckFresh(filepath.Join("..", "api"))
+	Expect(err).ToNot(HaveOccurred())
+
+	crds, err := crd.CRDs()
+	Expect(err).ToNot(HaveOccurred())
+
 	By("bootstrapping test environment")
 	testEnv = &envtest.Environment{
-		CRDDirectoryPaths: []string{filepath.Join("..", "config", "crd", "bases")},
+		CRDs: crds,
 	}
 
-	var err error
 	cfg, err = testEnv.Start()
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cfg).ToNot(BeNil())
@@ -57,6 +70,35 @@ var _ = BeforeSuite(func(done Done) {
 	close(done)
 }, 60)
 
+// testManagerOptions returns manager options binding metrics and webhooks
+// to free ephemeral ports, so managers started by different tests,
+// parallel ginkgo nodes or other test processes do not collide on :8080
+func testManagerOptions() mgr.Options {
+	metricsPort, err := freePort()
+	Expect(err).ToNot(HaveOccurred(), "allocating metrics port")
+	webhookPort, err := freePort()
+	Expect(err).ToNot(HaveOccurred(), "allocating webhook port")
+
+	return mgr.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: fmt.Sprintf("127.0.0.1:%d", metricsPort),
+		Host:               "127.0.0.1",
+		Port:               webhookPort,
+	}
+}
+
+// freePort asks the kernel for a free port
+// there is a small window where another process could take it
+// before the manager binds, which is good enough for tests
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
 var _ = AfterSuite(func() {
 	By("tearing down the test environment")
 	err := testEnv.Stop()
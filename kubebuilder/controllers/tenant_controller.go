@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+const (
+	// TenantLabel marks namespaces that should be bootstrapped for ship tenants
+	TenantLabel = "ship-tenant"
+	// TenantEditorsBinding is the RoleBinding created in tenant namespaces
+	TenantEditorsBinding = "ship-tenant-editors"
+	// DefaultTenantEditorRole is the ClusterRole granted to tenants
+	// matching config/rbac/frigate_editor_role.yaml after kustomize prefixes
+	DefaultTenantEditorRole = "controller-frigate-editor-role"
+	// TenantQuota is the ResourceQuota limiting the Frigates of a tenant
+	TenantQuota = "ship-tenant-quota"
+	// DefaultTenantFrigateQuota is the number of Frigates a tenant can create
+	DefaultTenantFrigateQuota = 50
+	// TenantConfig is the ConfigMap holding the ship settings of a tenant
+	TenantConfig = "ship-controller-config"
+	// TenantConfigShipClass is the key of TenantConfig naming the
+	// ShipClass tenants start with
+	TenantConfigShipClass = "defaultShipClass"
+	// TenantConfigFrigateQuota is the key of TenantConfig with the
+	// number of Frigates the tenant can create
+	TenantConfigFrigateQuota = "frigateQuota"
+)
+
+// frigateCount is the object count quota of Frigates
+var frigateCount = corev1.ResourceName("count/frigates." + shipv1beta1.GroupVersion.Group)
+
+// TenantReconciler bootstraps namespaces labeled with ship-tenant=true
+// and keeps the provisioned objects as declared
+type TenantReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// EditorRole is the ClusterRole bound to the tenant service accounts
+	EditorRole string
+	// FrigateQuota is the number of Frigates a tenant can create,
+	// defaults to DefaultTenantFrigateQuota
+	FrigateQuota int
+	// ShipClass is the default ShipClass recorded in the TenantConfig
+	// of every tenant, omitted when empty
+	ShipClass string
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+}
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind
+
+func (r *TenantReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("namespace", req.Name)
+
+	namespace := &corev1.Namespace{}
+	if err = r.Get(ctx, req.NamespacedName, namespace); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	if !isTenant(namespace) || namespace.DeletionTimestamp != nil {
+		return
+	}
+
+	// every object provisioned for a tenant is listed here
+	// adding a new one only requires a new desired function
+	for _, desired := range []func(*corev1.Namespace) runtime.Object{
+		r.editorsRoleBinding,
+		r.frigateQuota,
+		r.controllerConfig,
+	} {
+		if err = r.apply(ctx, namespace, desired(namespace)); err != nil {
+			log.Error(err, "bootstrapping tenant")
+			return
+		}
+	}
+	return
+}
+
+// editorsRoleBinding lets every service account of the tenant edit its Frigates
+func (r *TenantReconciler) editorsRoleBinding(namespace *corev1.Namespace) runtime.Object {
+	role := r.EditorRole
+	if role == "" {
+		role = DefaultTenantEditorRole
+	}
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TenantEditorsBinding,
+			Namespace: namespace.Name,
+			Labels:    map[string]string{TenantLabel: "true"},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     role,
+		},
+		Subjects: []rbacv1.Subject{{
+			APIGroup: rbacv1.GroupName,
+			Kind:     rbacv1.GroupKind,
+			Name:     "system:serviceaccounts:" + namespace.Name,
+		}},
+	}
+}
+
+// frigateQuota limits the number of Frigates of the tenant
+func (r *TenantReconciler) frigateQuota(namespace *corev1.Namespace) runtime.Object {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TenantQuota,
+			Namespace: namespace.Name,
+			Labels:    map[string]string{TenantLabel: "true"},
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{frigateCount: *resource.NewQuantity(int64(r.frigateQuotaLimit()), resource.DecimalSI)},
+		},
+	}
+}
+
+// controllerConfig tells tenants the class to start with and their quota
+func (r *TenantReconciler) controllerConfig(namespace *corev1.Namespace) runtime.Object {
+	config := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TenantConfig,
+			Namespace: namespace.Name,
+			Labels:    map[string]string{TenantLabel: "true"},
+		},
+		Data: map[string]string{TenantConfigFrigateQuota: strconv.Itoa(r.frigateQuotaLimit())},
+	}
+	if r.ShipClass != "" {
+		config.Data[TenantConfigShipClass] = r.ShipClass
+	}
+	return config
+}
+
+func (r *TenantReconciler) frigateQuotaLimit() int {
+	if r.FrigateQuota > 0 {
+		return r.FrigateQuota
+	}
+	return DefaultTenantFrigateQuota
+}
+
+// apply creates the object or restores its declared state
+func (r *TenantReconciler) apply(ctx context.Context, namespace *corev1.Namespace, desired runtime.Object) (err error) {
+	meta := desired.(metav1.Object)
+	if err = controllerutil.SetControllerReference(namespace, meta, r.Scheme); err != nil {
+		return
+	}
+	if binding, ok := desired.(*rbacv1.RoleBinding); ok {
+		if err = r.replaceRoleBinding(ctx, binding); err != nil {
+			return
+		}
+	}
+
+	// start from an empty object, a client reading from the API server
+	// merges into it and a prefilled one would hide the drift
+	key := metav1.ObjectMeta{Name: meta.GetName(), Namespace: meta.GetNamespace()}
+	var current runtime.Object
+	var restore func()
+	switch want := desired.(type) {
+	case *rbacv1.RoleBinding:
+		obj := &rbacv1.RoleBinding{ObjectMeta: key}
+		current, restore = obj, func() {
+			obj.RoleRef = want.RoleRef
+			obj.Subjects = want.Subjects
+		}
+	case *corev1.ResourceQuota:
+		obj := &corev1.ResourceQuota{ObjectMeta: key}
+		current, restore = obj, func() { obj.Spec.Hard = want.Spec.Hard }
+	case *corev1.ConfigMap:
+		obj := &corev1.ConfigMap{ObjectMeta: key}
+		current, restore = obj, func() { obj.Data = want.Data }
+	default:
+		return fmt.Errorf("unsupported tenant object %T", desired)
+	}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, current, func() error {
+		restore()
+		current.(metav1.Object).SetLabels(meta.GetLabels())
+		current.(metav1.Object).SetOwnerReferences(meta.GetOwnerReferences())
+		return nil
+	})
+	return
+}
+
+// replaceRoleBinding deletes the RoleBinding when it refers to another
+// role, the roleRef of a RoleBinding cannot be updated
+func (r *TenantReconciler) replaceRoleBinding(ctx context.Context, desired *rbacv1.RoleBinding) error {
+	current := &rbacv1.RoleBinding{}
+	if err := r.Get(ctx, client.ObjectKey{Namespace: desired.Namespace, Name: desired.Name}, current); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if current.RoleRef == desired.RoleRef {
+		return nil
+	}
+	r.Log.Info("replacing role binding", "namespace", desired.Namespace, "name", desired.Name,
+		"from", current.RoleRef.Name, "to", desired.RoleRef.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, current))
+}
+
+// isTenant accepts tenant namespaces and the objects created for them
+func isTenant(meta metav1.Object) bool {
+	return meta.GetLabels()[TenantLabel] == "true"
+}
+
+// tenantEvents only lets through namespaces labeled as tenants and the
+// objects they own. Updates removing the label are kept so it is restored
+var tenantEvents = predicate.Funcs{
+	CreateFunc:  func(e event.CreateEvent) bool { return isTenant(e.Meta) },
+	UpdateFunc:  func(e event.UpdateEvent) bool { return isTenant(e.MetaOld) || isTenant(e.MetaNew) },
+	DeleteFunc:  func(e event.DeleteEvent) bool { return isTenant(e.Meta) },
+	GenericFunc: func(e event.GenericEvent) bool { return isTenant(e.Meta) },
+}
+
+func (r *TenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&corev1.Namespace{}).
+		Owns(&rbacv1.RoleBinding{}).
+		Owns(&corev1.ResourceQuota{}).
+		Owns(&corev1.ConfigMap{}).
+		WithEventFilter(tenantEvents).
+		Complete(report.Wrap("tenant", r, r.Reporter))
+}
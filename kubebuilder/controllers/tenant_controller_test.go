@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestTenantBootstrap(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	rbacv1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "blue", UID: "1234", Labels: map[string]string{TenantLabel: "true"}}}
+	reconciler := &TenantReconciler{
+		Client:       fake.NewFakeClientWithScheme(scheme, tenant),
+		Log:          logf.Log,
+		Scheme:       scheme,
+		FrigateQuota: 10,
+		ShipClass:    "starter",
+	}
+	reconcile := func() {
+		if _, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "blue"}}); err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+	}
+	get := func(name string, obj runtime.Object) {
+		if err := reconciler.Get(ctx, client.ObjectKey{Namespace: "blue", Name: name}, obj); err != nil {
+			t.Fatalf("should get %s: %v", name, err)
+		}
+	}
+
+	// 1. the role binding, quota and config are created and owned by the namespace
+	reconcile()
+	binding := &rbacv1.RoleBinding{}
+	quota := &corev1.ResourceQuota{}
+	config := &corev1.ConfigMap{}
+	get(TenantEditorsBinding, binding)
+	get(TenantQuota, quota)
+	get(TenantConfig, config)
+	if binding.RoleRef.Name != DefaultTenantEditorRole || len(binding.Subjects) != 1 || binding.Subjects[0].Name != "system:serviceaccounts:blue" {
+		t.Errorf("unexpected role binding %+v", binding)
+	}
+	if hard := quota.Spec.Hard[frigateCount]; hard.Value() != 10 {
+		t.Errorf("expected a quota of 10 frigates, got %v", quota.Spec.Hard)
+	}
+	if config.Data[TenantConfigShipClass] != "starter" || config.Data[TenantConfigFrigateQuota] != "10" {
+		t.Errorf("unexpected config %v", config.Data)
+	}
+	for _, obj := range []metav1.Object{binding, quota, config} {
+		if !metav1.IsControlledBy(obj, tenant) || !isTenant(obj) {
+			t.Errorf("%s should be labeled and owned by the namespace", obj.GetName())
+		}
+	}
+
+	// 2. drift is repaired, the tenant label included
+	binding.Subjects = nil
+	binding.Labels = nil
+	quota.Spec.Hard = corev1.ResourceList{frigateCount: resource.MustParse("1000")}
+	config.Data = map[string]string{TenantConfigShipClass: "fast"}
+	for _, obj := range []runtime.Object{binding, quota, config} {
+		if err := reconciler.Update(ctx, obj); err != nil {
+			t.Fatalf("should update: %v", err)
+		}
+	}
+	reconcile()
+	binding = &rbacv1.RoleBinding{}
+	quota = &corev1.ResourceQuota{}
+	config = &corev1.ConfigMap{}
+	get(TenantEditorsBinding, binding)
+	get(TenantQuota, quota)
+	get(TenantConfig, config)
+	if len(binding.Subjects) != 1 || !isTenant(binding) {
+		t.Errorf("role binding should be restored, got %+v", binding)
+	}
+	if hard := quota.Spec.Hard[frigateCount]; hard.Value() != 10 {
+		t.Errorf("quota should be restored, got %v", quota.Spec.Hard)
+	}
+	if config.Data[TenantConfigShipClass] != "starter" || config.Data[TenantConfigFrigateQuota] != "10" {
+		t.Errorf("config should be restored, got %v", config.Data)
+	}
+
+	// 3. a new editor role replaces the binding, its roleRef is immutable
+	reconciler.EditorRole = "other-role"
+	reconcile()
+	binding = &rbacv1.RoleBinding{}
+	get(TenantEditorsBinding, binding)
+	if binding.RoleRef.Name != "other-role" {
+		t.Errorf("expected the binding to refer to other-role, got %+v", binding.RoleRef)
+	}
+}
+
+func TestTenantIgnoresUnlabeledNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	rbacv1.AddToScheme(scheme)
+
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "plain", UID: "1234"}}
+	reconciler := &TenantReconciler{
+		Client: fake.NewFakeClientWithScheme(scheme, namespace),
+		Log:    logf.Log,
+		Scheme: scheme,
+	}
+	if _, err := reconciler.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Name: "plain"}}); err != nil {
+		t.Fatalf("should reconcile: %v", err)
+	}
+	for _, obj := range []runtime.Object{&rbacv1.RoleBinding{}, &corev1.ResourceQuota{}} {
+		name := TenantEditorsBinding
+		if _, ok := obj.(*corev1.ResourceQuota); ok {
+			name = TenantQuota
+		}
+		if err := reconciler.Get(context.TODO(), client.ObjectKey{Namespace: "plain", Name: name}, obj); !errors.IsNotFound(err) {
+			t.Errorf("nothing should be provisioned in unlabeled namespaces, got %v", err)
+		}
+	}
+}
+
+func TestTenantEvents(t *testing.T) {
+	labeled := &rbacv1.RoleBinding{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{TenantLabel: "true"}}}
+	unlabeled := &rbacv1.RoleBinding{}
+	for _, test := range []struct {
+		name     string
+		old, new metav1.Object
+		expected bool
+	}{
+		{"labeled", labeled, labeled, true},
+		{"label removed", labeled, unlabeled, true},
+		{"label added", unlabeled, labeled, true},
+		{"never labeled", unlabeled, unlabeled, false},
+	} {
+		if got := tenantEvents.Update(event.UpdateEvent{MetaOld: test.old, MetaNew: test.new}); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+	if tenantEvents.Create(event.CreateEvent{Meta: unlabeled}) {
+		t.Errorf("unlabeled objects should be filtered")
+	}
+}
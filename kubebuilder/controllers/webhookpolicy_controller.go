@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+)
+
+const (
+	// DefaultWebhookDeployment serves the webhooks after kustomize prefixes
+	DefaultWebhookDeployment = "controller-system/controller-controller-manager"
+	// DefaultWebhookConfiguration is the generated ValidatingWebhookConfiguration
+	// after kustomize prefixes
+	DefaultWebhookConfiguration = "controller-validating-webhook-configuration"
+)
+
+// WebhookPolicyReconciler keeps the failurePolicy of the validating webhooks
+// as configured, and fails open the non-critical ones while the Deployment
+// serving them has been unavailable for longer than Threshold
+type WebhookPolicyReconciler struct {
+	client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Deployment serving the webhooks
+	Deployment types.NamespacedName
+	// Configuration is the ValidatingWebhookConfiguration name
+	Configuration string
+	// Policies is the failurePolicy of each webhook while healthy, webhooks
+	// not listed keep their policy or Fail when they are non-critical
+	Policies map[string]admissionregistrationv1beta1.FailurePolicyType
+	// NonCritical webhooks are switched to Ignore while unhealthy
+	NonCritical map[string]bool
+	// Threshold the Deployment must be unavailable before failing open
+	Threshold time.Duration
+
+	// Reporter receives the outcome of every reconcile, optional
+	Reporter report.Reporter
+}
+
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
+func (r *WebhookPolicyReconciler) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	ctx := context.Background()
+	log := r.Log.WithValues("deployment", r.Deployment)
+
+	deployment := &appsv1.Deployment{}
+	if err = r.Get(ctx, r.Deployment, deployment); err != nil {
+		if !errors.IsNotFound(err) {
+			return
+		}
+		// a deleted Deployment is as unhealthy as it gets
+		deployment = nil
+	}
+	configuration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+	if err = r.Get(ctx, types.NamespacedName{Name: r.Configuration}, configuration); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+
+	unhealthyFor := r.unhealthyFor(deployment)
+	failOpen := unhealthyFor >= r.Threshold
+	if unhealthyFor > 0 && !failOpen {
+		// check again once the threshold is reached
+		result.RequeueAfter = r.Threshold - unhealthyFor
+	}
+
+	changed := configuration.DeepCopy()
+	var flipped []string
+	for i := range changed.Webhooks {
+		webhook := &changed.Webhooks[i]
+		policy, ok := r.Policies[webhook.Name]
+		switch {
+		case ok:
+		case r.NonCritical[webhook.Name]:
+			// failing closed is the generated default
+			policy = admissionregistrationv1beta1.Fail
+		case webhook.FailurePolicy != nil:
+			policy = *webhook.FailurePolicy
+		}
+		if failOpen && r.NonCritical[webhook.Name] {
+			policy = admissionregistrationv1beta1.Ignore
+		}
+		if policy == "" || (webhook.FailurePolicy != nil && *webhook.FailurePolicy == policy) {
+			continue
+		}
+		webhook.FailurePolicy = &policy
+		flipped = append(flipped, fmt.Sprintf("%s=%s", webhook.Name, policy))
+	}
+	if len(flipped) == 0 {
+		return
+	}
+	if err = r.Update(ctx, changed); err != nil {
+		return
+	}
+
+	reason, message := "WebhooksFailClosed", fmt.Sprintf("Deployment %s is available, failure policies restored: %v", r.Deployment, flipped)
+	if failOpen {
+		reason, message = "WebhooksFailOpen", fmt.Sprintf("Deployment %s unavailable for %s, failing open: %v", r.Deployment, unhealthyFor.Round(time.Second), flipped)
+	}
+	log.Info(message)
+	if r.Recorder != nil {
+		r.Recorder.Event(configuration, corev1.EventTypeWarning, reason, message)
+	}
+	return
+}
+
+// unhealthyFor returns for how long the Deployment has no available replica
+func (r *WebhookPolicyReconciler) unhealthyFor(deployment *appsv1.Deployment) time.Duration {
+	if deployment == nil {
+		return r.Threshold
+	}
+	if deployment.Status.AvailableReplicas > 0 {
+		return 0
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable && condition.Status != corev1.ConditionTrue {
+			if since := time.Since(condition.LastTransitionTime.Time); since > 0 {
+				return since
+			}
+		}
+	}
+	// not reported yet, start counting on the next update
+	return time.Nanosecond
+}
+
+func (r *WebhookPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.Client = mgr.GetClient()
+	r.Scheme = mgr.GetScheme()
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("webhook-policy")
+	}
+
+	// only the serving Deployment and the configuration are relevant
+	relevant := func(namespace, name string) bool {
+		return (namespace == r.Deployment.Namespace && name == r.Deployment.Name) ||
+			(namespace == "" && name == r.Configuration)
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1.Deployment{}).
+		Watches(&source.Kind{Type: &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}}, &handler.EnqueueRequestForObject{}).
+		WithEventFilter(predicate.Funcs{
+			CreateFunc:  func(e event.CreateEvent) bool { return relevant(e.Meta.GetNamespace(), e.Meta.GetName()) },
+			UpdateFunc:  func(e event.UpdateEvent) bool { return relevant(e.MetaNew.GetNamespace(), e.MetaNew.GetName()) },
+			DeleteFunc:  func(e event.DeleteEvent) bool { return relevant(e.Meta.GetNamespace(), e.Meta.GetName()) },
+			GenericFunc: func(e event.GenericEvent) bool { return relevant(e.Meta.GetNamespace(), e.Meta.GetName()) },
+		}).
+		Complete(report.Wrap("webhookpolicy", r, r.Reporter))
+}
@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestWebhookPolicyReconciler(t *testing.T) {
+	ctx := context.TODO()
+	fail := admissionregistrationv1beta1.Fail
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: "webhooks"},
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+			Type:               appsv1.DeploymentAvailable,
+			Status:             corev1.ConditionFalse,
+			LastTransitionTime: metav1.NewTime(time.Now().Add(-5 * time.Minute)),
+		}}},
+	}
+	configuration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validating"},
+		Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+			{Name: "strict", FailurePolicy: &fail},
+			{Name: "critical", FailurePolicy: &fail},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &WebhookPolicyReconciler{
+		Client:        fake.NewFakeClientWithScheme(clientgoscheme.Scheme, deployment, configuration),
+		Log:           logf.Log,
+		Recorder:      recorder,
+		Deployment:    types.NamespacedName{Namespace: "system", Name: "webhooks"},
+		Configuration: "validating",
+		NonCritical:   map[string]bool{"strict": true},
+		Threshold:     2 * time.Minute,
+	}
+	policies := func() (strict, critical admissionregistrationv1beta1.FailurePolicyType) {
+		current := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+		if err := r.Get(ctx, types.NamespacedName{Name: "validating"}, current); err != nil {
+			t.Fatalf("should get configuration: %v", err)
+		}
+		return *current.Webhooks[0].FailurePolicy, *current.Webhooks[1].FailurePolicy
+	}
+
+	// 1. unavailable beyond the threshold fails open the non-critical webhook only
+	if _, err := r.Reconcile(ctrl.Request{}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if strict, critical := policies(); strict != admissionregistrationv1beta1.Ignore || critical != fail {
+		t.Errorf("expected strict=Ignore critical=Fail got %s %s", strict, critical)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("the flip should be explained in an event")
+	}
+	<-recorder.Events
+
+	// 2. once available again the policy is restored
+	deployment.Status.AvailableReplicas = 1
+	if err := r.Update(ctx, deployment); err != nil {
+		t.Fatalf("should update deployment: %v", err)
+	}
+	if _, err := r.Reconcile(ctrl.Request{}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if strict, _ := policies(); strict != fail {
+		t.Errorf("expected strict to fail closed again, got %s", strict)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("restoring should be explained in an event")
+	}
+}
@@ -0,0 +1,10 @@
+#!/usr/bin/env bash
+# Writes the hash of the api types used to generate the CRDs.
+# config/crd/crd.go compares it with the current types so tests
+# fail fast when `make manifests` was not run after changing them.
+set -o errexit
+set -o nounset
+set -o pipefail
+
+cd "$(dirname "${BASH_SOURCE[0]}")/.."
+cat $(ls api/*/*_types.go | LC_ALL=C sort) | sha256sum | cut -d' ' -f1 > config/crd/types.sha256
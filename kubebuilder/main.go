@@ -1,16 +1,57 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	shipv1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1"
 	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/config/crd"
+	"github.com/danielfbm/k8s-design-workshop/controller/config/samples"
 	"github.com/danielfbm/k8s-design-workshop/controller/controllers"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/apidocs"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/budget"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/buildinfo"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/certs"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/cloudevents"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/components"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/crdwatch"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/labelpolicy"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/lifecycle"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/objdiff"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/pressure"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/protection"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/quota"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/readonly"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/report"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/seed"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/selftest"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/standby"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/stream"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/strict"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/summary"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/templating"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/trigger"
+	uberzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -23,45 +64,676 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = shipv1beta1.AddToScheme(scheme)
+	_ = shipv1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var seedFile string
+	var seedTimeout time.Duration
+	var summaryAddr string
+	var warmStandby bool
+	var tenantEditorRole, tenantShipClass string
+	var tenantFrigateQuota int
+	var loadShedding, readOnly bool
+	var cloudEventsSink, cloudEventsNamespace string
+	var tombstoneTTL, coldStartWindow time.Duration
+	var frigateMaxChildren int
+	var controllersFlag, webhooksFlag string
+	var selftestNamespace string
+	var selftestInterval time.Duration
+	var reconcileReporters, reconcileReportURL string
+	var webhookDeployment, webhookConfiguration, webhookPolicies, webhookFailOpen string
+	var webhookUnhealthyThreshold, crdCheckInterval time.Duration
+	var logVerbosity int
+	var namespaceBudget time.Duration
+	var shipClassController string
+	var frigateQuotaConfigMap string
+	var webhookCertSecret, webhookService, webhookCertDir, webhookMutatingConfiguration string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&seedFile, "seed-file", "",
+		"YAML file with resources to apply once the manager starts. Used to prepare demo environments.")
+	flag.DurationVar(&seedTimeout, "seed-timeout", 5*time.Minute,
+		"How long applying the seed file may take, waiting for the seeded objects included.")
+	flag.StringVar(&summaryAddr, "summary-addr", ":8081",
+		"The address the read-only summary endpoint binds to. Use 0 to disable it.")
+	flag.BoolVar(&warmStandby, "warm-standby", true,
+		"Keep caches in sync while not being the leader so failover does not start from a cold cache.")
+	flag.StringVar(&tenantEditorRole, "tenant-editor-role", controllers.DefaultTenantEditorRole,
+		"ClusterRole bound to service accounts of namespaces labeled ship-tenant=true.")
+	flag.IntVar(&tenantFrigateQuota, "tenant-frigate-quota", controllers.DefaultTenantFrigateQuota,
+		"Number of Frigates each namespace labeled ship-tenant=true can create, enforced with a ResourceQuota.")
+	flag.StringVar(&tenantShipClass, "tenant-ship-class", "",
+		"ShipClass recorded as the default class in the ship-controller-config ConfigMap of tenant namespaces.")
+	flag.BoolVar(&loadShedding, "load-shedding", true,
+		"Throttle requests further when the API server keeps answering 429 Too Many Requests.")
+	flag.BoolVar(&readOnly, "read-only", false,
+		"Log and drop every write of the manager client so controller decisions can be observed safely during incidents. "+
+			"Leader election and events still write, combine with --enable-leader-election=false.")
+	flag.StringVar(&cloudEventsSink, "cloudevents-sink", "",
+		"URL that receives Frigate lifecycle CloudEvents. Publishing is disabled when empty.")
+	flag.StringVar(&cloudEventsNamespace, "cloudevents-namespace", "default",
+		"Namespace of the ConfigMap used as CloudEvents outbox.")
+	flag.DurationVar(&tombstoneTTL, "tombstone-ttl", 24*time.Hour,
+		"How long deleted Frigates can be restored with frigatectl undelete. Use 0 to disable tombstones.")
+	flag.IntVar(&frigateMaxChildren, "frigate-max-children", 20,
+		"Maximum number of Pods reported in Frigate status.children. Use 0 to stop watching Pods.")
+	flag.DurationVar(&coldStartWindow, "cold-start-window", 10*time.Second,
+		"After a restart, how long settled Frigates wait so the ones converging or being deleted are reconciled first. Use 0 to disable.")
+	flag.StringVar(&controllersFlag, "controllers", "*",
+		"Controllers to run. '*' runs all, 'name' enables and '-name' disables one, e.g. '*,-tenant'.")
+	flag.StringVar(&webhooksFlag, "webhooks", "*",
+		"Webhooks to serve, same syntax as --controllers. Allows running webhooks and controllers in separate Pods.")
+	flag.StringVar(&selftestNamespace, "selftest-namespace", "",
+		"Namespace dedicated to a canary Frigate exercised end to end, exported as controller_selftest_success. Disabled when empty.")
+	flag.DurationVar(&selftestInterval, "selftest-interval", 5*time.Minute,
+		"How often the canary Frigate goes through its lifecycle.")
+	flag.StringVar(&reconcileReporters, "reconcile-reporters", "",
+		"Comma separated reporters receiving the outcome of every reconcile, among log and prometheus.")
+	flag.StringVar(&reconcileReportURL, "reconcile-report-url", "",
+		"URL of a collector receiving batches of reconcile outcomes as JSON. Disabled when empty.")
+	flag.StringVar(&webhookDeployment, "webhook-deployment", controllers.DefaultWebhookDeployment,
+		"namespace/name of the Deployment serving the webhooks, watched by the webhookpolicy controller.")
+	flag.StringVar(&webhookConfiguration, "webhook-configuration", controllers.DefaultWebhookConfiguration,
+		"Name of the ValidatingWebhookConfiguration managed by the webhookpolicy controller.")
+	flag.StringVar(&webhookPolicies, "webhook-failure-policies", "",
+		"Failure policy of each webhook while healthy, i.e. 'strict.frigates.ship.danielfbm.github.io=Ignore'.")
+	flag.StringVar(&webhookFailOpen, "webhook-fail-open", "",
+		"Comma separated non-critical webhooks switched to Ignore while the webhook Deployment is unavailable.")
+	flag.DurationVar(&webhookUnhealthyThreshold, "webhook-unhealthy-threshold", 2*time.Minute,
+		"How long the webhook Deployment must be unavailable before failing open.")
+	flag.DurationVar(&crdCheckInterval, "crd-check-interval", crdwatch.DefaultInterval,
+		"How often to check the ship CRDs are installed. Controllers wait for missing CRDs and pause while they are deleted. Use 0 to disable.")
+	flag.DurationVar(&namespaceBudget, "namespace-reconcile-budget", 0,
+		"Reconcile time each namespace can use per second across controllers, requests of namespaces over it are postponed. Use 0 to disable.")
+	flag.StringVar(&shipClassController, "ship-class-controller", shipv1beta1.DefaultShipClassController,
+		"Controller name of the ShipClasses handled by the frigate controller, Frigates of other classes are ignored.")
+	flag.StringVar(&frigateQuotaConfigMap, "frigate-quota-configmap", quota.DefaultConfigMap,
+		"namespace/name of the ConfigMap limiting the number of Frigates per namespace, keyed by namespace with '*' as default.")
+	flag.StringVar(&webhookCertSecret, "webhook-cert-secret", "",
+		"namespace/name of the Secret where the manager keeps a self-signed CA and the webhook serving certificate, renewed before expiry. "+
+			"Leave empty when cert-manager provides the certificate.")
+	flag.StringVar(&webhookService, "webhook-service", "controller-system/controller-webhook-service",
+		"namespace/name of the Service of the webhooks, the serving certificate is issued for its DNS names.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", certs.DefaultCertDir,
+		"Directory the webhook server reads tls.crt and tls.key from.")
+	flag.StringVar(&webhookMutatingConfiguration, "webhook-mutating-configuration", "controller-mutating-webhook-configuration",
+		"Name of the MutatingWebhookConfiguration receiving the CA of --webhook-cert-secret.")
+	flag.IntVar(&logVerbosity, "log-verbosity", 1,
+		fmt.Sprintf("Highest V level logged. At %d and above reconciles log a redacted diff of the objects they change.", objdiff.Verbosity))
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = true
+		// logr V levels are negative zap levels
+		level := uberzap.NewAtomicLevelAt(zapcore.Level(-logVerbosity))
+		o.Level = &level
 	}))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	config := ctrl.GetConfigOrDie()
+	var detector *pressure.Detector
+	if loadShedding {
+		detector = pressure.NewDetector(pressure.DefaultOptions, ctrl.Log.WithName("pressure"))
+		detector.Instrument(config)
+	}
+
+	options := ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		LeaderElection:     enableLeaderElection,
 		Port:               9443,
-	})
+		CertDir:            webhookCertDir,
+	}
+	if crdCheckInterval > 0 {
+		// CRDs installed after the manager started must be mapped too
+		options.MapperProvider = func(c *rest.Config) (meta.RESTMapper, error) {
+			return apiutil.NewDynamicRESTMapper(c)
+		}
+	}
+	if readOnly {
+		setupLog.Info("read-only mode, writes are logged and dropped")
+		options.NewClient = readonly.NewClientFunc(ctrl.Log.WithName("readonly"))
+	}
+	mgr, err := ctrl.NewManager(config, options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
 	}
 
-	if err = (&controllers.FrigateReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Frigate"),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Frigate")
+	var publisher *cloudevents.Publisher
+	if cloudEventsSink != "" {
+		publisher = &cloudevents.Publisher{
+			Client:    mgr.GetClient(),
+			Reader:    mgr.GetAPIReader(),
+			Sink:      &cloudevents.HTTPSink{URL: cloudEventsSink},
+			Log:       ctrl.Log.WithName("cloudevents"),
+			Source:    "/apis/ship.danielfbm.github.io/frigates",
+			Namespace: cloudEventsNamespace,
+		}
+		if err = mgr.Add(publisher); err != nil {
+			setupLog.Error(err, "unable to add cloudevents publisher")
+			os.Exit(1)
+		}
+	}
+
+	var reporter report.Reporter
+	reporters, err := report.Parse(reconcileReporters, ctrl.Log.WithName("report"))
+	if err != nil {
+		setupLog.Error(err, "invalid --reconcile-reporters")
+		os.Exit(1)
+	}
+	if reconcileReportURL != "" {
+		collector := report.NewHTTPReporter(reconcileReportURL, ctrl.Log.WithName("report"))
+		if err = mgr.Add(collector); err != nil {
+			setupLog.Error(err, "unable to add reconcile report collector")
+			os.Exit(1)
+		}
+		reporters = append(reporters, collector)
+	}
+	if len(reporters) > 0 {
+		reporter = reporters
+	}
+
+	policies, err := parseFailurePolicies(webhookPolicies)
+	if err != nil {
+		setupLog.Error(err, "invalid --webhook-failure-policies")
+		os.Exit(1)
+	}
+	deploymentKey := strings.SplitN(webhookDeployment, "/", 2)
+	if len(deploymentKey) != 2 {
+		setupLog.Error(fmt.Errorf("expected namespace/name got %q", webhookDeployment), "invalid --webhook-deployment")
+		os.Exit(1)
+	}
+	quotaKey := strings.SplitN(frigateQuotaConfigMap, "/", 2)
+	if len(quotaKey) != 2 {
+		setupLog.Error(fmt.Errorf("expected namespace/name got %q", frigateQuotaConfigMap), "invalid --frigate-quota-configmap")
+		os.Exit(1)
+	}
+	nonCritical := map[string]bool{}
+	for _, name := range strings.Split(webhookFailOpen, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			nonCritical[name] = true
+		}
+	}
+
+	var crdWatcher *crdwatch.Watcher
+	if crdCheckInterval > 0 {
+		discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			setupLog.Error(err, "unable to create discovery client")
+			os.Exit(1)
+		}
+		crdWatcher = &crdwatch.Watcher{
+			Discovery: discoveryClient,
+			Interval:  crdCheckInterval,
+			Log:       ctrl.Log.WithName("crdwatch"),
+		}
+		if err = mgr.Add(crdWatcher); err != nil {
+			setupLog.Error(err, "unable to add CRD watcher")
+			os.Exit(1)
+		}
+	}
+
+	// shared by the controllers of namespaced resources
+	reconcileBudget := budget.New(namespaceBudget, ctrl.Log.WithName("budget"))
+
+	// external subsystems enqueue Frigates through frigateTriggers
+	frigateTriggers := trigger.NewChannel(100, func() trigger.Object { return &shipv1beta1.Frigate{} })
+
+	// every controller the manager can run, --controllers selects which ones
+	reconcilers := []struct {
+		name       string
+		reconciler reconciler
+	}{
+		{"frigate", &controllers.FrigateReconciler{
+			Client:          mgr.GetClient(),
+			Log:             ctrl.Log.WithName("controllers").WithName("Frigate"),
+			Scheme:          mgr.GetScheme(),
+			Events:          publisher,
+			TombstoneTTL:    tombstoneTTL,
+			Guards:          []lifecycle.Guard{lifecycle.HoldGuard{}, controllers.ArmadaGuard{Client: mgr.GetClient()}},
+			Triggers:        frigateTriggers,
+			MaxChildren:     frigateMaxChildren,
+			ColdStartWindow: coldStartWindow,
+			ClassController: shipClassController,
+			Reporter:        reporter,
+			CRDs:            crdWatcher,
+			Budget:          reconcileBudget,
+			Pressure:        detector,
+		}},
+		{"frigatetombstone", &controllers.FrigateTombstoneReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("FrigateTombstone"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+			Pressure: detector,
+		}},
+		{"destroyer", &controllers.DestroyerReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("Destroyer"),
+			Scheme:   mgr.GetScheme(),
+			Guards:   []lifecycle.Guard{lifecycle.HoldGuard{}},
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"fleet", &controllers.FleetReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("Fleet"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"frigatetransfer", &controllers.FrigateTransferReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("FrigateTransfer"),
+			Scheme:       mgr.GetScheme(),
+			Guards:       []lifecycle.Guard{lifecycle.HoldGuard{}},
+			TombstoneTTL: tombstoneTTL,
+			Reporter:     reporter,
+			CRDs:         crdWatcher,
+			Budget:       reconcileBudget,
+		}},
+		{"harbor", &controllers.HarborReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("Harbor"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"shiplabelpolicy", &controllers.ShipLabelPolicyReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("ShipLabelPolicy"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"cruisemission", &controllers.CruiseMissionReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("CruiseMission"),
+			Scheme:   mgr.GetScheme(),
+			Guards:   []lifecycle.Guard{lifecycle.HoldGuard{}},
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"frigatetemplate", &controllers.FrigateTemplateReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("FrigateTemplate"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+			Budget:   reconcileBudget,
+		}},
+		{"armada", &controllers.ArmadaReconciler{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("Armada"),
+			Scheme:   mgr.GetScheme(),
+			Reporter: reporter,
+			CRDs:     crdWatcher,
+		}},
+		{"tenant", &controllers.TenantReconciler{
+			Client:       mgr.GetClient(),
+			Log:          ctrl.Log.WithName("controllers").WithName("Tenant"),
+			Scheme:       mgr.GetScheme(),
+			EditorRole:   tenantEditorRole,
+			FrigateQuota: tenantFrigateQuota,
+			ShipClass:    tenantShipClass,
+			Reporter:     reporter,
+		}},
+		{"webhookpolicy", &controllers.WebhookPolicyReconciler{
+			Client:        mgr.GetClient(),
+			Log:           ctrl.Log.WithName("controllers").WithName("WebhookPolicy"),
+			Scheme:        mgr.GetScheme(),
+			Deployment:    types.NamespacedName{Namespace: deploymentKey[0], Name: deploymentKey[1]},
+			Configuration: webhookConfiguration,
+			Policies:      policies,
+			NonCritical:   nonCritical,
+			Threshold:     webhookUnhealthyThreshold,
+			Reporter:      reporter,
+		}},
+	}
+	names := make([]string, 0, len(reconcilers))
+	for _, r := range reconcilers {
+		names = append(names, r.name)
+	}
+	enabledControllers, err := components.Parse(controllersFlag, names)
+	if err != nil {
+		setupLog.Error(err, "invalid --controllers")
+		os.Exit(1)
+	}
+	capabilities := buildinfo.Capabilities{
+		Build: buildinfo.Get(),
+		FeatureGates: map[string]bool{
+			"LoadShedding":    loadShedding,
+			"WarmStandby":     warmStandby,
+			"ReadOnly":        readOnly,
+			"CloudEvents":     cloudEventsSink != "",
+			"Tombstones":      tombstoneTTL > 0,
+			"ColdStart":       coldStartWindow > 0,
+			"FrigateChildren": frigateMaxChildren > 0,
+			"Selftest":        selftestNamespace != "",
+			"CRDWatch":        crdWatcher != nil,
+			"NamespaceBudget": reconcileBudget != nil,
+		},
+	}
+	// CRDs the controllers watch, they are set up once every one is installed
+	frigates := shipv1beta1.GroupVersion.WithResource("frigates")
+	harbors := shipv1beta1.GroupVersion.WithResource("harbors")
+	armadas := shipv1beta1.GroupVersion.WithResource("armadas")
+	requiredCRDs := map[string][]schema.GroupVersionResource{
+		"frigate":          {frigates, shipv1beta1.GroupVersion.WithResource("frigatetombstones"), harbors, shipv1beta1.GroupVersion.WithResource("shipclasses"), armadas},
+		"frigatetombstone": {shipv1beta1.GroupVersion.WithResource("frigatetombstones")},
+		"destroyer":        {shipv1beta1.GroupVersion.WithResource("destroyers"), frigates},
+		"fleet":            {shipv1beta1.GroupVersion.WithResource("fleets"), frigates},
+		"harbor":           {harbors, frigates},
+		"frigatetransfer":  {shipv1beta1.GroupVersion.WithResource("frigatetransfers"), frigates, shipv1beta1.GroupVersion.WithResource("frigatetombstones")},
+		"shiplabelpolicy":  {shipv1beta1.GroupVersion.WithResource("shiplabelpolicies"), frigates},
+		"cruisemission":    {shipv1beta1.GroupVersion.WithResource("cruisemissions")},
+		"frigatetemplate":  {shipv1beta1.GroupVersion.WithResource("frigatetemplates"), frigates},
+		"armada":           {armadas, frigates},
+	}
+	if crdWatcher != nil {
+		for _, resources := range requiredCRDs {
+			for _, resource := range resources {
+				crdWatcher.Require(resource)
+			}
+		}
+		crdWatcher.Check()
+	}
+	for _, r := range reconcilers {
+		if !enabledControllers.Enabled(r.name) {
+			setupLog.Info("controller disabled", "controller", r.name)
+			continue
+		}
+		setup := r.reconciler.SetupWithManager
+		if crdWatcher != nil && len(requiredCRDs[r.name]) > 0 {
+			err = crdWatcher.SetupWhenAvailable(mgr, func() error { return setup(mgr) }, requiredCRDs[r.name]...)
+		} else {
+			err = setup(mgr)
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", r.name)
+			os.Exit(1)
+		}
+		capabilities.Controllers = append(capabilities.Controllers, r.name)
+	}
+
+	// every webhook the manager can serve, --webhooks selects which ones.
+	// They only read from the API server so they are declared with
+	// sideEffects None in config/webhook/sideeffects_patch.yaml and also answer
+	// dry run requests, keep it that way
+	webhooks := []struct {
+		name    string
+		webhook webhook
+	}{
+		// +kubebuilder:webhook:path=/validate-strict-ship-danielfbm-github-io-v1beta1-frigate,mutating=false,failurePolicy=fail,groups=ship.danielfbm.github.io,resources=frigates,verbs=create;update,versions=v1beta1;v1,name=strict.frigates.ship.danielfbm.github.io
+		// conversion between v1beta1 and v1 is served on /convert
+		{"frigate-conversion", &shipv1.Frigate{}},
+		{"strict-frigate", &strict.Validator{
+			Path: "/validate-strict-ship-danielfbm-github-io-v1beta1-frigate",
+			New:  func() runtime.Object { return &shipv1beta1.Frigate{} },
+			Versions: map[string]func() runtime.Object{
+				"v1": func() runtime.Object { return &shipv1.Frigate{} },
+			},
+		}},
+		// +kubebuilder:webhook:path=/validate-protection-ship-danielfbm-github-io-v1beta1-frigate,mutating=false,failurePolicy=fail,groups=ship.danielfbm.github.io,resources=frigates,verbs=delete,versions=v1beta1;v1,name=protection.frigates.ship.danielfbm.github.io
+		{"protection-frigate", &protection.Validator{
+			Path: "/validate-protection-ship-danielfbm-github-io-v1beta1-frigate",
+		}},
+		// +kubebuilder:webhook:path=/validate-quota-ship-danielfbm-github-io-v1beta1-frigate,mutating=false,failurePolicy=fail,groups=ship.danielfbm.github.io,resources=frigates,verbs=create,versions=v1beta1;v1,name=quota.frigates.ship.danielfbm.github.io
+		{"quota-frigate", &quota.Validator{
+			Path:      "/validate-quota-ship-danielfbm-github-io-v1beta1-frigate",
+			ConfigMap: types.NamespacedName{Namespace: quotaKey[0], Name: quotaKey[1]},
+		}},
+		// +kubebuilder:webhook:path=/mutate-templating-ship-danielfbm-github-io-v1beta1-frigate,mutating=true,failurePolicy=fail,groups=ship.danielfbm.github.io,resources=frigates,verbs=create;update,versions=v1beta1;v1,name=templating.frigates.ship.danielfbm.github.io
+		{"templating-frigate", &templating.Mutator{
+			Path:   "/mutate-templating-ship-danielfbm-github-io-v1beta1-frigate",
+			Fields: []string{"spec.foo"},
+			// foo was renamed to callsign in v1
+			Versions: map[string][]string{"v1": {"spec.callsign"}},
+		}},
+		// +kubebuilder:webhook:path=/mutate-labelpolicy-ship-danielfbm-github-io-v1beta1-frigate,mutating=true,failurePolicy=ignore,groups=ship.danielfbm.github.io,resources=frigates,verbs=create;update,versions=v1beta1,name=labelpolicy.frigates.ship.danielfbm.github.io
+		// the shiplabelpolicy controller repairs Frigates admitted while it is unavailable
+		{"labelpolicy-frigate", &labelpolicy.Mutator{
+			Path: "/mutate-labelpolicy-ship-danielfbm-github-io-v1beta1-frigate",
+		}},
+	}
+	names = make([]string, 0, len(webhooks))
+	for _, w := range webhooks {
+		names = append(names, w.name)
+	}
+	enabledWebhooks, err := components.Parse(webhooksFlag, names)
+	if err != nil {
+		setupLog.Error(err, "invalid --webhooks")
 		os.Exit(1)
 	}
+	for _, w := range webhooks {
+		if !enabledWebhooks.Enabled(w.name) {
+			setupLog.Info("webhook disabled", "webhook", w.name)
+			continue
+		}
+		if err = w.webhook.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", w.name)
+			os.Exit(1)
+		}
+		capabilities.Webhooks = append(capabilities.Webhooks, w.name)
+	}
 	// +kubebuilder:scaffold:builder
 
-	setupLog.Info("starting manager")
+	if selftestNamespace != "" {
+		if err = mgr.Add(&selftest.Canary{
+			Client:    mgr.GetClient(),
+			Log:       ctrl.Log.WithName("selftest"),
+			Namespace: selftestNamespace,
+			Interval:  selftestInterval,
+			Pressure:  detector,
+		}); err != nil {
+			setupLog.Error(err, "unable to add selftest canary")
+			os.Exit(1)
+		}
+	}
+
+	// runnables below run on every replica, not only on the leader
+	if webhookCertSecret != "" {
+		rotator, err := newCertRotator(config, webhookCertSecret, webhookService, webhookCertDir, webhookMutatingConfiguration, webhookConfiguration)
+		if err != nil {
+			setupLog.Error(err, "invalid webhook certificate flags")
+			os.Exit(1)
+		}
+		// the webhook server needs its certificate as soon as it starts
+		if err = rotator.Ensure(context.Background()); err != nil {
+			setupLog.Error(err, "unable to provision webhook certificate")
+			os.Exit(1)
+		}
+		if err = mgr.Add(rotator); err != nil {
+			setupLog.Error(err, "unable to add webhook certificate rotator")
+			os.Exit(1)
+		}
+	}
+	if detector != nil {
+		if err = mgr.Add(detector); err != nil {
+			setupLog.Error(err, "unable to add pressure detector")
+			os.Exit(1)
+		}
+	}
+	leader := &standby.Leader{}
+	if err = mgr.Add(leader); err != nil {
+		setupLog.Error(err, "unable to add leader tracker")
+		os.Exit(1)
+	}
+	if warmStandby {
+		if err = addWhenAvailable(mgr, crdWatcher, &standby.WarmCache{
+			Cache:   mgr.GetCache(),
+			Objects: []runtime.Object{&shipv1beta1.Frigate{}},
+			Log:     ctrl.Log.WithName("standby"),
+		}, frigates); err != nil {
+			setupLog.Error(err, "unable to add warm cache")
+			os.Exit(1)
+		}
+	}
+	if summaryAddr != "0" {
+		summaryServer := &summary.Server{
+			Addr:     summaryAddr,
+			Reader:   mgr.GetCache(),
+			IsLeader: leader.IsLeader,
+			Log:      ctrl.Log.WithName("summary"),
+		}
+		// live Frigate changes for front-ends, served next to the summary
+		broker := &stream.Broker{Cache: mgr.GetCache(), Log: ctrl.Log.WithName("stream")}
+		summaryServer.Handle("/streams/frigates", &stream.Handler{
+			Broker: broker,
+			Client: mgr.GetClient(),
+			Log:    ctrl.Log.WithName("stream"),
+		})
+		// API documentation generated from the embedded CRDs and samples
+		crds, err := crd.CRDs()
+		if err != nil {
+			setupLog.Error(err, "unable to decode embedded CRDs")
+			os.Exit(1)
+		}
+		docs, err := apidocs.New(crds, samples.Manifests)
+		if err != nil {
+			setupLog.Error(err, "unable to build API documentation")
+			os.Exit(1)
+		}
+		summaryServer.Handle(apidocs.Prefix, docs)
+		// what this build supports, for tooling and frigatectl version --server
+		capabilities.APIs = buildinfo.APIs(crds)
+		info := &buildinfo.Handler{Capabilities: capabilities}
+		summaryServer.Handle(buildinfo.VersionPath, info)
+		summaryServer.Handle(buildinfo.CapabilitiesPath, info)
+		if err = addWhenAvailable(mgr, crdWatcher, broker, frigates); err != nil {
+			setupLog.Error(err, "unable to add stream broker")
+			os.Exit(1)
+		}
+		if err = mgr.Add(summaryServer); err != nil {
+			setupLog.Error(err, "unable to add summary server")
+			os.Exit(1)
+		}
+	}
+
+	// seeding waits for the seeded objects, which never exist in read-only mode
+	if seedFile != "" && !readOnly {
+		if err = mgr.Add(seedRunnable(mgr, seedFile, seedTimeout)); err != nil {
+			setupLog.Error(err, "unable to add seed loader", "file", seedFile)
+			os.Exit(1)
+		}
+	}
+
+	setupLog.Info("starting manager", "version", capabilities.Build.Version, "commit", capabilities.Build.Commit)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// newCertRotator returns the Rotator of the webhook certificate stored
+// in secret, issued for the DNS names of service
+func newCertRotator(config *rest.Config, secret, service, certDir, mutating, validating string) (*certs.Rotator, error) {
+	secretKey := strings.SplitN(secret, "/", 2)
+	serviceKey := strings.SplitN(service, "/", 2)
+	if len(secretKey) != 2 || len(serviceKey) != 2 {
+		return nil, fmt.Errorf("expected namespace/name got %q and %q", secret, service)
+	}
+	// certificates are provisioned before the manager cache starts
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	name, namespace := serviceKey[1], serviceKey[0]
+	return &certs.Rotator{
+		Client: c,
+		Secret: types.NamespacedName{Namespace: secretKey[0], Name: secretKey[1]},
+		DNSNames: []string{
+			fmt.Sprintf("%s.%s.svc", name, namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+		},
+		CertDir:            certDir,
+		MutatingWebhooks:   []string{mutating},
+		ValidatingWebhooks: []string{validating},
+		CRDs:               []string{"frigates." + shipv1beta1.GroupVersion.Group},
+		Log:                ctrl.Log.WithName("certs"),
+	}, nil
+}
+
+// reconciler is implemented by all controllers
+type reconciler interface {
+	SetupWithManager(mgr ctrl.Manager) error
+}
+
+// addWhenAvailable adds runnable, which uses informers of resources,
+// once their CRDs are installed
+func addWhenAvailable(mgr ctrl.Manager, watcher *crdwatch.Watcher, runnable manager.Runnable, resources ...schema.GroupVersionResource) error {
+	if watcher == nil {
+		return mgr.Add(runnable)
+	}
+	return watcher.SetupWhenAvailable(mgr, func() error { return mgr.Add(runnable) }, resources...)
+}
+
+// webhook is implemented by all webhooks
+type webhook interface {
+	SetupWebhookWithManager(mgr ctrl.Manager) error
+}
+
+// parseFailurePolicies parses name=Policy pairs separated by commas
+func parseFailurePolicies(value string) (policies map[string]admissionregistrationv1beta1.FailurePolicyType, err error) {
+	policies = map[string]admissionregistrationv1beta1.FailurePolicyType{}
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected name=Policy got %q", pair)
+		}
+		switch policy := admissionregistrationv1beta1.FailurePolicyType(parts[1]); policy {
+		case admissionregistrationv1beta1.Fail, admissionregistrationv1beta1.Ignore:
+			policies[parts[0]] = policy
+		default:
+			return nil, fmt.Errorf("unknown failure policy %q for %s, must be Fail or Ignore", parts[1], parts[0])
+		}
+	}
+	return
+}
+
+// seedRunnable applies the seed file once caches are synced, giving up
+// after timeout
+func seedRunnable(mgr manager.Manager, file string, timeout time.Duration) manager.Runnable {
+	log := ctrl.Log.WithName("seed")
+	return manager.RunnableFunc(func(stop <-chan struct{}) error {
+		reader, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		loader := seed.NewLoader(mgr.GetClient(), mgr.GetRESTMapper(), log, seed.Options{Namespace: "default", Wait: true})
+		if err = loader.Load(ctx, reader); err != nil {
+			// a broken seed file should not take the controller down
+			log.Error(err, "seeding failed", "file", file)
+			return nil
+		}
+		log.Info("seeding done", "file", file)
+		return nil
+	})
+}
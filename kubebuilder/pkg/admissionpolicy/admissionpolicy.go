@@ -0,0 +1,203 @@
+// Package admissionpolicy describes the Frigate validations of the
+// webhooks as ValidatingAdmissionPolicies.
+//
+// Clusters preferring in-process admission can apply the generated
+// config/admissionpolicy/frigates.yaml instead of running the strict and
+// protection webhooks. ValidatingAdmissionPolicies need Kubernetes 1.30,
+// the webhooks stay the default. The namespace quota and the unknown
+// field checks have no CEL equivalent and still need their webhooks.
+//
+// Every validation names the field its Go counterpart reports, the
+// tests run the Go validations on shared fixtures and check each
+// rejected field has a CEL validation here. Evaluating the expressions
+// on the same fixtures needs the CEL library of k8s.io/apiserver, newer
+// than the Kubernetes libraries this project builds with.
+package admissionpolicy
+
+//go:generate go run ../../cmd/admissionpolicy-gen --output ../../config/admissionpolicy/frigates.yaml
+
+import (
+	"bytes"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/protection"
+)
+
+// Policy is a ValidatingAdmissionPolicy on Frigates and its binding
+type Policy struct {
+	Name string
+	// Operations the policy applies to, i.e. CREATE
+	Operations []string
+	// MatchConditions narrow the requests the policy applies to
+	MatchConditions []MatchCondition
+	Validations     []Validation
+}
+
+// MatchCondition is a CEL expression that must be true for the policy
+// to apply to the request
+type MatchCondition struct {
+	Name       string
+	Expression string
+}
+
+// Validation is a CEL expression that must be true to admit the request
+type Validation struct {
+	// Field reported by the Go validation, without list indexes
+	Field      string
+	Expression string
+	Message    string
+}
+
+// Policies mirror Frigate.ValidateSpec, Frigate.ValidateUpdate and the
+// protection webhook
+var Policies = []Policy{
+	{
+		Name:       "spec.frigates." + shipv1beta1.GroupVersion.Group,
+		Operations: []string{"CREATE", "UPDATE"},
+		// like the strict webhook, metadata updates and Frigates being
+		// deleted are admitted so their finalizers can be removed
+		MatchConditions: []MatchCondition{
+			{Name: "not-deleting", Expression: "!has(object.metadata.deletionTimestamp)"},
+			{Name: "spec-changed", Expression: "oldObject == null || object.spec != oldObject.spec"},
+		},
+		Validations: []Validation{
+			{
+				Field:      "spec.foo",
+				Expression: "(has(object.spec.replicas) && object.spec.replicas == 0) || (has(object.spec.foo) && object.spec.foo != '')",
+				Message:    "spec.foo is required unless replicas is 0, replicas defaults to 1",
+			},
+			{
+				Field:      "spec.configRef",
+				Expression: "!has(object.spec.configRef) || has(object.spec.cargoTemplate)",
+				Message:    "spec.configRef is only loaded into the Pods of cargoTemplate",
+			},
+			{
+				Field: "spec.expose.loadBalancerSourceRanges",
+				Expression: "!has(object.spec.expose) || !has(object.spec.expose.loadBalancerSourceRanges) || " +
+					"size(object.spec.expose.loadBalancerSourceRanges) == 0 || " +
+					"(has(object.spec.expose.type) && object.spec.expose.type == 'LoadBalancer')",
+				Message: "spec.expose.loadBalancerSourceRanges is only allowed for the LoadBalancer type",
+			},
+			{
+				Field: "spec.expose.loadBalancerSourceRanges",
+				Expression: "!has(object.spec.expose) || !has(object.spec.expose.loadBalancerSourceRanges) || " +
+					"object.spec.expose.loadBalancerSourceRanges.all(r, isCIDR(r))",
+				Message: "spec.expose.loadBalancerSourceRanges must be CIDRs, i.e. 203.0.113.0/24",
+			},
+			{
+				Field: "spec.expose.ports.name",
+				Expression: "!has(object.spec.expose) || size(object.spec.expose.ports) <= 1 || " +
+					"object.spec.expose.ports.all(p, has(p.name) && p.name != '' && " +
+					"object.spec.expose.ports.exists_one(q, has(q.name) && q.name == p.name))",
+				Message: "spec.expose.ports need distinct names when there are several",
+			},
+		},
+	},
+	{
+		Name:       "immutable.frigates." + shipv1beta1.GroupVersion.Group,
+		Operations: []string{"UPDATE"},
+		// the expressions hold when the spec is unchanged, Frigates being
+		// deleted are admitted whatever changed
+		MatchConditions: []MatchCondition{
+			{Name: "not-deleting", Expression: "!has(object.metadata.deletionTimestamp)"},
+		},
+		Validations: []Validation{
+			{
+				Field: "spec.frigateClassName",
+				Expression: "(has(object.spec.frigateClassName) ? object.spec.frigateClassName : '') == " +
+					"(has(oldObject.spec.frigateClassName) ? oldObject.spec.frigateClassName : '')",
+				Message: "spec.frigateClassName is immutable",
+			},
+			{
+				Field: "spec.harborRef",
+				Expression: "has(object.spec.harborRef) == has(oldObject.spec.harborRef) && " +
+					"(!has(object.spec.harborRef) || object.spec.harborRef == oldObject.spec.harborRef)",
+				Message: "spec.harborRef is immutable, recreate the Frigate to dock at another Harbor",
+			},
+		},
+	},
+	{
+		Name:       "protection.frigates." + shipv1beta1.GroupVersion.Group,
+		Operations: []string{"DELETE"},
+		Validations: []Validation{
+			{
+				Field: "metadata.annotations",
+				Expression: fmt.Sprintf("!has(oldObject.metadata.annotations) || !('%[1]s' in oldObject.metadata.annotations) || "+
+					"('%[2]s' in oldObject.metadata.annotations && oldObject.metadata.annotations['%[2]s'] == 'true')",
+					protection.ProtectedAnnotation, protection.ForceDeleteAnnotation),
+				Message: fmt.Sprintf("the Frigate is protected by the %s annotation, annotate it with %s=true to delete it",
+					protection.ProtectedAnnotation, protection.ForceDeleteAnnotation),
+			},
+		},
+	},
+}
+
+// Render returns the ValidatingAdmissionPolicy and binding manifests of
+// policies as a multi document YAML
+func Render(policies []Policy) ([]byte, error) {
+	out := &bytes.Buffer{}
+	out.WriteString("# Code generated by cmd/admissionpolicy-gen. DO NOT EDIT.\n")
+	for _, policy := range policies {
+		validations := make([]interface{}, 0, len(policy.Validations))
+		for _, validation := range policy.Validations {
+			validations = append(validations, map[string]interface{}{
+				"expression": validation.Expression,
+				"message":    validation.Message,
+				"reason":     "Invalid",
+			})
+		}
+		spec := map[string]interface{}{
+			"failurePolicy": "Fail",
+			"matchConstraints": map[string]interface{}{
+				// v1 requests are converted to v1beta1, where the
+				// expressions find spec.foo instead of spec.callsign
+				"matchPolicy": "Equivalent",
+				"resourceRules": []interface{}{map[string]interface{}{
+					"apiGroups":   []string{shipv1beta1.GroupVersion.Group},
+					"apiVersions": []string{shipv1beta1.GroupVersion.Version},
+					"operations":  policy.Operations,
+					"resources":   []string{"frigates"},
+				}},
+			},
+			"validations": validations,
+		}
+		if len(policy.MatchConditions) > 0 {
+			conditions := make([]interface{}, 0, len(policy.MatchConditions))
+			for _, condition := range policy.MatchConditions {
+				conditions = append(conditions, map[string]interface{}{
+					"name":       condition.Name,
+					"expression": condition.Expression,
+				})
+			}
+			spec["matchConditions"] = conditions
+		}
+		for _, doc := range []map[string]interface{}{
+			{
+				"apiVersion": "admissionregistration.k8s.io/v1",
+				"kind":       "ValidatingAdmissionPolicy",
+				"metadata":   map[string]interface{}{"name": policy.Name},
+				"spec":       spec,
+			},
+			{
+				"apiVersion": "admissionregistration.k8s.io/v1",
+				"kind":       "ValidatingAdmissionPolicyBinding",
+				"metadata":   map[string]interface{}{"name": policy.Name},
+				"spec": map[string]interface{}{
+					"policyName":        policy.Name,
+					"validationActions": []string{"Deny"},
+				},
+			},
+		} {
+			data, err := yaml.Marshal(doc)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString("---\n")
+			out.Write(data)
+		}
+	}
+	return out.Bytes(), nil
+}
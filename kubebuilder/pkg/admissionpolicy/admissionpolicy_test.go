@@ -0,0 +1,155 @@
+package admissionpolicy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/yaml"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/protection"
+)
+
+type fixture struct {
+	Name      string                     `json:"name"`
+	Operation admissionv1beta1.Operation `json:"operation"`
+	Object    *shipv1beta1.Frigate       `json:"object"`
+	OldObject *shipv1beta1.Frigate       `json:"oldObject"`
+	Denied    []string                   `json:"denied"`
+}
+
+var index = regexp.MustCompile(`\[[^]]*\]`)
+
+// denied runs the webhook validations of the fixture operation and
+// returns the fields rejected, without list indexes
+func (f fixture) denied(t *testing.T) []string {
+	fields := map[string]bool{}
+	switch f.Operation {
+	case admissionv1beta1.Delete:
+		raw, err := json.Marshal(f.OldObject)
+		if err != nil {
+			t.Fatalf("%s: should marshal: %v", f.Name, err)
+		}
+		validator := &protection.Validator{}
+		resp := validator.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: admissionv1beta1.Delete,
+			OldObject: runtime.RawExtension{Raw: raw},
+		}})
+		if !resp.Allowed {
+			fields["metadata.annotations"] = true
+		}
+	default:
+		errs := f.Object.ValidateSpec()
+		if f.Operation == admissionv1beta1.Update {
+			errs = append(errs, f.Object.ValidateUpdate(f.OldObject)...)
+		}
+		for _, err := range errs {
+			fields[index.ReplaceAllString(err.Field, "")] = true
+		}
+	}
+	result := []string{}
+	for field := range fields {
+		result = append(result, field)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestPoliciesMirrorWebhooks(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/frigates.yaml")
+	if err != nil {
+		t.Fatalf("should read fixtures: %v", err)
+	}
+	var fixtures []fixture
+	if err = yaml.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("should decode fixtures: %v", err)
+	}
+
+	exercised := map[string]bool{}
+	for _, f := range fixtures {
+		if f.Object == nil {
+			f.Object = &shipv1beta1.Frigate{}
+		}
+		denied := f.denied(t)
+		expected := append([]string{}, f.Denied...)
+		sort.Strings(expected)
+		if len(expected) == 0 {
+			expected = []string{}
+		}
+		// 1. the fixture describes what the webhooks do
+		if !reflect.DeepEqual(denied, expected) {
+			t.Errorf("%s: expected the webhooks to deny %v, got %v", f.Name, expected, denied)
+		}
+		// 2. a policy of the same operation checks every denied field
+		for _, field := range denied {
+			found := false
+			for _, policy := range Policies {
+				for _, validation := range policy.Validations {
+					if validation.Field == field && contains(policy.Operations, string(f.Operation)) {
+						exercised[policy.Name+" "+field] = true
+						found = true
+					}
+				}
+			}
+			if !found {
+				t.Errorf("%s: no %s policy validates %s", f.Name, f.Operation, field)
+			}
+		}
+	}
+
+	// 3. every validation has a fixture denied by it
+	for _, policy := range Policies {
+		for _, validation := range policy.Validations {
+			if !exercised[policy.Name+" "+validation.Field] {
+				t.Errorf("%s: no fixture is denied on %s", policy.Name, validation.Field)
+			}
+		}
+	}
+}
+
+func TestRenderMatchesManifest(t *testing.T) {
+	rendered, err := Render(Policies)
+	if err != nil {
+		t.Fatalf("should render: %v", err)
+	}
+	committed, err := ioutil.ReadFile("../../config/admissionpolicy/frigates.yaml")
+	if err != nil {
+		t.Fatalf("should read manifest: %v", err)
+	}
+	// compared as documents so the YAML emitter line wrapping does not matter
+	if !reflect.DeepEqual(documents(t, rendered), documents(t, committed)) {
+		t.Errorf("config/admissionpolicy/frigates.yaml is outdated, run go generate ./pkg/admissionpolicy")
+	}
+}
+
+func documents(t *testing.T, data []byte) []interface{} {
+	var docs []interface{}
+	for _, part := range bytes.Split(data, []byte("\n---\n")) {
+		var doc interface{}
+		if err := yaml.Unmarshal(part, &doc); err != nil {
+			t.Fatalf("should decode manifest: %v", err)
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+	return docs
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+// Package apidocs serves the API documentation of a group straight from
+// the embedded CustomResourceDefinitions and example manifests, so the
+// API surface can be explored without external docs tooling.
+//
+//	GET /apis-docs/<group>           every kind of the group
+//	GET /apis-docs/<group>/<plural>  a single kind
+package apidocs
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"sigs.k8s.io/yaml"
+)
+
+// Prefix all documentation is served under
+const Prefix = "/apis-docs/"
+
+// Group documents an API group
+type Group struct {
+	Name  string `json:"name"`
+	Kinds []Kind `json:"kinds"`
+}
+
+// Kind documents a kind of the group
+type Kind struct {
+	Kind       string    `json:"kind"`
+	Plural     string    `json:"plural"`
+	Scope      string    `json:"scope"`
+	ShortNames []string  `json:"shortNames,omitempty"`
+	Versions   []Version `json:"versions"`
+	// Examples are manifests of this kind, in YAML
+	Examples []string `json:"examples,omitempty"`
+}
+
+// Version documents a served version of a kind
+type Version struct {
+	Name    string                                `json:"name"`
+	Storage bool                                  `json:"storage"`
+	Schema  *apiextensionsv1beta1.JSONSchemaProps `json:"schema,omitempty"`
+}
+
+// Handler serves the documentation, build it using New
+type Handler struct {
+	groups map[string]*Group
+}
+
+var _ http.Handler = &Handler{}
+
+// New builds the documentation from crds and the YAML manifests in examples
+func New(crds []*apiextensionsv1beta1.CustomResourceDefinition, examples fs.FS) (*Handler, error) {
+	manifests, err := readExamples(examples)
+	if err != nil {
+		return nil, err
+	}
+	h := &Handler{groups: map[string]*Group{}}
+	for _, crd := range crds {
+		group := h.groups[crd.Spec.Group]
+		if group == nil {
+			group = &Group{Name: crd.Spec.Group}
+			h.groups[crd.Spec.Group] = group
+		}
+		kind := Kind{
+			Kind:       crd.Spec.Names.Kind,
+			Plural:     crd.Spec.Names.Plural,
+			Scope:      string(crd.Spec.Scope),
+			ShortNames: crd.Spec.Names.ShortNames,
+			Examples:   manifests[crd.Spec.Group+"/"+crd.Spec.Names.Kind],
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			doc := Version{Name: version.Name, Storage: version.Storage}
+			// per version schemas take precedence over the shared one
+			if version.Schema != nil {
+				doc.Schema = version.Schema.OpenAPIV3Schema
+			} else if crd.Spec.Validation != nil {
+				doc.Schema = crd.Spec.Validation.OpenAPIV3Schema
+			}
+			kind.Versions = append(kind.Versions, doc)
+		}
+		group.Kinds = append(group.Kinds, kind)
+	}
+	for _, group := range h.groups {
+		sort.Slice(group.Kinds, func(i, j int) bool { return group.Kinds[i].Kind < group.Kinds[j].Kind })
+	}
+	return h, nil
+}
+
+// readExamples returns the manifests in examples by group/kind
+func readExamples(examples fs.FS) (manifests map[string][]string, err error) {
+	manifests = map[string][]string{}
+	if examples == nil {
+		return
+	}
+	names, err := fs.Glob(examples, "*.yaml")
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		var data []byte
+		if data, err = fs.ReadFile(examples, name); err != nil {
+			return
+		}
+		var object struct {
+			APIVersion string `json:"apiVersion"`
+			Kind       string `json:"kind"`
+		}
+		if err = yaml.Unmarshal(data, &object); err != nil {
+			return
+		}
+		group := strings.Split(object.APIVersion, "/")[0]
+		manifests[group+"/"+object.Kind] = append(manifests[group+"/"+object.Kind], string(data))
+	}
+	return
+}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, Prefix), "/"), "/")
+	group := h.groups[parts[0]]
+	if group == nil || len(parts) > 2 {
+		http.NotFound(w, r)
+		return
+	}
+	var doc interface{} = group
+	if len(parts) == 2 {
+		doc = nil
+		for i := range group.Kinds {
+			if group.Kinds[i].Plural == parts[1] {
+				doc = group.Kinds[i]
+			}
+		}
+		if doc == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(doc)
+}
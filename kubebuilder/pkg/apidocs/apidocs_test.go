@@ -0,0 +1,55 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/config/crd"
+	"github.com/danielfbm/k8s-design-workshop/controller/config/samples"
+)
+
+func TestHandler(t *testing.T) {
+	crds, err := crd.CRDs()
+	if err != nil {
+		t.Fatalf("should decode embedded crds: %v", err)
+	}
+	handler, err := New(crds, samples.Manifests)
+	if err != nil {
+		t.Fatalf("should build docs: %v", err)
+	}
+
+	// 1. the group lists every kind with its schema
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/apis-docs/ship.danielfbm.github.io", nil))
+	group := Group{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &group); err != nil {
+		t.Fatalf("should return a group document: %v", err)
+	}
+	if len(group.Kinds) != len(crds) {
+		t.Errorf("expected %d kinds got %d", len(crds), len(group.Kinds))
+	}
+
+	// 2. a single kind carries its example manifest
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/apis-docs/ship.danielfbm.github.io/frigates", nil))
+	kind := Kind{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &kind); err != nil {
+		t.Fatalf("should return a kind document: %v", err)
+	}
+	if kind.Kind != "Frigate" || len(kind.Versions) == 0 || kind.Versions[0].Schema == nil {
+		t.Errorf("unexpected frigate docs %+v", kind)
+	}
+	if len(kind.Examples) != 1 || !strings.Contains(kind.Examples[0], "kind: Frigate") {
+		t.Errorf("expected the frigate sample, got %v", kind.Examples)
+	}
+
+	// 3. unknown groups are not found
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/apis-docs/ship.example.com", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected not found got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,152 @@
+// Package budget limits the reconcile time a namespace can use so one
+// tenant with many or slow objects cannot keep every worker busy.
+//
+// Every namespace gets Limit of reconcile time per Period. Time spent
+// reconciling is charged to the namespace of the request and paid back
+// continuously, a namespace over its budget has its requests requeued
+// until it is paid back instead of running them. The budget is shared by
+// every controller wrapped with the same Budget. Cluster scoped objects
+// are never throttled.
+package budget
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	throttledReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ship_namespace_throttled_reconciles_total",
+		Help: "Number of reconciles postponed because the namespace used its reconcile budget",
+	}, []string{"controller", "namespace"})
+	throttledNamespaces = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ship_throttled_namespaces",
+		Help: "Number of namespaces currently over their reconcile budget",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(throttledReconciles, throttledNamespaces)
+}
+
+// DefaultPeriod the limit of a Budget applies to
+const DefaultPeriod = time.Second
+
+// Budget tracks the reconcile time used by each namespace
+type Budget struct {
+	// Limit of reconcile time a namespace can use per Period
+	Limit time.Duration
+	// Period the limit applies to, DefaultPeriod when zero
+	Period time.Duration
+	Log    logr.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	lock       sync.Mutex
+	namespaces map[string]*usage
+}
+
+// usage is the reconcile time a namespace still has to pay back
+type usage struct {
+	debt      time.Duration
+	updated   time.Time
+	throttled bool
+}
+
+// New returns a Budget, nil when limit is zero so budgets are disabled
+func New(limit time.Duration, log logr.Logger) *Budget {
+	if limit <= 0 {
+		return nil
+	}
+	return &Budget{Limit: limit, Period: DefaultPeriod, Log: log, now: time.Now, namespaces: map[string]*usage{}}
+}
+
+// Wrap returns a reconciler running r while the namespace of the
+// request is within its budget, it is returned as is for a nil Budget
+func (b *Budget) Wrap(controller string, r reconcile.Reconciler) reconcile.Reconciler {
+	if b == nil {
+		return r
+	}
+	return reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+		if req.Namespace == "" {
+			return r.Reconcile(req)
+		}
+		if wait := b.Wait(req.Namespace); wait > 0 {
+			throttledReconciles.WithLabelValues(controller, req.Namespace).Inc()
+			return reconcile.Result{RequeueAfter: wait}, nil
+		}
+		begin := b.now()
+		defer func() { b.Charge(req.Namespace, b.now().Sub(begin)) }()
+		return r.Reconcile(req)
+	})
+}
+
+// Wait returns how long the namespace must wait before reconciling,
+// zero when it is within its budget
+func (b *Budget) Wait(namespace string) time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	u := b.repay(namespace)
+	if u == nil || u.debt < b.Limit {
+		if u != nil && u.throttled {
+			u.throttled = false
+			throttledNamespaces.Dec()
+			b.Log.Info("namespace back within its reconcile budget", "namespace", namespace)
+		}
+		return 0
+	}
+	if !u.throttled {
+		u.throttled = true
+		throttledNamespaces.Inc()
+		b.Log.Info("namespace over its reconcile budget, throttling", "namespace", namespace, "limit", b.Limit, "period", b.period())
+	}
+	// the debt is paid back at Limit per Period
+	return time.Duration(float64(u.debt-b.Limit+time.Millisecond) * float64(b.period()) / float64(b.Limit))
+}
+
+// Charge records reconcile time used by the namespace
+func (b *Budget) Charge(namespace string, used time.Duration) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	u := b.repay(namespace)
+	if u == nil {
+		u = &usage{updated: b.now()}
+		b.namespaces[namespace] = u
+	}
+	u.debt += used
+}
+
+// repay pays back the debt of a namespace for the time elapsed since
+// its last update, namespaces without debt are forgotten
+func (b *Budget) repay(namespace string) *usage {
+	u, ok := b.namespaces[namespace]
+	if !ok {
+		return nil
+	}
+	now := b.now()
+	u.debt -= time.Duration(float64(now.Sub(u.updated)) * float64(b.Limit) / float64(b.period()))
+	u.updated = now
+	if u.debt <= 0 && !u.throttled {
+		delete(b.namespaces, namespace)
+		return nil
+	}
+	if u.debt < 0 {
+		u.debt = 0
+	}
+	return u
+}
+
+func (b *Budget) period() time.Duration {
+	if b.Period <= 0 {
+		return DefaultPeriod
+	}
+	return b.Period
+}
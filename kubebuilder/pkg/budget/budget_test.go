@@ -0,0 +1,67 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestBudget(t *testing.T) {
+	now := time.Now()
+	budget := New(100*time.Millisecond, logf.Log)
+	budget.now = func() time.Time { return now }
+
+	var reconciled []string
+	r := budget.Wrap("test", reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+		reconciled = append(reconciled, req.Namespace)
+		// a slow reconcile
+		now = now.Add(150 * time.Millisecond)
+		return reconcile.Result{}, nil
+	}))
+	reconcileIn := func(namespace string) reconcile.Result {
+		result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: namespace, Name: "a"}})
+		if err != nil {
+			t.Fatalf("should reconcile: %v", err)
+		}
+		return result
+	}
+
+	// 1. a namespace within its budget is reconciled and charged
+	if result := reconcileIn("noisy"); result.RequeueAfter != 0 || len(reconciled) != 1 {
+		t.Fatalf("should reconcile within budget, got %+v", result)
+	}
+
+	// 2. over budget its requests are requeued until the debt is paid back
+	result := reconcileIn("noisy")
+	if len(reconciled) != 1 || result.RequeueAfter <= 0 {
+		t.Fatalf("should throttle, got %+v after %v", result, reconciled)
+	}
+	// other namespaces and cluster scoped objects are not affected
+	reconcileIn("quiet")
+	reconcileIn("")
+	if len(reconciled) != 3 {
+		t.Fatalf("other namespaces should be reconciled, got %v", reconciled)
+	}
+
+	// 3. once paid back the namespace is reconciled again
+	now = now.Add(result.RequeueAfter)
+	if result = reconcileIn("noisy"); result.RequeueAfter != 0 || len(reconciled) != 4 {
+		t.Errorf("should reconcile after waiting, got %+v", result)
+	}
+}
+
+func TestDisabledBudget(t *testing.T) {
+	var budget *Budget = New(0, logf.Log)
+	called := false
+	r := budget.Wrap("test", reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+		called = true
+		return reconcile.Result{}, nil
+	}))
+	r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "a"}})
+	if !called {
+		t.Errorf("a disabled budget should not throttle")
+	}
+}
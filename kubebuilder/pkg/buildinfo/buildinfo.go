@@ -0,0 +1,122 @@
+// Package buildinfo reports which build of the controller is running and
+// what it supports, so tooling and support scripts can adapt to it.
+//
+// Version, Commit and Date are set at build time, see the Makefile:
+//
+//	go build -ldflags "-X github.com/danielfbm/k8s-design-workshop/controller/pkg/buildinfo.Version=v0.1.0"
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+// set with -ldflags -X at build time
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+const (
+	// VersionPath serves Info
+	VersionPath = "/version"
+	// CapabilitiesPath serves Capabilities
+	CapabilitiesPath = "/capabilities"
+)
+
+// Info identifies a build
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the Info of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// API is a resource served by the controller CRDs
+type API struct {
+	Group    string `json:"group"`
+	Resource string `json:"resource"`
+	Kind     string `json:"kind"`
+	// Versions served by the API server, the storage version first
+	Versions []string `json:"versions"`
+}
+
+// Capabilities is what the running controller supports
+type Capabilities struct {
+	Build Info `json:"build"`
+	// FeatureGates are the optional features and whether they are enabled
+	FeatureGates map[string]bool `json:"featureGates"`
+	APIs         []API           `json:"apis"`
+	// Controllers and Webhooks enabled with --controllers and --webhooks
+	Controllers []string `json:"controllers"`
+	Webhooks    []string `json:"webhooks"`
+}
+
+// APIs lists the served versions of crds
+func APIs(crds []*apiextensionsv1beta1.CustomResourceDefinition) []API {
+	apis := make([]API, 0, len(crds))
+	for _, crd := range crds {
+		api := API{Group: crd.Spec.Group, Resource: crd.Spec.Names.Plural, Kind: crd.Spec.Names.Kind}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			if version.Storage {
+				api.Versions = append([]string{version.Name}, api.Versions...)
+			} else {
+				api.Versions = append(api.Versions, version.Name)
+			}
+		}
+		if len(crd.Spec.Versions) == 0 && crd.Spec.Version != "" {
+			api.Versions = []string{crd.Spec.Version}
+		}
+		apis = append(apis, api)
+	}
+	sort.Slice(apis, func(i, j int) bool {
+		if apis[i].Group != apis[j].Group {
+			return apis[i].Group < apis[j].Group
+		}
+		return apis[i].Resource < apis[j].Resource
+	})
+	return apis
+}
+
+// Handler serves VersionPath and CapabilitiesPath
+type Handler struct {
+	Capabilities Capabilities
+}
+
+var _ http.Handler = &Handler{}
+
+// ServeHTTP implements http.Handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body interface{}
+	switch r.URL.Path {
+	case VersionPath:
+		body = h.Capabilities.Build
+	case CapabilitiesPath:
+		body = h.Capabilities
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,62 @@
+package buildinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/config/crd"
+)
+
+func TestHandler(t *testing.T) {
+	crds, err := crd.CRDs()
+	if err != nil {
+		t.Fatalf("should decode embedded crds: %v", err)
+	}
+	handler := &Handler{Capabilities: Capabilities{
+		Build:        Get(),
+		FeatureGates: map[string]bool{"ReadOnly": false, "Tombstones": true},
+		APIs:         APIs(crds),
+		Controllers:  []string{"frigate"},
+	}}
+
+	// 1. the build is served on /version
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", VersionPath, nil))
+	info := Info{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &info); err != nil {
+		t.Fatalf("should return the build info: %v", err)
+	}
+	if info != Get() {
+		t.Errorf("expected %+v got %+v", Get(), info)
+	}
+
+	// 2. served versions list the storage version first
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", CapabilitiesPath, nil))
+	capabilities := Capabilities{}
+	if err = json.Unmarshal(recorder.Body.Bytes(), &capabilities); err != nil {
+		t.Fatalf("should return the capabilities: %v", err)
+	}
+	if !reflect.DeepEqual(capabilities, handler.Capabilities) {
+		t.Errorf("expected %+v got %+v", handler.Capabilities, capabilities)
+	}
+	var frigates *API
+	for i := range capabilities.APIs {
+		if capabilities.APIs[i].Resource == "frigates" {
+			frigates = &capabilities.APIs[i]
+		}
+	}
+	if frigates == nil || !reflect.DeepEqual(frigates.Versions, []string{"v1beta1", "v1"}) {
+		t.Errorf("expected frigates served as v1beta1 and v1, got %+v", frigates)
+	}
+
+	// 3. other paths are not found
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/other", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected not found got %d", recorder.Code)
+	}
+}
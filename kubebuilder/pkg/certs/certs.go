@@ -0,0 +1,450 @@
+// Package certs provisions the webhook serving certificate without
+// cert-manager.
+//
+// A Rotator keeps a self-signed CA and a serving certificate in a
+// Secret, shared by every replica of the manager, and writes the serving
+// certificate to the directory the webhook server reads it from. The CA
+// is injected as caBundle into the webhook configurations and the CRDs
+// converted by webhook. Certificates are renewed before they expire, a
+// renewed CA is served next to the previous one until that expires so
+// API servers caching the old bundle keep trusting the webhooks.
+//
+// Replicas race on the Secret with optimistic concurrency, the losers
+// pick up the winner's certificates on their next check.
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// DefaultCertDir is where the webhook server reads tls.crt and tls.key
+const DefaultCertDir = "/tmp/k8s-webhook-server/serving-certs"
+
+// Keys of the Secret
+const (
+	// CAKey is the PEM encoded CA bundle, the current CA first
+	CAKey = "ca.crt"
+	// CAPrivateKey signs the serving certificates
+	CAPrivateKey = "ca.key"
+)
+
+// Defaults of a Rotator
+const (
+	DefaultCAValidity   = 365 * 24 * time.Hour
+	DefaultCertValidity = 90 * 24 * time.Hour
+	DefaultRotateBefore = 30 * 24 * time.Hour
+	DefaultInterval     = time.Hour
+)
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;update
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;update
+
+// Rotator provisions and renews the webhook serving certificate
+type Rotator struct {
+	// Client must not read from the manager cache, certificates are
+	// provisioned before the manager starts
+	Client client.Client
+	// Secret storing the CA and the serving certificate
+	Secret types.NamespacedName
+	// DNSNames of the webhook Service, i.e. webhook-service.system.svc
+	DNSNames []string
+	// CertDir the webhook server reads the certificate from
+	CertDir string
+	// MutatingWebhooks and ValidatingWebhooks are the names of the
+	// webhook configurations receiving the caBundle
+	MutatingWebhooks   []string
+	ValidatingWebhooks []string
+	// CRDs are the names of the CustomResourceDefinitions converted by
+	// webhook receiving the caBundle
+	CRDs []string
+
+	// CAValidity, CertValidity and RotateBefore, how long before expiry
+	// certificates are renewed, use the defaults when 0
+	CAValidity   time.Duration
+	CertValidity time.Duration
+	RotateBefore time.Duration
+	// Interval between checks, defaults to DefaultInterval
+	Interval time.Duration
+
+	Log logr.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+var _ manager.Runnable = &Rotator{}
+
+// Ensure renews the certificates when needed, writes the serving
+// certificate to CertDir and injects the CA. Call it before starting
+// the manager so the webhook server finds its certificate
+func (r *Rotator) Ensure(ctx context.Context) (err error) {
+	for attempt := 0; attempt < 3; attempt++ {
+		// another replica rotated at the same time, use its certificates
+		if err = r.ensure(ctx); !errors.IsConflict(err) && !errors.IsAlreadyExists(err) {
+			return
+		}
+	}
+	return
+}
+
+func (r *Rotator) ensure(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	exists := true
+	if err := r.Client.Get(ctx, r.Secret, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		exists = false
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: r.Secret.Namespace, Name: r.Secret.Name},
+			Type:       corev1.SecretTypeTLS,
+		}
+	}
+
+	data, rotated, err := r.rotate(secret.Data)
+	if err != nil {
+		return err
+	}
+	if rotated {
+		r.Log.Info("renewed webhook certificate", "secret", r.Secret.String())
+		secret.Data = data
+		if exists {
+			err = r.Client.Update(ctx, secret)
+		} else {
+			err = r.Client.Create(ctx, secret)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = r.writeFiles(data); err != nil {
+		return err
+	}
+	return r.inject(ctx, data[CAKey])
+}
+
+// rotate returns data with the certificates renewed, rotated is false
+// when data is still valid
+func (r *Rotator) rotate(data map[string][]byte) (result map[string][]byte, rotated bool, err error) {
+	now := r.clock()
+	result = map[string][]byte{}
+	for key, value := range data {
+		result[key] = value
+	}
+
+	ca, caErr := parseCert(result[CAKey])
+	caKey, caKeyErr := parseKey(result[CAPrivateKey])
+	if caErr != nil || caKeyErr != nil || r.expiring(ca, now) {
+		bundle := []byte{}
+		// the previous CA is trusted until it expires
+		if caErr == nil && now.Before(ca.NotAfter) {
+			bundle = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+		}
+		if ca, caKey, err = newCA(now, r.validity(r.CAValidity, DefaultCAValidity)); err != nil {
+			return
+		}
+		result[CAKey] = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), bundle...)
+		result[CAPrivateKey], err = encodeKey(caKey)
+		if err != nil {
+			return
+		}
+		rotated = true
+	}
+
+	if !rotated && r.servingValid(result, ca, now) {
+		return
+	}
+	certPEM, keyPEM, err := newServingCert(ca, caKey, r.DNSNames, now, r.validity(r.CertValidity, DefaultCertValidity))
+	if err != nil {
+		return
+	}
+	result[corev1.TLSCertKey], result[corev1.TLSPrivateKeyKey] = certPEM, keyPEM
+	return result, true, nil
+}
+
+// servingValid is true when the serving certificate of data is signed
+// by ca, covers the DNS names and is not expiring
+func (r *Rotator) servingValid(data map[string][]byte, ca *x509.Certificate, now time.Time) bool {
+	pair, err := tls.X509KeyPair(data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil || cert.CheckSignatureFrom(ca) != nil || r.expiring(cert, now) {
+		return false
+	}
+	for _, name := range r.DNSNames {
+		if cert.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// writeFiles writes the serving certificate where the webhook server
+// reads it, files are replaced atomically and only when they changed
+func (r *Rotator) writeFiles(data map[string][]byte) error {
+	dir := r.CertDir
+	if dir == "" {
+		dir = DefaultCertDir
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		path := filepath.Join(dir, key)
+		if current, err := ioutil.ReadFile(path); err == nil && bytes.Equal(current, data[key]) {
+			continue
+		}
+		tmp := path + ".tmp"
+		if err := ioutil.WriteFile(tmp, data[key], 0600); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inject sets caBundle in the webhook configurations and the CRDs,
+// the ones not installed are skipped
+func (r *Rotator) inject(ctx context.Context, caBundle []byte) error {
+	for _, name := range r.MutatingWebhooks {
+		config := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle, changed = caBundle, true
+			}
+		}
+		if changed {
+			if err := r.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range r.ValidatingWebhooks {
+		config := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle, changed = caBundle, true
+			}
+		}
+		if changed {
+			if err := r.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+	encoded := base64.StdEncoding.EncodeToString(caBundle)
+	for _, name := range r.CRDs {
+		// unstructured so the scheme does not need apiextensions
+		crd := &unstructured.Unstructured{}
+		crd.SetAPIVersion("apiextensions.k8s.io/v1beta1")
+		crd.SetKind("CustomResourceDefinition")
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name}, crd); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if strategy, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "strategy"); strategy != "Webhook" {
+			continue
+		}
+		if current, _, _ := unstructured.NestedString(crd.Object, "spec", "conversion", "webhookClientConfig", "caBundle"); current == encoded {
+			continue
+		}
+		if err := unstructured.SetNestedField(crd.Object, encoded, "spec", "conversion", "webhookClientConfig", "caBundle"); err != nil {
+			return err
+		}
+		if err := r.Client.Update(ctx, crd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Start implements manager.Runnable, checking the certificates periodically
+func (r *Rotator) Start(stop <-chan struct{}) error {
+	interval := r.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := r.Ensure(context.Background()); err != nil {
+				r.Log.Error(err, "checking webhook certificate")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable,
+// every replica serves webhooks with the certificate on its disk
+func (r *Rotator) NeedLeaderElection() bool {
+	return false
+}
+
+func (r *Rotator) clock() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func (r *Rotator) validity(value, defaultValue time.Duration) time.Duration {
+	if value == 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// expiring is true when cert expires within RotateBefore
+func (r *Rotator) expiring(cert *x509.Certificate, now time.Time) bool {
+	return now.Add(r.validity(r.RotateBefore, DefaultRotateBefore)).After(cert.NotAfter)
+}
+
+// newCA returns a self-signed CA valid from now
+func newCA(now time.Time, validity time.Duration) (*x509.Certificate, crypto.Signer, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("ship-webhook-ca@%d", now.Unix())},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// newServingCert returns the PEM encoded certificate and key for
+// dnsNames signed by ca, it does not outlive ca
+func newServingCert(ca *x509.Certificate, caKey crypto.Signer, dnsNames []string, now time.Time, validity time.Duration) (certPEM, keyPEM []byte, err error) {
+	if len(dnsNames) == 0 {
+		return nil, nil, fmt.Errorf("no DNS names for the webhook certificate")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return
+	}
+	notAfter := now.Add(validity)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[0]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), caKey)
+	if err != nil {
+		return
+	}
+	if keyPEM, err = encodeKey(key); err != nil {
+		return
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// parseCert returns the first certificate of data
+func parseCert(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parseKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM EC private key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	ecKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unexpected key type %T", key)
+	}
+	der, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
@@ -0,0 +1,119 @@
+package certs
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestRotator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	webhooks := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "validating"},
+		Webhooks:   []admissionregistrationv1beta1.ValidatingWebhook{{Name: "strict.frigates.ship.danielfbm.github.io"}},
+	}
+	now := time.Now()
+	rotator := &Rotator{
+		Client:             fake.NewFakeClientWithScheme(scheme, webhooks),
+		Secret:             types.NamespacedName{Namespace: "system", Name: "webhook-server-cert"},
+		DNSNames:           []string{"webhook-service.system.svc"},
+		CertDir:            t.TempDir(),
+		ValidatingWebhooks: []string{"validating", "not-installed"},
+		Log:                logf.Log,
+		now:                func() time.Time { return now },
+	}
+	ensure := func() *corev1.Secret {
+		if err := rotator.Ensure(ctx); err != nil {
+			t.Fatalf("should ensure certificates: %v", err)
+		}
+		secret := &corev1.Secret{}
+		if err := rotator.Client.Get(ctx, rotator.Secret, secret); err != nil {
+			t.Fatalf("should get secret: %v", err)
+		}
+		return secret
+	}
+
+	// 1. bootstrap: certificates are generated, written and injected
+	secret := ensure()
+	cert := servingCert(t, secret)
+	if err := cert.VerifyHostname("webhook-service.system.svc"); err != nil {
+		t.Errorf("unexpected certificate names: %v", err)
+	}
+	written, err := ioutil.ReadFile(filepath.Join(rotator.CertDir, corev1.TLSCertKey))
+	if err != nil || !bytes.Equal(written, secret.Data[corev1.TLSCertKey]) {
+		t.Errorf("expected the certificate in the cert dir: %v", err)
+	}
+	if err = rotator.Client.Get(ctx, types.NamespacedName{Name: "validating"}, webhooks); err != nil {
+		t.Fatalf("should get webhooks: %v", err)
+	}
+	if !bytes.Equal(webhooks.Webhooks[0].ClientConfig.CABundle, secret.Data[CAKey]) {
+		t.Errorf("expected the CA to be injected")
+	}
+
+	// 2. valid certificates are kept
+	if again := ensure(); !bytes.Equal(again.Data[corev1.TLSCertKey], secret.Data[corev1.TLSCertKey]) {
+		t.Errorf("expected the secret to be unchanged")
+	}
+
+	// 3. the serving certificate is renewed before it expires, with the same CA
+	now = cert.NotAfter.Add(-DefaultRotateBefore + time.Hour)
+	renewed := ensure()
+	if servingCert(t, renewed).SerialNumber.Cmp(cert.SerialNumber) == 0 {
+		t.Errorf("expected the serving certificate to be renewed")
+	}
+	if !bytes.Equal(renewed.Data[CAKey], secret.Data[CAKey]) {
+		t.Errorf("expected the CA to be kept")
+	}
+
+	// 4. a renewed CA is served next to the previous one
+	ca := caCerts(t, renewed)[0]
+	now = ca.NotAfter.Add(-DefaultRotateBefore + time.Hour)
+	rotated := ensure()
+	bundle := caCerts(t, rotated)
+	if len(bundle) != 2 || !bundle[1].Equal(ca) || bundle[0].Equal(ca) {
+		t.Errorf("expected the new CA followed by the previous one, got %d certificates", len(bundle))
+	}
+	if err = servingCert(t, rotated).CheckSignatureFrom(bundle[0]); err != nil {
+		t.Errorf("expected the serving certificate signed by the new CA: %v", err)
+	}
+}
+
+func servingCert(t *testing.T, secret *corev1.Secret) *x509.Certificate {
+	cert, err := parseCert(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		t.Fatalf("should parse serving certificate: %v", err)
+	}
+	return cert
+}
+
+func caCerts(t *testing.T, secret *corev1.Secret) (certs []*x509.Certificate) {
+	rest := secret.Data[CAKey]
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			return
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("should parse CA bundle: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+}
@@ -0,0 +1,61 @@
+// Package cloudevents publishes Frigate lifecycle transitions as CloudEvents.
+//
+// Events are first written to an outbox kept in a ConfigMap and only
+// removed from it once a Sink accepted them, giving at-least-once delivery
+// across controller restarts. Consumers should use the event ID to
+// deduplicate.
+package cloudevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// SpecVersion of the CloudEvents specification used
+const SpecVersion = "1.0"
+
+// Event types published for Frigates
+const (
+	FrigateCreated      = "io.github.danielfbm.ship.frigate.created"
+	FrigatePhaseChanged = "io.github.danielfbm.ship.frigate.phasechanged"
+	FrigateDeleted      = "io.github.danielfbm.ship.frigate.deleted"
+)
+
+// Event is a CloudEvent in structured JSON mode
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// FrigateData is the payload of Frigate events
+type FrigateData struct {
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	UID           string `json:"uid,omitempty"`
+	Phase         string `json:"phase,omitempty"`
+	PreviousPhase string `json:"previousPhase,omitempty"`
+}
+
+// NewEvent builds an event with a new ID for a Frigate
+func NewEvent(source, eventType string, data FrigateData) (evt Event, err error) {
+	evt = Event{
+		SpecVersion:     SpecVersion,
+		ID:              string(uuid.NewUUID()),
+		Source:          source,
+		Type:            eventType,
+		Subject:         fmt.Sprintf("frigates/%s/%s", data.Namespace, data.Name),
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+	}
+	evt.Data, err = json.Marshal(data)
+	return
+}
@@ -0,0 +1,225 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// DefaultOutboxName is the ConfigMap used as outbox
+const DefaultOutboxName = "ship-cloudevents-outbox"
+
+// Publisher writes events to the outbox and delivers them to the Sink
+type Publisher struct {
+	// Client used to write the outbox ConfigMap
+	Client client.Client
+	// Reader used to read the outbox, should not be the cache
+	// to avoid watching every ConfigMap in the cluster
+	Reader client.Reader
+	Sink   Sink
+	Log    logr.Logger
+
+	// Source is the CloudEvents source attribute
+	Source string
+	// Namespace and Name of the outbox ConfigMap
+	Namespace string
+	Name      string
+	// Interval between delivery retries
+	Interval time.Duration
+	// MaxPending events kept in the outbox, older ones are dropped
+	// to keep the ConfigMap under the object size limit
+	MaxPending int
+
+	flush   chan struct{}
+	running int32
+}
+
+var _ manager.Runnable = &Publisher{}
+var _ manager.LeaderElectionRunnable = &Publisher{}
+
+func (p *Publisher) init() {
+	if p.flush == nil {
+		p.flush = make(chan struct{}, 1)
+	}
+	if p.Name == "" {
+		p.Name = DefaultOutboxName
+	}
+	if p.Interval == 0 {
+		p.Interval = 10 * time.Second
+	}
+	if p.MaxPending == 0 {
+		p.MaxPending = 1000
+	}
+}
+
+// Publish stores a Frigate event in the outbox, it will be
+// delivered asynchronously
+func (p *Publisher) Publish(ctx context.Context, eventType string, data FrigateData) (err error) {
+	p.init()
+	evt, err := NewEvent(p.Source, eventType, data)
+	if err != nil {
+		return
+	}
+	raw, err := json.Marshal(evt)
+	if err != nil {
+		return
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		outbox, err := p.getOutbox(ctx)
+		if err != nil {
+			return err
+		}
+		outbox.Data[evt.ID] = string(raw)
+		p.trim(outbox)
+		if outbox.ResourceVersion == "" {
+			return p.Client.Create(ctx, outbox)
+		}
+		return p.Client.Update(ctx, outbox)
+	})
+	if err == nil {
+		select {
+		case p.flush <- struct{}{}:
+		default:
+		}
+	}
+	return
+}
+
+// Running returns true once the publisher was started by the manager
+// which only happens on the elected leader
+func (p *Publisher) Running() bool {
+	return atomic.LoadInt32(&p.running) == 1
+}
+
+// Start implements manager.Runnable, it delivers pending events
+// whenever new ones are published and periodically retries failures
+func (p *Publisher) Start(stop <-chan struct{}) error {
+	p.init()
+	atomic.StoreInt32(&p.running, 1)
+	defer atomic.StoreInt32(&p.running, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for {
+		if err := p.deliver(ctx); err != nil {
+			p.Log.Error(err, "delivering cloudevents")
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-p.flush:
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (p *Publisher) NeedLeaderElection() bool {
+	return true
+}
+
+// deliver sends all pending events in order and removes
+// the delivered ones from the outbox
+func (p *Publisher) deliver(ctx context.Context) error {
+	outbox, err := p.getOutbox(ctx)
+	if err != nil || len(outbox.Data) == 0 {
+		return err
+	}
+
+	delivered := []string{}
+	for _, evt := range pending(outbox, p.Log) {
+		if err = p.Sink.Send(ctx, evt); err != nil {
+			// keep order, the rest is retried on the next round
+			break
+		}
+		delivered = append(delivered, evt.ID)
+	}
+	if len(delivered) > 0 {
+		if removeErr := p.remove(ctx, delivered); removeErr != nil {
+			return removeErr
+		}
+	}
+	return err
+}
+
+// remove deletes delivered events from the outbox
+func (p *Publisher) remove(ctx context.Context, ids []string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		outbox, err := p.getOutbox(ctx)
+		if err != nil || outbox.ResourceVersion == "" {
+			return err
+		}
+		for _, id := range ids {
+			delete(outbox.Data, id)
+		}
+		return p.Client.Update(ctx, outbox)
+	})
+}
+
+// getOutbox returns the outbox ConfigMap or a new one if it does not exist
+func (p *Publisher) getOutbox(ctx context.Context) (*corev1.ConfigMap, error) {
+	outbox := &corev1.ConfigMap{}
+	err := p.Reader.Get(ctx, client.ObjectKey{Namespace: p.Namespace, Name: p.Name}, outbox)
+	switch {
+	case errors.IsNotFound(err):
+		outbox = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: p.Namespace,
+				Name:      p.Name,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "ship-controller"},
+			},
+		}
+	case err != nil:
+		return nil, err
+	}
+	if outbox.Data == nil {
+		outbox.Data = map[string]string{}
+	}
+	return outbox, nil
+}
+
+// trim drops the oldest events above MaxPending
+func (p *Publisher) trim(outbox *corev1.ConfigMap) {
+	events := pending(outbox, p.Log)
+	for len(events) > p.MaxPending {
+		p.Log.Info("outbox full, dropping event", "id", events[0].ID, "type", events[0].Type, "subject", events[0].Subject)
+		delete(outbox.Data, events[0].ID)
+		events = events[1:]
+	}
+}
+
+// pending decodes events in the outbox ordered by time
+func pending(outbox *corev1.ConfigMap, log logr.Logger) []Event {
+	events := make([]Event, 0, len(outbox.Data))
+	for id, raw := range outbox.Data {
+		evt := Event{}
+		if err := json.Unmarshal([]byte(raw), &evt); err != nil {
+			log.Error(err, "dropping malformed event", "id", id)
+			delete(outbox.Data, id)
+			continue
+		}
+		events = append(events, evt)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
@@ -0,0 +1,57 @@
+package cloudevents
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestPublisherDeliversAtLeastOnce(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	c := fake.NewFakeClientWithScheme(scheme)
+	sink := &MemorySink{}
+	publisher := &Publisher{Client: c, Reader: c, Sink: sink, Log: logf.Log, Source: "test", Namespace: "default"}
+	ctx := context.TODO()
+
+	// 1. while the sink fails events stay in the outbox
+	sink.Err = fmt.Errorf("sink down")
+	for _, phase := range []string{"Pending", "Completed"} {
+		if err := publisher.Publish(ctx, FrigatePhaseChanged, FrigateData{Namespace: "default", Name: "some", Phase: phase}); err != nil {
+			t.Fatalf("should publish to outbox: %v", err)
+		}
+	}
+	if err := publisher.deliver(ctx); err == nil {
+		t.Errorf("should return the sink error")
+	}
+	outbox := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: DefaultOutboxName}, outbox); err != nil {
+		t.Fatalf("should have an outbox: %v", err)
+	}
+	if len(outbox.Data) != 2 {
+		t.Errorf("events should be kept until delivered, got %d", len(outbox.Data))
+	}
+
+	// 2. once the sink is back events are delivered in order and removed
+	sink.Err = nil
+	if err := publisher.deliver(ctx); err != nil {
+		t.Fatalf("should deliver: %v", err)
+	}
+	events := sink.Events()
+	if len(events) != 2 || events[0].Subject != "frigates/default/some" {
+		t.Fatalf("unexpected delivered events %+v", events)
+	}
+	outbox = &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: DefaultOutboxName}, outbox); err != nil {
+		t.Fatalf("should have an outbox: %v", err)
+	}
+	if len(outbox.Data) != 0 {
+		t.Errorf("delivered events should be removed, got %v", outbox.Data)
+	}
+}
@@ -0,0 +1,102 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Sink delivers events to a destination
+type Sink interface {
+	Send(ctx context.Context, evt Event) error
+}
+
+// HTTPSink posts events in structured mode to a URL
+type HTTPSink struct {
+	URL    string
+	Client *http.Client
+}
+
+var _ Sink = &HTTPSink{}
+
+// Send implements Sink
+func (s *HTTPSink) Send(ctx context.Context, evt Event) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sink %s answered %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// Producer is the minimal interface of a Kafka producer, so the
+// controller does not depend on a specific Kafka library
+type Producer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+}
+
+// KafkaSink sends events to a topic using the event subject as key,
+// keeping events of the same Frigate in order within a partition
+type KafkaSink struct {
+	Topic    string
+	Producer Producer
+}
+
+var _ Sink = &KafkaSink{}
+
+// Send implements Sink
+func (s *KafkaSink) Send(ctx context.Context, evt Event) error {
+	value, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	return s.Producer.Produce(ctx, s.Topic, []byte(evt.Subject), value)
+}
+
+// MemorySink keeps events in memory, used in tests
+type MemorySink struct {
+	lock   sync.Mutex
+	events []Event
+	// Err is returned by Send when set
+	Err error
+}
+
+var _ Sink = &MemorySink{}
+
+// Send implements Sink
+func (s *MemorySink) Send(ctx context.Context, evt Event) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.Err != nil {
+		return s.Err
+	}
+	s.events = append(s.events, evt)
+	return nil
+}
+
+// Events returns a copy of all received events
+func (s *MemorySink) Events() []Event {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Event(nil), s.events...)
+}
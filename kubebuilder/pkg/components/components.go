@@ -0,0 +1,74 @@
+// Package components parses flags enabling or disabling
+// individual controllers and webhooks of the manager.
+//
+// The syntax follows kube-controller-manager --controllers:
+// "*" enables everything that is on by default, "name" enables a
+// component and "-name" disables it, i.e. "*,-tenant" or "frigate".
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Set of enabled components
+type Set struct {
+	known   []string
+	enabled map[string]bool
+}
+
+// Parse builds a Set from a flag value. known lists all component names
+// and disabledByDefault the ones "*" does not enable
+func Parse(value string, known []string, disabledByDefault ...string) (*Set, error) {
+	set := &Set{known: append([]string(nil), known...), enabled: map[string]bool{}}
+	sort.Strings(set.known)
+	isKnown := map[string]bool{}
+	for _, name := range known {
+		isKnown[name] = true
+	}
+	offByDefault := map[string]bool{}
+	for _, name := range disabledByDefault {
+		offByDefault[name] = true
+	}
+
+	explicit := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case item == "*":
+			for _, name := range known {
+				if !offByDefault[name] && !explicit[name] {
+					set.enabled[name] = true
+				}
+			}
+			continue
+		}
+		enable := !strings.HasPrefix(item, "-")
+		name := strings.TrimPrefix(item, "-")
+		if !isKnown[name] {
+			return nil, fmt.Errorf("unknown component %q, known components: %s", name, strings.Join(set.known, ", "))
+		}
+		// explicit entries win over "*" regardless of their position
+		explicit[name] = true
+		set.enabled[name] = enable
+	}
+	return set, nil
+}
+
+// Enabled returns true when the component should run
+func (s *Set) Enabled(name string) bool {
+	return s.enabled[name]
+}
+
+// List returns the enabled components sorted by name
+func (s *Set) List() (names []string) {
+	for _, name := range s.known {
+		if s.enabled[name] {
+			names = append(names, name)
+		}
+	}
+	return
+}
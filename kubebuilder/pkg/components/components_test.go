@@ -0,0 +1,40 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	known := []string{"frigate", "frigatetombstone", "tenant", "experimental"}
+	table := []struct {
+		value    string
+		expected []string
+		err      bool
+	}{
+		{value: "*", expected: []string{"frigate", "frigatetombstone", "tenant"}},
+		{value: "*,-tenant", expected: []string{"frigate", "frigatetombstone"}},
+		{value: "-tenant,*", expected: []string{"frigate", "frigatetombstone"}},
+		{value: "frigate", expected: []string{"frigate"}},
+		{value: "*,experimental", expected: []string{"experimental", "frigate", "frigatetombstone", "tenant"}},
+		{value: "", expected: nil},
+		{value: "*,-voyage", err: true},
+	}
+
+	for _, test := range table {
+		set, err := Parse(test.value, known, "experimental")
+		if test.err {
+			if err == nil {
+				t.Errorf("%q should fail", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q should not fail: %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(set.List(), test.expected) {
+			t.Errorf("%q expected %v got %v", test.value, test.expected, set.List())
+		}
+	}
+}
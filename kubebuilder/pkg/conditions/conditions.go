@@ -0,0 +1,78 @@
+// Package conditions manages Frigate status conditions with the same
+// semantics as the k8s.io/apimachinery/pkg/api/meta condition helpers,
+// which are not available in the apimachinery version used here.
+package conditions
+
+import (
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetStatusCondition sets newCondition in conditions. LastTransitionTime
+// is only changed when the status changes, and defaults to now when unset
+func SetStatusCondition(conditions *[]shipv1beta1.Condition, newCondition shipv1beta1.Condition) {
+	if conditions == nil {
+		return
+	}
+	existing := FindStatusCondition(*conditions, newCondition.Type)
+	if existing == nil {
+		if newCondition.LastTransitionTime.IsZero() {
+			newCondition.LastTransitionTime = metav1.Now()
+		}
+		*conditions = append(*conditions, newCondition)
+		return
+	}
+
+	if existing.Status != newCondition.Status {
+		existing.Status = newCondition.Status
+		if !newCondition.LastTransitionTime.IsZero() {
+			existing.LastTransitionTime = newCondition.LastTransitionTime
+		} else {
+			existing.LastTransitionTime = metav1.Now()
+		}
+	}
+	existing.Reason = newCondition.Reason
+	existing.Message = newCondition.Message
+	existing.ObservedGeneration = newCondition.ObservedGeneration
+}
+
+// RemoveStatusCondition removes the condition of conditionType
+func RemoveStatusCondition(conditions *[]shipv1beta1.Condition, conditionType string) {
+	if conditions == nil || len(*conditions) == 0 {
+		return
+	}
+	kept := make([]shipv1beta1.Condition, 0, len(*conditions))
+	for _, condition := range *conditions {
+		if condition.Type != conditionType {
+			kept = append(kept, condition)
+		}
+	}
+	*conditions = kept
+}
+
+// FindStatusCondition returns the condition of conditionType or nil
+func FindStatusCondition(conditions []shipv1beta1.Condition, conditionType string) *shipv1beta1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsStatusConditionTrue returns true when the condition of conditionType is True
+func IsStatusConditionTrue(conditions []shipv1beta1.Condition, conditionType string) bool {
+	return IsStatusConditionPresentAndEqual(conditions, conditionType, shipv1beta1.ConditionTrue)
+}
+
+// IsStatusConditionFalse returns true when the condition of conditionType is False
+func IsStatusConditionFalse(conditions []shipv1beta1.Condition, conditionType string) bool {
+	return IsStatusConditionPresentAndEqual(conditions, conditionType, shipv1beta1.ConditionFalse)
+}
+
+// IsStatusConditionPresentAndEqual returns true when the condition
+// of conditionType has status
+func IsStatusConditionPresentAndEqual(conditions []shipv1beta1.Condition, conditionType string, status shipv1beta1.ConditionStatus) bool {
+	condition := FindStatusCondition(conditions, conditionType)
+	return condition != nil && condition.Status == status
+}
@@ -0,0 +1,47 @@
+package conditions
+
+import (
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStatusCondition(t *testing.T) {
+	var conditions []shipv1beta1.Condition
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	// 1. new conditions are added
+	SetStatusCondition(&conditions, shipv1beta1.Condition{
+		Type: shipv1beta1.ConditionReady, Status: shipv1beta1.ConditionFalse,
+		Reason: "Provisioning", LastTransitionTime: before,
+	})
+	if !IsStatusConditionFalse(conditions, shipv1beta1.ConditionReady) {
+		t.Fatalf("ready should be false, got %+v", conditions)
+	}
+
+	// 2. same status keeps the transition time but updates the reason
+	SetStatusCondition(&conditions, shipv1beta1.Condition{
+		Type: shipv1beta1.ConditionReady, Status: shipv1beta1.ConditionFalse, Reason: "Waiting",
+	})
+	ready := FindStatusCondition(conditions, shipv1beta1.ConditionReady)
+	if !ready.LastTransitionTime.Equal(&before) || ready.Reason != "Waiting" {
+		t.Errorf("transition time should be kept and reason updated, got %+v", ready)
+	}
+
+	// 3. a status change moves the transition time
+	SetStatusCondition(&conditions, shipv1beta1.Condition{
+		Type: shipv1beta1.ConditionReady, Status: shipv1beta1.ConditionTrue, Reason: "Completed",
+	})
+	ready = FindStatusCondition(conditions, shipv1beta1.ConditionReady)
+	if !IsStatusConditionTrue(conditions, shipv1beta1.ConditionReady) || !ready.LastTransitionTime.After(before.Time) {
+		t.Errorf("ready should be true with a new transition time, got %+v", ready)
+	}
+
+	// 4. conditions can be removed
+	RemoveStatusCondition(&conditions, shipv1beta1.ConditionReady)
+	if len(conditions) != 0 {
+		t.Errorf("ready should be removed, got %+v", conditions)
+	}
+}
@@ -0,0 +1,240 @@
+// Package crdwatch keeps controllers running when the CRDs they
+// depend on are deleted or not installed yet.
+//
+// A Watcher polls discovery for the required resources. Controllers of
+// a missing resource are only set up once it is installed, instead of
+// failing the manager start, and reconciles are paused while the
+// resource is removed at runtime. Both resume on their own once the
+// CRD is installed again.
+package crdwatch
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	availableGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ship_crd_available",
+		Help: "1 while the resource is served by the API server, 0 while its CRD is missing",
+	}, []string{"resource"})
+	pausedReconciles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ship_crd_paused_reconciles_total",
+		Help: "Number of reconciles skipped because the CRD of the controller is missing",
+	}, []string{"resource"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(availableGauge, pausedReconciles)
+}
+
+// DefaultInterval between discovery checks
+const DefaultInterval = 30 * time.Second
+
+// Watcher tracks the availability of resources
+type Watcher struct {
+	Discovery discovery.DiscoveryInterface
+	// Interval between discovery checks, DefaultInterval when zero
+	Interval time.Duration
+	Log      logr.Logger
+
+	lock      sync.Mutex
+	available map[schema.GroupVersionResource]bool
+	changed   chan struct{}
+}
+
+var _ manager.Runnable = &Watcher{}
+var _ manager.LeaderElectionRunnable = &Watcher{}
+
+// Require adds resource to the checked resources, it is considered
+// available until the first check says otherwise
+func (w *Watcher) Require(resource schema.GroupVersionResource) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.init()
+	if _, ok := w.available[resource]; !ok {
+		w.available[resource] = true
+		availableGauge.WithLabelValues(resource.String()).Set(1)
+	}
+}
+
+// Available returns true when resource was served on the last check
+func (w *Watcher) Available(resource schema.GroupVersionResource) bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.init()
+	available, ok := w.available[resource]
+	return !ok || available
+}
+
+func (w *Watcher) init() {
+	if w.available == nil {
+		w.available = map[schema.GroupVersionResource]bool{}
+		w.changed = make(chan struct{})
+	}
+}
+
+// Check queries discovery for every required resource
+func (w *Watcher) Check() {
+	w.lock.Lock()
+	w.init()
+	resources := make([]schema.GroupVersionResource, 0, len(w.available))
+	for resource := range w.available {
+		resources = append(resources, resource)
+	}
+	w.lock.Unlock()
+
+	// one discovery request per group version
+	served := map[schema.GroupVersion]map[string]bool{}
+	for _, resource := range resources {
+		gv := resource.GroupVersion()
+		if _, ok := served[gv]; ok {
+			continue
+		}
+		served[gv] = map[string]bool{}
+		list, err := w.Discovery.ServerResourcesForGroupVersion(gv.String())
+		if err != nil || list == nil {
+			// a missing group version is reported as not found
+			continue
+		}
+		for _, apiResource := range list.APIResources {
+			served[gv][apiResource.Name] = true
+		}
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	changed := false
+	for _, resource := range resources {
+		available := served[resource.GroupVersion()][resource.Resource]
+		if available == w.available[resource] {
+			continue
+		}
+		changed = true
+		w.available[resource] = available
+		if available {
+			availableGauge.WithLabelValues(resource.String()).Set(1)
+			w.Log.Info("CRD installed, resuming its controller", "resource", resource.String())
+		} else {
+			availableGauge.WithLabelValues(resource.String()).Set(0)
+			w.Log.Info("CRD missing, pausing its controller until it is installed again", "resource", resource.String())
+		}
+	}
+	if changed {
+		close(w.changed)
+		w.changed = make(chan struct{})
+	}
+}
+
+// Start implements manager.Runnable, checking periodically
+func (w *Watcher) Start(stop <-chan struct{}) error {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable,
+// deferred controllers of followers wait for the CRD too
+func (w *Watcher) NeedLeaderElection() bool {
+	return false
+}
+
+// wait blocks until resource is available, it returns false when stopped
+func (w *Watcher) wait(resource schema.GroupVersionResource, stop <-chan struct{}) bool {
+	for {
+		w.lock.Lock()
+		w.init()
+		available, changed := w.available[resource], w.changed
+		w.lock.Unlock()
+		if available {
+			return true
+		}
+		select {
+		case <-stop:
+			return false
+		case <-changed:
+		}
+	}
+}
+
+// Pause returns a reconciler skipping reconciles while resource is
+// missing. Objects are deleted together with their CRD so nothing is
+// requeued, a re-installed CRD starts with new events.
+// When w is nil r is returned as is
+func (w *Watcher) Pause(resource schema.GroupVersionResource, r reconcile.Reconciler) reconcile.Reconciler {
+	if w == nil {
+		return r
+	}
+	w.Require(resource)
+	return reconcile.Func(func(req reconcile.Request) (reconcile.Result, error) {
+		if !w.Available(resource) {
+			pausedReconciles.WithLabelValues(resource.String()).Inc()
+			return reconcile.Result{}, nil
+		}
+		return r.Reconcile(req)
+	})
+}
+
+// SetupWhenAvailable calls setup right away when every resource is
+// available, otherwise once they are installed. Watches of a kind
+// without CRD would fail the manager start, setup can add controllers
+// or any runnable using informers of resources
+func (w *Watcher) SetupWhenAvailable(mgr ctrl.Manager, setup func() error, resources ...schema.GroupVersionResource) error {
+	var missing []string
+	for _, resource := range resources {
+		w.Require(resource)
+		if !w.Available(resource) {
+			missing = append(missing, resource.String())
+		}
+	}
+	if len(missing) == 0 {
+		return setup()
+	}
+	w.Log.Info("CRDs missing, the controller starts once they are installed", "resources", missing)
+	return mgr.Add(&deferred{watcher: w, resources: resources, setup: setup})
+}
+
+// deferred sets up a controller once its resources are available.
+// It runs on every replica, controllers added by setup still wait
+// for leader election
+type deferred struct {
+	watcher   *Watcher
+	resources []schema.GroupVersionResource
+	setup     func() error
+}
+
+var _ manager.LeaderElectionRunnable = &deferred{}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (d *deferred) NeedLeaderElection() bool {
+	return false
+}
+
+func (d *deferred) Start(stop <-chan struct{}) error {
+	for _, resource := range d.resources {
+		if !d.watcher.wait(resource, stop) {
+			return nil
+		}
+	}
+	return d.setup()
+}
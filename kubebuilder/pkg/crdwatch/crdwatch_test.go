@@ -0,0 +1,66 @@
+package crdwatch
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestWatcher(t *testing.T) {
+	frigates := schema.GroupVersionResource{Group: "ship.danielfbm.github.io", Version: "v1beta1", Resource: "frigates"}
+	served := &metav1.APIResourceList{
+		GroupVersion: "ship.danielfbm.github.io/v1beta1",
+		APIResources: []metav1.APIResource{{Name: "frigates", Kind: "Frigate", Namespaced: true}},
+	}
+	fake := &clienttesting.Fake{}
+	watcher := &Watcher{Discovery: &fakediscovery.FakeDiscovery{Fake: fake}, Log: logf.Log}
+
+	reconciled := 0
+	paused := watcher.Pause(frigates, reconcile.Func(func(reconcile.Request) (reconcile.Result, error) {
+		reconciled++
+		return reconcile.Result{}, nil
+	}))
+
+	// 1. a missing CRD pauses reconciles
+	watcher.Check()
+	if watcher.Available(frigates) {
+		t.Fatalf("frigates should not be available")
+	}
+	paused.Reconcile(reconcile.Request{})
+	if reconciled != 0 {
+		t.Errorf("reconcile should be paused")
+	}
+
+	// 2. deferred setups wait for the CRD to be installed
+	stop := make(chan struct{})
+	defer close(stop)
+	setup := make(chan struct{})
+	go (&deferred{watcher: watcher, resources: []schema.GroupVersionResource{frigates}, setup: func() error {
+		close(setup)
+		return nil
+	}}).Start(stop)
+	select {
+	case <-setup:
+		t.Fatalf("setup should wait for the CRD")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 3. and everything resumes once it is installed
+	fake.Resources = []*metav1.APIResourceList{served}
+	watcher.Check()
+	select {
+	case <-setup:
+	case <-time.After(time.Second):
+		t.Fatalf("setup should run once the CRD is installed")
+	}
+	paused.Reconcile(reconcile.Request{})
+	if reconciled != 1 {
+		t.Errorf("reconcile should resume, got %d reconciles", reconciled)
+	}
+}
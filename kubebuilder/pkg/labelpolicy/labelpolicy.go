@@ -0,0 +1,164 @@
+// Package labelpolicy computes the labels ShipLabelPolicies require on
+// Frigates.
+//
+// The same rules are applied by a mutating webhook, so most Frigates are
+// stored with their labels already set, and by the shiplabelpolicy
+// controller which repairs labels removed later on or required by
+// policies created after the Frigate. Labels are only added, never
+// removed.
+package labelpolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// Required returns the labels the policies require on obj, a Frigate
+// converted to a map. Policies are evaluated sorted by name and the first
+// rule setting a label wins, the labels other rules wanted are returned
+// as conflicts
+func Required(policies []shipv1beta1.ShipLabelPolicy, obj map[string]interface{}) (labels map[string]string, conflicts []string) {
+	sorted := make([]*shipv1beta1.ShipLabelPolicy, len(policies))
+	for i := range policies {
+		sorted[i] = &policies[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	labels = map[string]string{}
+	for _, policy := range sorted {
+		for i, rule := range policy.Spec.Rules {
+			if !matches(rule.Match, obj) {
+				continue
+			}
+			for key, value := range rule.Labels {
+				if current, ok := labels[key]; ok {
+					if current != value {
+						conflicts = append(conflicts, fmt.Sprintf("%s rule %d: %s=%s", policy.Name, i, key, value))
+					}
+					continue
+				}
+				labels[key] = value
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return
+}
+
+// Missing returns the required labels current does not have
+// with the required value, nil when there are none
+func Missing(current, required map[string]string) map[string]string {
+	var missing map[string]string
+	for key, value := range required {
+		if v, ok := current[key]; ok && v == value {
+			continue
+		}
+		if missing == nil {
+			missing = map[string]string{}
+		}
+		missing[key] = value
+	}
+	return missing
+}
+
+// matches returns true when every field has the expected value
+func matches(match map[string]string, obj map[string]interface{}) bool {
+	for path, expected := range match {
+		if lookup(obj, path) != expected {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup formats the value at a dotted path, "" when it is missing
+func lookup(obj map[string]interface{}, path string) string {
+	var value interface{} = obj
+	for _, part := range strings.Split(path, ".") {
+		child, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		if value, ok = child[part]; !ok || value == nil {
+			return ""
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// Mutator is a mutating admission handler setting the required labels
+type Mutator struct {
+	// Path the webhook is served on
+	Path string
+	// Client used to list the policies
+	Client client.Reader
+}
+
+var _ admission.Handler = &Mutator{}
+
+// SetupWebhookWithManager registers the mutator on the manager webhook server
+func (m *Mutator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if m.Client == nil {
+		m.Client = mgr.GetClient()
+	}
+	mgr.GetWebhookServer().Register(m.Path, &webhook.Admission{Handler: m})
+	return nil
+}
+
+// Handle implements admission.Handler
+func (m *Mutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if len(req.Object.Raw) == 0 {
+		return admission.Allowed("")
+	}
+	policies := &shipv1beta1.ShipLabelPolicyList{}
+	if err := m.Client.List(ctx, policies, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(policies.Items) == 0 {
+		return admission.Allowed("")
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		obj["metadata"] = metadata
+	}
+	current := map[string]string{}
+	labels, _ := metadata["labels"].(map[string]interface{})
+	for key, value := range labels {
+		current[key], _ = value.(string)
+	}
+	required, _ := Required(policies.Items, obj)
+	missing := Missing(current, required)
+	if len(missing) == 0 {
+		return admission.Allowed("")
+	}
+	if labels == nil {
+		labels = map[string]interface{}{}
+		metadata["labels"] = labels
+	}
+	for key, value := range missing {
+		labels[key] = value
+	}
+
+	mutated, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, mutated)
+}
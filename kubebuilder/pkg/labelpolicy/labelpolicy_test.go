@@ -0,0 +1,113 @@
+package labelpolicy
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+func policy(name string, rules ...shipv1beta1.LabelRule) shipv1beta1.ShipLabelPolicy {
+	return shipv1beta1.ShipLabelPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       shipv1beta1.ShipLabelPolicySpec{Rules: rules},
+	}
+}
+
+func TestRequired(t *testing.T) {
+	policies := []shipv1beta1.ShipLabelPolicy{
+		policy("standard", shipv1beta1.LabelRule{Labels: map[string]string{"tier": "standard", "team": "ops"}}),
+		policy("premium",
+			shipv1beta1.LabelRule{Match: map[string]string{"spec.harborRef.name": "north"}, Labels: map[string]string{"tier": "premium"}},
+			shipv1beta1.LabelRule{Match: map[string]string{"spec.replicas": "3"}, Labels: map[string]string{"size": "large"}},
+			shipv1beta1.LabelRule{Match: map[string]string{"spec.harborRef.name": ""}, Labels: map[string]string{"docked": "false"}},
+		),
+	}
+	table := []struct {
+		name      string
+		obj       map[string]interface{}
+		expected  map[string]string
+		conflicts []string
+	}{
+		{
+			name:     "unmatched",
+			obj:      map[string]interface{}{"spec": map[string]interface{}{"harborRef": map[string]interface{}{"name": "south"}}},
+			expected: map[string]string{"tier": "standard", "team": "ops"},
+		},
+		{
+			name: "missing fields match empty values",
+			obj:  map[string]interface{}{"spec": map[string]interface{}{"replicas": float64(3)}},
+			expected: map[string]string{
+				"tier": "standard", "team": "ops", "size": "large", "docked": "false",
+			},
+		},
+		{
+			name:      "first policy by name wins",
+			obj:       map[string]interface{}{"spec": map[string]interface{}{"harborRef": map[string]interface{}{"name": "north"}}},
+			expected:  map[string]string{"tier": "premium", "team": "ops"},
+			conflicts: []string{"standard rule 0: tier=standard"},
+		},
+	}
+	for _, test := range table {
+		labels, conflicts := Required(policies, test.obj)
+		if !reflect.DeepEqual(labels, test.expected) || !reflect.DeepEqual(conflicts, test.conflicts) {
+			t.Errorf("%s: expected %v %v got %v %v", test.name, test.expected, test.conflicts, labels, conflicts)
+		}
+	}
+}
+
+func TestMissing(t *testing.T) {
+	missing := Missing(map[string]string{"a": "1", "b": "2"}, map[string]string{"a": "1", "b": "3", "c": "4"})
+	if !reflect.DeepEqual(missing, map[string]string{"b": "3", "c": "4"}) {
+		t.Errorf("unexpected missing labels %v", missing)
+	}
+	if missing = Missing(map[string]string{"a": "1"}, map[string]string{"a": "1"}); missing != nil {
+		t.Errorf("expected nothing missing got %v", missing)
+	}
+}
+
+func TestMutator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+	premium := policy("premium", shipv1beta1.LabelRule{
+		Match:  map[string]string{"spec.harborRef.name": "north"},
+		Labels: map[string]string{"tier": "premium"},
+	})
+	mutator := &Mutator{Client: fake.NewFakeClientWithScheme(scheme, &premium)}
+	request := func(namespace, raw string) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Namespace: namespace,
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		}}
+	}
+
+	// 1. required labels are added with a patch
+	resp := mutator.Handle(context.TODO(), request("default", `{"metadata":{"name":"a"},"spec":{"harborRef":{"name":"north"}}}`))
+	if !resp.Allowed || len(resp.Patches) != 1 || resp.Patches[0].Path != "/metadata/labels" {
+		t.Errorf("should patch the labels, got %+v", resp)
+	}
+
+	// 2. Frigates already labelled or not matching are left alone
+	for _, raw := range []string{
+		`{"metadata":{"name":"a","labels":{"tier":"premium"}},"spec":{"harborRef":{"name":"north"}}}`,
+		`{"metadata":{"name":"a"},"spec":{"harborRef":{"name":"south"}}}`,
+	} {
+		resp = mutator.Handle(context.TODO(), request("default", raw))
+		if !resp.Allowed || len(resp.Patches) != 0 {
+			t.Errorf("should allow without patches, got %+v", resp)
+		}
+	}
+
+	// 3. policies of other namespaces do not apply
+	resp = mutator.Handle(context.TODO(), request("other", `{"metadata":{"name":"a"},"spec":{"harborRef":{"name":"north"}}}`))
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("should allow without patches, got %+v", resp)
+	}
+}
@@ -0,0 +1,45 @@
+package lifecycle
+
+import (
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+)
+
+// SetConditions derives the Ready, Progressing and Degraded conditions
+// from the phase and the result of the reconcile steps. kind is used
+// in the condition messages
+func SetConditions(kind string, obj Object, stepErr error) {
+	set := func(conditionType string, status shipv1beta1.ConditionStatus, reason, message string) {
+		conditions.SetStatusCondition(obj.GetConditions(), shipv1beta1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			ObservedGeneration: obj.GetGeneration(),
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	if stepErr != nil {
+		// Ready is left as is, a failed step does not undo previous work
+		step := obj.GetCheckpoint().Failed
+		obj.SetReason(shipv1beta1.ReasonStepFailed, step+": "+stepErr.Error())
+		set(shipv1beta1.ConditionProgressing, shipv1beta1.ConditionTrue, "Retrying", "retrying step "+step)
+		set(shipv1beta1.ConditionDegraded, shipv1beta1.ConditionTrue, "StepFailed", step+": "+stepErr.Error())
+		return
+	}
+
+	switch obj.GetPhase() {
+	case Completed:
+		set(shipv1beta1.ConditionReady, shipv1beta1.ConditionTrue, "Completed", kind+" is ready")
+		set(shipv1beta1.ConditionProgressing, shipv1beta1.ConditionFalse, "Completed", "")
+		set(shipv1beta1.ConditionDegraded, shipv1beta1.ConditionFalse, "Completed", "")
+	case Failure:
+		set(shipv1beta1.ConditionReady, shipv1beta1.ConditionFalse, "Failure", kind+" failed")
+		set(shipv1beta1.ConditionProgressing, shipv1beta1.ConditionFalse, "Failure", "")
+		set(shipv1beta1.ConditionDegraded, shipv1beta1.ConditionTrue, "Failure", kind+" failed")
+	default:
+		set(shipv1beta1.ConditionReady, shipv1beta1.ConditionFalse, "Pending", "")
+		set(shipv1beta1.ConditionProgressing, shipv1beta1.ConditionTrue, "Pending", "")
+		set(shipv1beta1.ConditionDegraded, shipv1beta1.ConditionFalse, "Pending", "")
+	}
+}
@@ -0,0 +1,43 @@
+// Package lifecycle holds the phase management shared by the ship
+// controllers: named reconcile steps with a checkpoint, transition
+// guards and the Ready, Progressing and Degraded conditions.
+//
+// Kinds take part by implementing Object, the status types stay
+// owned by each kind.
+package lifecycle
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// Phases shared by every ship kind, they match the phase enums of the API
+const (
+	Pending   = "Pending"
+	Running   = "Running"
+	Completed = "Completed"
+	Failure   = "Failure"
+)
+
+// Object is a ship kind managed by this package
+type Object interface {
+	metav1.Object
+	runtime.Object
+
+	// GetSpec returns the spec, its hash identifies the step checkpoint
+	GetSpec() interface{}
+	GetPhase() string
+	SetPhase(phase string)
+	// SetReason sets the machine readable reason and the message of the phase
+	SetReason(reason, message string)
+	// GetConditions returns the status conditions to be modified in place
+	GetConditions() *[]shipv1beta1.Condition
+	GetCheckpoint() *shipv1beta1.StepCheckpoint
+	SetCheckpoint(checkpoint *shipv1beta1.StepCheckpoint)
+}
+
+var _ Object = &shipv1beta1.Frigate{}
+var _ Object = &shipv1beta1.Destroyer{}
+var _ Object = &shipv1beta1.FrigateTransfer{}
@@ -0,0 +1,85 @@
+package lifecycle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+var stepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "ship_reconcile_step_duration_seconds",
+	Help: "Duration of each named reconcile step",
+}, []string{"controller", "step"})
+
+func init() {
+	metrics.Registry.MustRegister(stepDuration)
+}
+
+// Step is a named stage of a reconcile. Steps run in order and must be
+// idempotent: after a failure the next reconcile starts again from the
+// failed step
+type Step struct {
+	Name string
+	Run  func(ctx context.Context, obj Object) error
+	// Always runs the step even when resuming after it, for steps loading
+	// inputs outside the spec that can change while a later step fails
+	Always bool
+}
+
+// RunSteps runs steps on obj and records the checkpoint in its status.
+// When the checkpoint shows a failure for the current spec the steps
+// completed before it are skipped, their result is already persisted,
+// except the Always ones
+func RunSteps(ctx context.Context, controller string, obj Object, steps []Step) (err error) {
+	start := 0
+	if checkpoint := obj.GetCheckpoint(); checkpoint != nil &&
+		checkpoint.Failed != "" && checkpoint.SpecHash == SpecHash(obj) {
+		for i := range steps {
+			if steps[i].Name == checkpoint.Failed {
+				start = i
+				break
+			}
+		}
+	}
+
+	checkpoint := &shipv1beta1.StepCheckpoint{SpecHash: SpecHash(obj)}
+	if start > 0 {
+		checkpoint.Completed = steps[start-1].Name
+	}
+	for i, s := range steps {
+		if i < start && !s.Always {
+			continue
+		}
+		begin := time.Now()
+		err = s.Run(ctx, obj)
+		stepDuration.WithLabelValues(controller, s.Name).Observe(time.Since(begin).Seconds())
+		if err != nil {
+			checkpoint.Completed = ""
+			if i > 0 {
+				checkpoint.Completed = steps[i-1].Name
+			}
+			checkpoint.Failed = s.Name
+			checkpoint.Message = err.Error()
+			break
+		}
+		if i >= start {
+			checkpoint.Completed = s.Name
+		}
+	}
+	obj.SetCheckpoint(checkpoint)
+	return
+}
+
+// SpecHash identifies the spec of obj
+func SpecHash(obj Object) string {
+	data, _ := json.Marshal(obj.GetSpec())
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
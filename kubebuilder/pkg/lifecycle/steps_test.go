@@ -0,0 +1,72 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRunSteps(t *testing.T) {
+	var ran []string
+	fail := true
+	steps := []Step{
+		{Name: "load", Always: true, Run: func(context.Context, Object) error {
+			ran = append(ran, "load")
+			return nil
+		}},
+		{Name: "first", Run: func(context.Context, Object) error {
+			ran = append(ran, "first")
+			return nil
+		}},
+		{Name: "second", Run: func(context.Context, Object) error {
+			ran = append(ran, "second")
+			if fail {
+				return fmt.Errorf("boom")
+			}
+			return nil
+		}},
+		{Name: "third", Run: func(context.Context, Object) error {
+			ran = append(ran, "third")
+			return nil
+		}},
+	}
+	frigate := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "some"}}
+	hash := SpecHash(frigate)
+
+	// 1. a failing step stops the reconcile and is recorded
+	if err := RunSteps(context.TODO(), "test", frigate, steps); err == nil {
+		t.Fatalf("should return the step error")
+	}
+	expected := &shipv1beta1.StepCheckpoint{SpecHash: hash, Completed: "first", Failed: "second", Message: "boom"}
+	if !reflect.DeepEqual(frigate.Status.Checkpoint, expected) {
+		t.Errorf("expected checkpoint %+v got %+v", expected, frigate.Status.Checkpoint)
+	}
+
+	// 2. the next reconcile resumes from the failed step
+	ran, fail = nil, false
+	if err := RunSteps(context.TODO(), "test", frigate, steps); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"load", "second", "third"}) {
+		t.Errorf("should load and resume from the failed step, ran %v", ran)
+	}
+	expected = &shipv1beta1.StepCheckpoint{SpecHash: hash, Completed: "third"}
+	if !reflect.DeepEqual(frigate.Status.Checkpoint, expected) {
+		t.Errorf("expected checkpoint %+v got %+v", expected, frigate.Status.Checkpoint)
+	}
+
+	// 3. a new spec runs every step again
+	ran = nil
+	frigate.Status.Checkpoint.Failed = "third"
+	frigate.Spec.Foo = "changed"
+	if err := RunSteps(context.TODO(), "test", frigate, steps); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(ran) != 4 {
+		t.Errorf("should run every step for a new spec, ran %v", ran)
+	}
+}
@@ -0,0 +1,124 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/conditions"
+)
+
+// HoldAnnotation vetoes every phase transition of a ship while set,
+// its value is used as the veto message
+const HoldAnnotation = "ship.danielfbm.github.io/hold-transitions"
+
+// Transition is a proposed phase change
+type Transition struct {
+	From string
+	To   string
+}
+
+// Veto explains why a transition was refused
+type Veto struct {
+	// Guard that refused the transition
+	Guard string
+	// Reason in CamelCase, used as condition reason
+	Reason  string
+	Message string
+}
+
+// Guard can refuse a phase transition, i.e. quota or maintenance
+// window checks. Guards must not modify the object
+type Guard interface {
+	// Name identifies the guard in vetoes
+	Name() string
+	// Check returns a Veto to refuse the transition, nil to allow it
+	Check(ctx context.Context, obj Object, transition Transition) (*Veto, error)
+}
+
+// GuardFunc adapts a function to a Guard
+type GuardFunc struct {
+	GuardName string
+	Func      func(ctx context.Context, obj Object, transition Transition) (*Veto, error)
+}
+
+var _ Guard = GuardFunc{}
+
+// Name implements Guard
+func (g GuardFunc) Name() string { return g.GuardName }
+
+// Check implements Guard
+func (g GuardFunc) Check(ctx context.Context, obj Object, transition Transition) (*Veto, error) {
+	return g.Func(ctx, obj, transition)
+}
+
+// HoldGuard vetoes transitions of objects annotated with HoldAnnotation
+type HoldGuard struct{}
+
+var _ Guard = HoldGuard{}
+
+// Name implements Guard
+func (HoldGuard) Name() string { return "hold" }
+
+// Check implements Guard
+func (HoldGuard) Check(ctx context.Context, obj Object, transition Transition) (*Veto, error) {
+	message, ok := obj.GetAnnotations()[HoldAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	if message == "" {
+		message = "transitions are on hold"
+	}
+	return &Veto{Reason: "OnHold", Message: message}, nil
+}
+
+// SetPhase moves obj to phase with the given reason and message
+// unless a guard vetoes the transition, the phase is then kept
+func SetPhase(ctx context.Context, guards []Guard, obj Object, phase, reason, message string) error {
+	if phase != obj.GetPhase() {
+		allowed, err := CheckTransition(ctx, guards, obj, Transition{From: obj.GetPhase(), To: phase})
+		if !allowed {
+			return err
+		}
+	}
+	obj.SetPhase(phase)
+	obj.SetReason(reason, message)
+	return nil
+}
+
+// CheckTransition runs every guard and records the vetoes in the
+// TransitionVetoed condition. It returns true when the transition is allowed
+func CheckTransition(ctx context.Context, guards []Guard, obj Object, transition Transition) (allowed bool, err error) {
+	var vetoes []*Veto
+	for _, guard := range guards {
+		var veto *Veto
+		if veto, err = guard.Check(ctx, obj, transition); err != nil {
+			return false, fmt.Errorf("transition guard %s: %v", guard.Name(), err)
+		}
+		if veto != nil {
+			veto.Guard = guard.Name()
+			vetoes = append(vetoes, veto)
+		}
+	}
+
+	if len(vetoes) == 0 {
+		conditions.SetStatusCondition(obj.GetConditions(), shipv1beta1.Condition{
+			Type:   shipv1beta1.ConditionTransitionVetoed,
+			Status: shipv1beta1.ConditionFalse,
+			Reason: "Allowed",
+		})
+		return true, nil
+	}
+	messages := make([]string, 0, len(vetoes))
+	for _, veto := range vetoes {
+		messages = append(messages, fmt.Sprintf("%s: %s", veto.Guard, veto.Message))
+	}
+	conditions.SetStatusCondition(obj.GetConditions(), shipv1beta1.Condition{
+		Type:    shipv1beta1.ConditionTransitionVetoed,
+		Status:  shipv1beta1.ConditionTrue,
+		Reason:  vetoes[0].Reason,
+		Message: fmt.Sprintf("%s to %s refused by %s", transition.From, transition.To, strings.Join(messages, "; ")),
+	})
+	return false, nil
+}
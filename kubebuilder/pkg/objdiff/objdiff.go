@@ -0,0 +1,124 @@
+// Package objdiff logs what a reconcile changed in an object or in its
+// children, field by field, at debug verbosity. Values of fields that can
+// hold credentials are redacted.
+package objdiff
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/go-logr/logr"
+)
+
+// Verbosity is the log level diffs are written at, --log-verbosity on
+// the manager must be at least this high to see them
+const Verbosity = 2
+
+// Redacted replaces the values of redacted fields
+const Redacted = "<redacted>"
+
+// RedactedFields are field names whose values are never logged, at any
+// depth: Secret and ConfigMap data, env values and anything named after
+// a credential
+var RedactedFields = map[string]bool{
+	"data":       true,
+	"stringData": true,
+	"binaryData": true,
+	"value":      true,
+	"password":   true,
+	"token":      true,
+}
+
+// Change of a single field, Before or After is nil when the
+// field was added or removed. Lists are compared as a whole
+type Change struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Diff returns the fields changed from before to after, sorted by path.
+// Both are compared through their JSON form, nil compares as empty
+func Diff(before, after interface{}) []Change {
+	changes := []Change{}
+	walk("", toJSON(before), toJSON(after), false, &changes)
+	return changes
+}
+
+// Log writes the diff of before and after with msg when the log
+// verbosity allows it. Nothing is computed or logged otherwise
+func Log(log logr.Logger, msg string, before, after interface{}, keysAndValues ...interface{}) {
+	debug := log.V(Verbosity)
+	if !debug.Enabled() {
+		return
+	}
+	changes := Diff(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	debug.Info(msg, append(keysAndValues, "diff", changes)...)
+}
+
+func toJSON(obj interface{}) interface{} {
+	if obj == nil || (reflect.ValueOf(obj).Kind() == reflect.Ptr && reflect.ValueOf(obj).IsNil()) {
+		return map[string]interface{}{}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	var value interface{}
+	if err = json.Unmarshal(data, &value); err != nil {
+		return map[string]interface{}{}
+	}
+	return value
+}
+
+func walk(path string, before, after interface{}, redact bool, changes *[]Change) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	// added and removed objects are listed field by field
+	if before == nil && afterIsMap {
+		beforeMap, beforeIsMap = map[string]interface{}{}, true
+	}
+	if after == nil && beforeIsMap {
+		afterMap, afterIsMap = map[string]interface{}{}, true
+	}
+	if beforeIsMap && afterIsMap {
+		keys := make([]string, 0, len(beforeMap)+len(afterMap))
+		for key := range beforeMap {
+			keys = append(keys, key)
+		}
+		for key := range afterMap {
+			if _, ok := beforeMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			walk(child, beforeMap[key], afterMap[key], redact || RedactedFields[key], changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(before, after) {
+		return
+	}
+	change := Change{Path: path, Before: before, After: after}
+	if redact {
+		change.Before, change.After = redacted(before), redacted(after)
+	}
+	*changes = append(*changes, change)
+}
+
+// redacted keeps telling apart added and removed fields
+func redacted(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return Redacted
+}
@@ -0,0 +1,59 @@
+package objdiff
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDiff(t *testing.T) {
+	before := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crew", Labels: map[string]string{"a": "1", "b": "2"}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "crew", Env: []corev1.EnvVar{{Name: "PASSWORD", Value: "hunter2"}},
+		}}},
+	}
+	after := before.DeepCopy()
+	after.Labels["b"] = "3"
+	delete(after.Labels, "a")
+	after.Labels["c"] = "4"
+	after.Status.Phase = corev1.PodRunning
+
+	expected := []Change{
+		{Path: "metadata.labels.a", Before: "1"},
+		{Path: "metadata.labels.b", Before: "2", After: "3"},
+		{Path: "metadata.labels.c", After: "4"},
+		{Path: "status.phase", After: "Running"},
+	}
+	if changes := Diff(before, after); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v got %+v", expected, changes)
+	}
+	if changes := Diff(after, after.DeepCopy()); len(changes) != 0 {
+		t.Errorf("equal objects should have no changes, got %+v", changes)
+	}
+}
+
+func TestDiffRedacts(t *testing.T) {
+	before := &corev1.Secret{Data: map[string][]byte{"password": []byte("hunter2")}}
+	after := &corev1.Secret{Data: map[string][]byte{"password": []byte("hunter3"), "token": []byte("abc")}}
+
+	expected := []Change{
+		{Path: "data.password", Before: Redacted, After: Redacted},
+		{Path: "data.token", After: Redacted},
+	}
+	if changes := Diff(before, after); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v got %+v", expected, changes)
+	}
+
+	// a created child is diffed against nothing
+	var missing *corev1.Secret
+	expected = []Change{
+		{Path: "data.password", After: Redacted},
+		{Path: "data.token", After: Redacted},
+	}
+	if changes := Diff(missing, after); !reflect.DeepEqual(changes, expected) {
+		t.Errorf("expected %+v got %+v", expected, changes)
+	}
+}
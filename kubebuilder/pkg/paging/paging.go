@@ -0,0 +1,123 @@
+// Package paging lists objects straight from the API server without
+// causing LIST storms when thousands of Frigates exist.
+//
+// Reads from the manager cache never reach the API server and do not need
+// this package: controllers and the summary server list from the cache, and
+// informers already list from the watch cache. It is meant for clients
+// talking to the API server directly, like the CLI or an APIReader.
+package paging
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultPageSize is used when Lister.PageSize is zero
+	DefaultPageSize = 500
+	// MaxPageSize is the largest page a Lister requests
+	MaxPageSize = 1000
+)
+
+// Policy selects the resourceVersion semantics of a list
+type Policy int
+
+const (
+	// Consistent lists the latest data from etcd one page at a time
+	// (resourceVersion unset, limit and continue)
+	Consistent Policy = iota
+	// Cached lists from the API server watch cache (resourceVersion=0).
+	// Data can be slightly stale and the watch cache ignores the limit,
+	// but etcd is never touched
+	Cached
+)
+
+// Lister lists objects using a Policy and a bounded page size
+type Lister struct {
+	Reader client.Reader
+	Policy Policy
+	// PageSize of Consistent lists, defaults to DefaultPageSize
+	// and cannot exceed MaxPageSize
+	PageSize int64
+}
+
+// List fills list with every object matching opts. Options setting
+// a limit, continue token or resourceVersion are overridden
+func (l *Lister) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if l.Policy == Cached {
+		return l.Reader.List(ctx, list, append(append([]client.ListOption{}, opts...), resourceVersion("0"))...)
+	}
+
+	pageSize, err := l.pageSize()
+	if err != nil {
+		return err
+	}
+	var items, pageItems []runtime.Object
+	var listMeta metav1.ListInterface
+	page := list.DeepCopyObject()
+	continueToken := ""
+	for {
+		pageOpts := append(append([]client.ListOption{}, opts...), client.Limit(pageSize), client.Continue(continueToken))
+		if err = l.Reader.List(ctx, page, pageOpts...); err != nil {
+			return err
+		}
+		if pageItems, err = meta.ExtractList(page); err != nil {
+			return err
+		}
+		items = append(items, pageItems...)
+
+		if listMeta, err = meta.ListAccessor(page); err != nil {
+			return err
+		}
+		if continueToken = listMeta.GetContinue(); continueToken == "" {
+			break
+		}
+	}
+
+	// keep the metadata of the last page, it has the resourceVersion
+	// of the snapshot all pages were read from
+	if err = copyListMeta(page, list); err != nil {
+		return err
+	}
+	return meta.SetList(list, items)
+}
+
+func (l *Lister) pageSize() (int64, error) {
+	switch {
+	case l.PageSize == 0:
+		return DefaultPageSize, nil
+	case l.PageSize < 0 || l.PageSize > MaxPageSize:
+		return 0, fmt.Errorf("page size %d out of range, must be between 1 and %d", l.PageSize, MaxPageSize)
+	}
+	return l.PageSize, nil
+}
+
+func copyListMeta(from, to runtime.Object) error {
+	source, err := meta.ListAccessor(from)
+	if err != nil {
+		return err
+	}
+	target, err := meta.ListAccessor(to)
+	if err != nil {
+		return err
+	}
+	target.SetResourceVersion(source.GetResourceVersion())
+	target.SetSelfLink(source.GetSelfLink())
+	target.SetContinue("")
+	return nil
+}
+
+// resourceVersion sets the resourceVersion of a list
+type resourceVersion string
+
+func (rv resourceVersion) ApplyToList(opts *client.ListOptions) {
+	if opts.Raw == nil {
+		opts.Raw = &metav1.ListOptions{}
+	}
+	opts.Raw.ResourceVersion = string(rv)
+}
@@ -0,0 +1,78 @@
+package paging
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// pagedReader serves total Frigates honoring limit and continue
+type pagedReader struct {
+	client.Reader
+	total    int
+	requests []client.ListOptions
+}
+
+func (r *pagedReader) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	options := client.ListOptions{}
+	options.ApplyOptions(opts)
+	r.requests = append(r.requests, options)
+
+	start := 0
+	if options.Continue != "" {
+		start, _ = strconv.Atoi(options.Continue)
+	}
+	end := r.total
+	if options.Limit > 0 && start+int(options.Limit) < r.total {
+		end = start + int(options.Limit)
+	}
+	frigates := list.(*shipv1beta1.FrigateList)
+	frigates.Items = nil
+	frigates.ResourceVersion = "42"
+	frigates.Continue = ""
+	if end < r.total {
+		frigates.Continue = strconv.Itoa(end)
+	}
+	for i := start; i < end; i++ {
+		frigates.Items = append(frigates.Items, shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("frigate-%d", i)}})
+	}
+	return nil
+}
+
+func TestLister(t *testing.T) {
+	// 1. consistent lists are read one page at a time
+	reader := &pagedReader{total: 25}
+	lister := &Lister{Reader: reader, PageSize: 10}
+	frigates := &shipv1beta1.FrigateList{}
+	if err := lister.List(context.TODO(), frigates, client.InNamespace("default")); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(frigates.Items) != 25 || frigates.Items[24].Name != "frigate-24" || frigates.ResourceVersion != "42" {
+		t.Errorf("should list every frigate, got %d items rv %q", len(frigates.Items), frigates.ResourceVersion)
+	}
+	if len(reader.requests) != 3 || reader.requests[0].Limit != 10 || reader.requests[2].Namespace != "default" {
+		t.Errorf("expected 3 pages of 10 got %+v", reader.requests)
+	}
+
+	// 2. cached lists are served by the watch cache in one request
+	reader = &pagedReader{total: 25}
+	lister = &Lister{Reader: reader, Policy: Cached}
+	if err := lister.List(context.TODO(), frigates); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(reader.requests) != 1 || reader.requests[0].Raw == nil || reader.requests[0].Raw.ResourceVersion != "0" {
+		t.Errorf("expected a single resourceVersion=0 request got %+v", reader.requests)
+	}
+
+	// 3. page sizes are bounded
+	lister = &Lister{Reader: reader, PageSize: MaxPageSize + 1}
+	if err := lister.List(context.TODO(), frigates); err == nil {
+		t.Errorf("page sizes above %d should be rejected", MaxPageSize)
+	}
+}
@@ -0,0 +1,145 @@
+// Package payload stores large status payloads in owned ConfigMaps
+// so custom resources only carry a small reference and a hash.
+package payload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// OwnerLabel is set on every payload ConfigMap with the owner UID
+	// and is used to find payloads during garbage collection
+	OwnerLabel = "ship.danielfbm.github.io/payload-owner"
+	// NameLabel holds the payload name given to Put
+	NameLabel = "ship.danielfbm.github.io/payload-name"
+	// DataKey is the binaryData key used to store the payload
+	DataKey = "payload"
+)
+
+// Owner is an object that can own payloads
+type Owner interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Store reads and writes payloads
+type Store struct {
+	Client client.Client
+	Scheme *runtime.Scheme
+}
+
+// ConfigMapName returns the ConfigMap name used for a payload
+func ConfigMapName(owner metav1.Object, name string) string {
+	return fmt.Sprintf("%s-%s-payload", owner.GetName(), name)
+}
+
+// Hash returns the hash stored in references for data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put stores data for owner under name and returns the reference
+// that should be kept in the owner status. The ConfigMap is only
+// written when the content changed
+func (s *Store) Put(ctx context.Context, owner Owner, name string, data []byte) (ref *shipv1beta1.PayloadReference, err error) {
+	ref = &shipv1beta1.PayloadReference{
+		ConfigMap: ConfigMapName(owner, name),
+		Key:       DataKey,
+		Hash:      Hash(data),
+		Size:      len(data),
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err = s.Client.Get(ctx, client.ObjectKey{Namespace: owner.GetNamespace(), Name: ref.ConfigMap}, configMap)
+	switch {
+	case errors.IsNotFound(err):
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ref.ConfigMap,
+				Namespace: owner.GetNamespace(),
+				Labels: map[string]string{
+					OwnerLabel: string(owner.GetUID()),
+					NameLabel:  name,
+				},
+			},
+			BinaryData: map[string][]byte{DataKey: data},
+		}
+		// payloads are garbage collected together with the owner
+		if err = controllerutil.SetControllerReference(owner, configMap, s.Scheme); err != nil {
+			return nil, err
+		}
+		err = s.Client.Create(ctx, configMap)
+	case err == nil:
+		if Hash(configMap.BinaryData[DataKey]) == ref.Hash {
+			return
+		}
+		configMap = configMap.DeepCopy()
+		configMap.BinaryData = map[string][]byte{DataKey: data}
+		err = s.Client.Update(ctx, configMap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("storing payload %q for %s/%s: %v", name, owner.GetNamespace(), owner.GetName(), err)
+	}
+	return
+}
+
+// Get returns the payload for ref and verifies its hash
+func (s *Store) Get(ctx context.Context, namespace string, ref *shipv1beta1.PayloadReference) (data []byte, err error) {
+	if ref == nil {
+		return
+	}
+	configMap := &corev1.ConfigMap{}
+	if err = s.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: ref.ConfigMap}, configMap); err != nil {
+		return
+	}
+	data = configMap.BinaryData[ref.Key]
+	if Hash(data) != ref.Hash {
+		// the ConfigMap was changed after the reference was written
+		// most probably a newer payload that is not yet in status
+		err = fmt.Errorf("payload %s/%s hash mismatch: expected %s got %s", namespace, ref.ConfigMap, ref.Hash, Hash(data))
+		data = nil
+	}
+	return
+}
+
+// Prune deletes payload ConfigMaps of owner that are not in keep.
+// ConfigMaps of deleted owners are removed by the garbage collector
+func (s *Store) Prune(ctx context.Context, owner Owner, keep ...*shipv1beta1.PayloadReference) (err error) {
+	configMaps := &corev1.ConfigMapList{}
+	if err = s.Client.List(ctx, configMaps,
+		client.InNamespace(owner.GetNamespace()),
+		client.MatchingLabels{OwnerLabel: string(owner.GetUID())},
+	); err != nil {
+		return
+	}
+
+	referenced := make(map[string]bool, len(keep))
+	for _, ref := range keep {
+		if ref != nil {
+			referenced[ref.ConfigMap] = true
+		}
+	}
+	for i := range configMaps.Items {
+		configMap := &configMaps.Items[i]
+		if referenced[configMap.Name] {
+			continue
+		}
+		if err = s.Client.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return
+		}
+		err = nil
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package payload
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	owner := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Name: "some", Namespace: "default", UID: "1234"}}
+	store := &Store{Client: fake.NewFakeClientWithScheme(scheme, owner), Scheme: scheme}
+
+	// 1. a stored payload can be read back using the reference
+	report := []byte("hull: ok\nengine: ok\n")
+	ref, err := store.Put(ctx, owner, "inspection", report)
+	if err != nil {
+		t.Fatalf("should store payload: %v", err)
+	}
+	if ref.ConfigMap != "some-inspection-payload" || ref.Hash != Hash(report) || ref.Size != len(report) {
+		t.Errorf("unexpected reference %+v", ref)
+	}
+	data, err := store.Get(ctx, owner.Namespace, ref)
+	if err != nil || string(data) != string(report) {
+		t.Errorf("should read payload back, got %q %v", data, err)
+	}
+
+	// 2. the payload ConfigMap is owned by the Frigate
+	configMap := &corev1.ConfigMap{}
+	if err = store.Client.Get(ctx, client.ObjectKey{Namespace: "default", Name: ref.ConfigMap}, configMap); err != nil {
+		t.Fatalf("should get payload configmap: %v", err)
+	}
+	if len(configMap.OwnerReferences) != 1 || configMap.OwnerReferences[0].UID != owner.UID {
+		t.Errorf("payload configmap should be owned by the frigate, got %v", configMap.OwnerReferences)
+	}
+
+	// 3. an outdated reference is detected by the hash
+	if _, err = store.Put(ctx, owner, "inspection", []byte("hull: damaged\n")); err != nil {
+		t.Fatalf("should update payload: %v", err)
+	}
+	if _, err = store.Get(ctx, owner.Namespace, ref); err == nil {
+		t.Errorf("should fail reading with an outdated reference")
+	}
+
+	// 4. payloads not referenced anymore are pruned
+	telemetry, err := store.Put(ctx, owner, "telemetry", []byte("{}"))
+	if err != nil {
+		t.Fatalf("should store telemetry: %v", err)
+	}
+	if err = store.Prune(ctx, owner, telemetry); err != nil {
+		t.Fatalf("should prune: %v", err)
+	}
+	list := &corev1.ConfigMapList{}
+	if err = store.Client.List(ctx, list, client.InNamespace("default")); err != nil {
+		t.Fatalf("should list configmaps: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != telemetry.ConfigMap {
+		t.Errorf("only the telemetry payload should be kept, got %v", list.Items)
+	}
+}
@@ -0,0 +1,225 @@
+// Package pressure detects when the API server is pushing back on the
+// manager and sheds load until the pressure subsides.
+//
+// A Detector wraps the client transport and counts 429 (Too Many Requests)
+// responses. When too many are seen within a window it enters load-shedding
+// mode: requests go through an additional, stricter rate limiter and
+// non-critical components can check Shedding to skip their work.
+package pressure
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	throttledResponses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "ship_apiserver_throttled_responses_total",
+		Help: "Number of 429 responses received from the API server",
+	})
+	sheddingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "ship_controller_load_shedding",
+		Help: "1 while the controller is shedding load because of API server pressure",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(throttledResponses, sheddingGauge)
+}
+
+// Options for a Detector
+type Options struct {
+	// Threshold of 429 responses within Window to start shedding
+	Threshold int
+	// Window used to count 429 responses
+	Window time.Duration
+	// Cooldown without 429 responses, or with Retry-After expired,
+	// before leaving shedding mode
+	Cooldown time.Duration
+	// QPS and Burst applied on top of the client limits while shedding
+	QPS   float32
+	Burst int
+}
+
+// DefaultOptions shed load after 10 throttled responses in a minute
+var DefaultOptions = Options{
+	Threshold: 10,
+	Window:    time.Minute,
+	Cooldown:  2 * time.Minute,
+	QPS:       2,
+	Burst:     5,
+}
+
+// Detector tracks API server pressure
+type Detector struct {
+	Options Options
+	Log     logr.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	lock       sync.Mutex
+	throttled  []time.Time
+	last       time.Time
+	retryAfter time.Time
+	shedding   bool
+	limiter    flowcontrol.RateLimiter
+}
+
+var _ manager.Runnable = &Detector{}
+var _ manager.LeaderElectionRunnable = &Detector{}
+
+// NewDetector builds a Detector, zero options are taken from DefaultOptions
+func NewDetector(opts Options, log logr.Logger) *Detector {
+	if opts.Threshold == 0 {
+		opts.Threshold = DefaultOptions.Threshold
+	}
+	if opts.Window == 0 {
+		opts.Window = DefaultOptions.Window
+	}
+	if opts.Cooldown == 0 {
+		opts.Cooldown = DefaultOptions.Cooldown
+	}
+	if opts.QPS == 0 {
+		opts.QPS, opts.Burst = DefaultOptions.QPS, DefaultOptions.Burst
+	}
+	return &Detector{
+		Options: opts,
+		Log:     log,
+		now:     time.Now,
+		limiter: flowcontrol.NewTokenBucketRateLimiter(opts.QPS, opts.Burst),
+	}
+}
+
+// Instrument wraps the transport of config so all responses are observed
+func (d *Detector) Instrument(config *rest.Config) {
+	previous := config.WrapTransport
+	config.WrapTransport = func(rt http.RoundTripper) http.RoundTripper {
+		if previous != nil {
+			rt = previous(rt)
+		}
+		return &roundTripper{detector: d, next: rt}
+	}
+}
+
+// Shedder reports when non-critical work should be skipped, it is
+// implemented by Detector
+type Shedder interface {
+	Shedding() bool
+}
+
+var _ Shedder = &Detector{}
+
+// Shedding returns true while the API server is under pressure.
+// Non-critical work should be skipped or postponed. A nil Detector
+// never sheds load
+func (d *Detector) Shedding() bool {
+	if d == nil {
+		return false
+	}
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.shedding
+}
+
+// Start implements manager.Runnable, it periodically checks
+// if the pressure subsided
+func (d *Detector) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(d.Options.Window / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			d.evaluate()
+		}
+	}
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable,
+// followers also talk to the API server
+func (d *Detector) NeedLeaderElection() bool {
+	return false
+}
+
+// observe records a response
+func (d *Detector) observe(resp *http.Response) {
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	throttledResponses.Inc()
+
+	d.lock.Lock()
+	now := d.now()
+	d.throttled = append(d.throttled, now)
+	d.last = now
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		if until := now.Add(time.Duration(seconds) * time.Second); until.After(d.retryAfter) {
+			d.retryAfter = until
+		}
+	}
+	d.lock.Unlock()
+
+	d.evaluate()
+}
+
+// evaluate switches shedding mode on or off
+func (d *Detector) evaluate() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	now := d.now()
+	// drop throttled responses outside the window
+	recent := d.throttled[:0]
+	for _, t := range d.throttled {
+		if now.Sub(t) <= d.Options.Window {
+			recent = append(recent, t)
+		}
+	}
+	d.throttled = recent
+
+	switch {
+	case !d.shedding && len(d.throttled) >= d.Options.Threshold:
+		d.shedding = true
+		sheddingGauge.Set(1)
+		d.Log.Info("API server under pressure, shedding load", "throttled", len(d.throttled), "window", d.Options.Window)
+	case d.shedding && d.quiet(now):
+		d.shedding = false
+		sheddingGauge.Set(0)
+		d.Log.Info("API server pressure subsided, leaving load shedding mode")
+	}
+}
+
+// quiet returns true when no throttling happened for the cooldown
+// and the server is not asking to wait anymore
+func (d *Detector) quiet(now time.Time) bool {
+	if now.Before(d.retryAfter) {
+		return false
+	}
+	return now.Sub(d.last) >= d.Options.Cooldown
+}
+
+// roundTripper throttles requests while shedding and observes responses
+type roundTripper struct {
+	detector *Detector
+	next     http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if rt.detector.Shedding() {
+		rt.detector.limiter.Accept()
+	}
+	resp, err := rt.next.RoundTrip(req)
+	rt.detector.observe(resp)
+	return resp, err
+}
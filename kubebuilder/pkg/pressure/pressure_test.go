@@ -0,0 +1,56 @@
+package pressure
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDetector(t *testing.T) {
+	now := time.Now()
+	detector := NewDetector(Options{Threshold: 3, Window: time.Minute, Cooldown: 2 * time.Minute}, logf.Log)
+	detector.now = func() time.Time { return now }
+
+	throttled := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	ok := &http.Response{StatusCode: http.StatusOK}
+
+	// 1. successful responses and a few 429s are not enough
+	detector.observe(ok)
+	detector.observe(throttled)
+	detector.observe(throttled)
+	if detector.Shedding() {
+		t.Fatalf("should not shed load below the threshold")
+	}
+
+	// 2. reaching the threshold starts shedding
+	throttled.Header.Set("Retry-After", "300")
+	detector.observe(throttled)
+	if !detector.Shedding() {
+		t.Fatalf("should shed load once the threshold is reached")
+	}
+
+	// 3. after the cooldown the server still asks to wait
+	now = now.Add(3 * time.Minute)
+	detector.evaluate()
+	if !detector.Shedding() {
+		t.Fatalf("should keep shedding while Retry-After did not expire")
+	}
+
+	// 4. once Retry-After expired shedding stops
+	now = now.Add(3 * time.Minute)
+	detector.evaluate()
+	if detector.Shedding() {
+		t.Fatalf("should stop shedding when pressure subsided")
+	}
+}
+
+func TestNilDetector(t *testing.T) {
+	// components are given a nil Detector when load shedding is disabled
+	var detector *Detector
+	var shedder Shedder = detector
+	if shedder.Shedding() {
+		t.Errorf("a nil detector should never shed load")
+	}
+}
@@ -0,0 +1,66 @@
+// Package protection denies the deletion of objects locked with an
+// annotation.
+//
+// Production Frigates are annotated with ProtectedAnnotation so a
+// mistaken `kubectl delete` or a pruning `kubectl apply` is refused.
+// Deleting them on purpose takes two steps: annotating them with
+// ForceDeleteAnnotation, then deleting them. Controllers deleting
+// Frigates, and the deletion of their namespace, are refused as well
+// until the annotation is removed or the force annotation is added.
+package protection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// ProtectedAnnotation locks an object against deletion, its value
+	// is shown in the denial, i.e. who to ask before deleting it
+	ProtectedAnnotation = "ship.danielfbm.github.io/protected"
+	// ForceDeleteAnnotation allows deleting a protected object
+	ForceDeleteAnnotation = "ship.danielfbm.github.io/force-delete"
+)
+
+// Validator is a validating admission handler for DELETE requests
+type Validator struct {
+	// Path the webhook is served on
+	Path string
+}
+
+var _ admission.Handler = &Validator{}
+
+// SetupWebhookWithManager registers the validator on the manager webhook server
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(v.Path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	// the API server sends the deleted object as the old object
+	if req.Operation != admissionv1beta1.Delete || len(req.OldObject.Raw) == 0 {
+		return admission.Allowed("")
+	}
+	obj := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(req.OldObject.Raw, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	owner, protected := obj.Annotations[ProtectedAnnotation]
+	if !protected || obj.Annotations[ForceDeleteAnnotation] == "true" {
+		return admission.Allowed("")
+	}
+	message := fmt.Sprintf("%s %s/%s is protected by the %s annotation", req.Kind.Kind, req.Namespace, req.Name, ProtectedAnnotation)
+	if owner != "" && owner != "true" {
+		message += fmt.Sprintf(" (%s)", owner)
+	}
+	return admission.Denied(fmt.Sprintf("%s, annotate it with %s=true to delete it", message, ForceDeleteAnnotation))
+}
@@ -0,0 +1,71 @@
+package protection
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func deleteRequest(old string) admission.Request {
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Delete,
+		Kind:      metav1.GroupVersionKind{Group: "ship.danielfbm.github.io", Version: "v1beta1", Kind: "Frigate"},
+		Namespace: "prod",
+		Name:      "flagship",
+		OldObject: runtime.RawExtension{Raw: []byte(old)},
+	}}
+}
+
+func TestValidator(t *testing.T) {
+	validator := &Validator{}
+	table := []struct {
+		name    string
+		old     string
+		allowed bool
+		message string
+	}{
+		{name: "not protected", old: `{"metadata":{"name":"flagship"}}`, allowed: true},
+		{
+			name:    "protected",
+			old:     `{"metadata":{"name":"flagship","annotations":{"ship.danielfbm.github.io/protected":"true"}}}`,
+			message: "Frigate prod/flagship is protected",
+		},
+		{
+			name:    "protected with owner",
+			old:     `{"metadata":{"name":"flagship","annotations":{"ship.danielfbm.github.io/protected":"ask team-blue"}}}`,
+			message: "(ask team-blue)",
+		},
+		{
+			name:    "force must be true",
+			old:     `{"metadata":{"name":"flagship","annotations":{"ship.danielfbm.github.io/protected":"","ship.danielfbm.github.io/force-delete":"yes"}}}`,
+			message: "ship.danielfbm.github.io/force-delete=true",
+		},
+		{
+			name:    "forced",
+			old:     `{"metadata":{"name":"flagship","annotations":{"ship.danielfbm.github.io/protected":"true","ship.danielfbm.github.io/force-delete":"true"}}}`,
+			allowed: true,
+		},
+	}
+	for _, test := range table {
+		resp := validator.Handle(context.TODO(), deleteRequest(test.old))
+		if resp.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed %v got %+v", test.name, test.allowed, resp.Result)
+			continue
+		}
+		if !resp.Allowed && !strings.Contains(string(resp.Result.Reason), test.message) {
+			t.Errorf("%s: expected %q in %q", test.name, test.message, resp.Result.Reason)
+		}
+	}
+
+	// only deletes are checked
+	req := deleteRequest(`{"metadata":{"name":"flagship","annotations":{"ship.danielfbm.github.io/protected":"true"}}}`)
+	req.Operation = admissionv1beta1.Update
+	if resp := validator.Handle(context.TODO(), req); !resp.Allowed {
+		t.Errorf("updates should be allowed, got %+v", resp.Result)
+	}
+}
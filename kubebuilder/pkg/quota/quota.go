@@ -0,0 +1,109 @@
+// Package quota limits how many Frigates a namespace can create.
+//
+// Limits are read from a single ConfigMap managed by the cluster admins,
+// keyed by namespace, so tenants cannot raise their own quota:
+//
+//	data:
+//	  "*": "50"      # every namespace without its own entry
+//	  team-blue: "200"
+//
+// Namespaces without an entry are unlimited when there is no "*" entry.
+// Frigates are counted from the manager cache, which indexes objects by
+// namespace, so a burst of creates racing the cache can overshoot the
+// limit by the number of Frigates created concurrently. Frigates being
+// deleted count until they are gone, like with ResourceQuotas.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// DefaultConfigMap is the namespace/name of the ConfigMap holding the limits
+const DefaultConfigMap = "controller-system/frigate-quota"
+
+// DefaultKey is the ConfigMap key of the limit of namespaces without their own
+const DefaultKey = "*"
+
+// Validator is a validating admission handler rejecting Frigate creates
+// beyond the limit of the namespace
+type Validator struct {
+	// Path the webhook is served on
+	Path string
+	// Client used to read the limits and count the Frigates, the
+	// manager client reads both from its cache
+	Client client.Reader
+	// ConfigMap holding the limits, every namespace is unlimited while
+	// it does not exist
+	ConfigMap types.NamespacedName
+}
+
+var _ admission.Handler = &Validator{}
+
+// SetupWebhookWithManager registers the validator on the manager webhook server
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if v.Client == nil {
+		v.Client = mgr.GetClient()
+	}
+	mgr.GetWebhookServer().Register(v.Path, &webhook.Admission{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation != admissionv1beta1.Create {
+		return admission.Allowed("")
+	}
+	limit, limited, err := v.limit(ctx, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !limited {
+		return admission.Allowed("")
+	}
+	frigates := &shipv1beta1.FrigateList{}
+	if err = v.Client.List(ctx, frigates, client.InNamespace(req.Namespace)); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if len(frigates.Items) >= limit {
+		return admission.Denied(fmt.Sprintf("namespace %s is limited to %d Frigates by ConfigMap %s, delete Frigates or ask to raise the limit",
+			req.Namespace, limit, v.ConfigMap))
+	}
+	return admission.Allowed("")
+}
+
+// limit returns the number of Frigates allowed in namespace, limited
+// is false for unlimited namespaces
+func (v *Validator) limit(ctx context.Context, namespace string) (limit int, limited bool, err error) {
+	configMap := &corev1.ConfigMap{}
+	if err = v.Client.Get(ctx, v.ConfigMap, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+		return
+	}
+	value, ok := configMap.Data[namespace]
+	if !ok {
+		if value, ok = configMap.Data[DefaultKey]; !ok {
+			return
+		}
+	}
+	if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+		err = fmt.Errorf("invalid limit %q for namespace %s in ConfigMap %s, expected a non negative integer", value, namespace, v.ConfigMap)
+		return
+	}
+	return limit, true, nil
+}
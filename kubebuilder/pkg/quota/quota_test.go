@@ -0,0 +1,76 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func createRequest(namespace string) admission.Request {
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Operation: admissionv1beta1.Create,
+		Namespace: namespace,
+		Name:      "new",
+	}}
+}
+
+func TestValidator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	clientgoscheme.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	objs := []runtime.Object{&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "system", Name: "frigate-quota"},
+		Data:       map[string]string{DefaultKey: "2", "blue": "3", "broken": "many"},
+	}}
+	for _, namespace := range []string{"red", "blue", "broken"} {
+		for i := 0; i < 2; i++ {
+			objs = append(objs, &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: fmt.Sprintf("frigate-%d", i)}})
+		}
+	}
+	c := fake.NewFakeClientWithScheme(scheme, objs...)
+	validator := &Validator{Client: c, ConfigMap: types.NamespacedName{Namespace: "system", Name: "frigate-quota"}}
+
+	table := []struct {
+		namespace string
+		allowed   bool
+	}{
+		// default limit reached
+		{namespace: "red"},
+		// own limit
+		{namespace: "blue", allowed: true},
+		// under the default limit
+		{namespace: "green", allowed: true},
+	}
+	for _, test := range table {
+		if resp := validator.Handle(context.TODO(), createRequest(test.namespace)); resp.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed %v got %+v", test.namespace, test.allowed, resp.Result)
+		}
+	}
+
+	// invalid limits are reported instead of guessed
+	if resp := validator.Handle(context.TODO(), createRequest("broken")); resp.Allowed || resp.Result.Code != 500 {
+		t.Errorf("expected an error for an invalid limit, got %+v", resp.Result)
+	}
+	// only creates are limited
+	req := createRequest("red")
+	req.Operation = admissionv1beta1.Update
+	if resp := validator.Handle(context.TODO(), req); !resp.Allowed {
+		t.Errorf("updates should be allowed, got %+v", resp.Result)
+	}
+	// unlimited without the ConfigMap
+	validator.ConfigMap.Name = "missing"
+	if resp := validator.Handle(context.TODO(), createRequest("red")); !resp.Allowed {
+		t.Errorf("expected no limit without ConfigMap, got %+v", resp.Result)
+	}
+}
@@ -0,0 +1,129 @@
+// Package readonly guards a client so nothing is written to the API server.
+//
+// It is meant for incident response: the manager keeps its caches, metrics
+// and summary endpoints and controllers keep making decisions, but every
+// create, update, patch and delete is logged and dropped instead of being
+// sent. Writes report success so reconcilers carry on as they would.
+package readonly
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var blockedWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ship_readonly_blocked_writes_total",
+	Help: "Number of writes dropped because the manager runs in read-only mode or an object asked for a dry run",
+}, []string{"verb", "kind"})
+
+func init() {
+	metrics.Registry.MustRegister(blockedWrites)
+}
+
+// Client reads through the wrapped client and drops every write
+type Client struct {
+	client.Client
+	Log logr.Logger
+}
+
+var _ client.Client = &Client{}
+
+// NewClientFunc returns a manager.NewClientFunc building the same cache
+// backed client as the manager default, guarded by a Client
+func NewClientFunc(log logr.Logger) manager.NewClientFunc {
+	return func(cache cache.Cache, config *rest.Config, options client.Options) (client.Client, error) {
+		c, err := client.New(config, options)
+		if err != nil {
+			return nil, err
+		}
+		return &Client{
+			Client: &client.DelegatingClient{
+				Reader: &client.DelegatingReader{
+					CacheReader:  cache,
+					ClientReader: c,
+				},
+				Writer:       c,
+				StatusClient: c,
+			},
+			Log: log,
+		}, nil
+	}
+}
+
+// Create implements client.Writer
+func (c *Client) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	c.block("create", obj, nil)
+	return nil
+}
+
+// Update implements client.Writer
+func (c *Client) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	c.block("update", obj, nil)
+	return nil
+}
+
+// Patch implements client.Writer
+func (c *Client) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.block("patch", obj, patch)
+	return nil
+}
+
+// Delete implements client.Writer
+func (c *Client) Delete(ctx context.Context, obj runtime.Object, opts ...client.DeleteOption) error {
+	c.block("delete", obj, nil)
+	return nil
+}
+
+// DeleteAllOf implements client.Writer
+func (c *Client) DeleteAllOf(ctx context.Context, obj runtime.Object, opts ...client.DeleteAllOfOption) error {
+	c.block("deletecollection", obj, nil)
+	return nil
+}
+
+// Status implements client.StatusClient
+func (c *Client) Status() client.StatusWriter {
+	return &statusWriter{client: c}
+}
+
+type statusWriter struct {
+	client *Client
+}
+
+func (w *statusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	w.client.block("update status", obj, nil)
+	return nil
+}
+
+func (w *statusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.client.block("patch status", obj, patch)
+	return nil
+}
+
+// block logs what would have been written
+func (c *Client) block(verb string, obj runtime.Object, patch client.Patch) {
+	kind := reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+	blockedWrites.WithLabelValues(verb, kind).Inc()
+
+	values := []interface{}{"verb", verb, "kind", kind}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		values = append(values, "namespace", accessor.GetNamespace(), "name", accessor.GetName())
+	}
+	if patch != nil {
+		if data, err := patch.Data(obj); err == nil {
+			values = append(values, "patch", string(data))
+		}
+	} else if verb != "delete" && verb != "deletecollection" {
+		values = append(values, "object", obj)
+	}
+	c.Log.Info("write dropped", values...)
+}
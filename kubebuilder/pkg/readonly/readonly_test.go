@@ -0,0 +1,59 @@
+package readonly
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestClient(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "existing", Namespace: "default"},
+		Data:       map[string]string{"key": "value"},
+	}
+	backend := fake.NewFakeClientWithScheme(scheme, existing)
+	c := &Client{Client: backend, Log: logf.Log}
+
+	// 1. reads go through
+	current := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, current); err != nil {
+		t.Fatalf("should read: %v", err)
+	}
+
+	// 2. writes report success but are never sent
+	created := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "created", Namespace: "default"}}
+	if err := c.Create(ctx, created); err != nil {
+		t.Errorf("create should be dropped silently: %v", err)
+	}
+	if err := backend.Get(ctx, client.ObjectKey{Namespace: "default", Name: "created"}, &corev1.ConfigMap{}); !errors.IsNotFound(err) {
+		t.Errorf("create should not reach the API server, got %v", err)
+	}
+
+	changed := current.DeepCopy()
+	changed.Data["key"] = "changed"
+	if err := c.Patch(ctx, changed, client.MergeFrom(current)); err != nil {
+		t.Errorf("patch should be dropped silently: %v", err)
+	}
+	if err := c.Status().Update(ctx, changed); err != nil {
+		t.Errorf("status update should be dropped silently: %v", err)
+	}
+	if err := c.Delete(ctx, current); err != nil {
+		t.Errorf("delete should be dropped silently: %v", err)
+	}
+
+	stored := &corev1.ConfigMap{}
+	if err := backend.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, stored); err != nil || stored.Data["key"] != "value" {
+		t.Errorf("existing object should be untouched, got %v %v", stored.Data, err)
+	}
+}
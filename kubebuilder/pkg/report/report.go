@@ -0,0 +1,112 @@
+// Package report exports the outcome of every reconcile to pluggable
+// reporters, so controller behaviour can be observed across clusters.
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Outcome of a single reconcile
+type Outcome struct {
+	Controller string `json:"controller"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Requeue and RequeueAfter are taken from the reconcile result
+	Requeue      bool          `json:"requeue,omitempty"`
+	RequeueAfter time.Duration `json:"requeueAfter,omitempty"`
+	// ErrorClass is empty on success, the API status reason for API
+	// errors and Unknown for everything else
+	ErrorClass string `json:"errorClass,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Duration of the reconcile
+	Duration time.Duration `json:"duration"`
+	Time     time.Time     `json:"time"`
+}
+
+// Reporter receives reconcile outcomes. Report is called from the
+// reconcile goroutine and must not block
+type Reporter interface {
+	Report(outcome Outcome)
+}
+
+// Reporters sends outcomes to every reporter in the list
+type Reporters []Reporter
+
+var _ Reporter = Reporters{}
+
+// Report implements Reporter
+func (r Reporters) Report(outcome Outcome) {
+	for _, reporter := range r {
+		reporter.Report(outcome)
+	}
+}
+
+// Wrap returns a reconciler reporting the outcomes of r.
+// When reporter is nil r is returned as is
+func Wrap(controller string, r reconcile.Reconciler, reporter Reporter) reconcile.Reconciler {
+	if reporter == nil {
+		return r
+	}
+	return &reporting{controller: controller, reconciler: r, reporter: reporter}
+}
+
+type reporting struct {
+	controller string
+	reconciler reconcile.Reconciler
+	reporter   Reporter
+}
+
+func (r *reporting) Reconcile(req ctrl.Request) (result ctrl.Result, err error) {
+	begin := time.Now()
+	result, err = r.reconciler.Reconcile(req)
+	outcome := Outcome{
+		Controller:   r.controller,
+		Namespace:    req.Namespace,
+		Name:         req.Name,
+		Requeue:      result.Requeue,
+		RequeueAfter: result.RequeueAfter,
+		ErrorClass:   ErrorClass(err),
+		Duration:     time.Since(begin),
+		Time:         begin,
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+	}
+	r.reporter.Report(outcome)
+	return
+}
+
+// ErrorClass classifies err to keep the cardinality of reports low
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if reason := errors.ReasonForError(err); reason != "" {
+		return string(reason)
+	}
+	return "Unknown"
+}
+
+// Parse builds the reporters named in a comma separated list
+// among log and prometheus, i.e. "log,prometheus"
+func Parse(list string, log logr.Logger) (reporters Reporters, err error) {
+	for _, name := range strings.Split(list, ",") {
+		switch name = strings.TrimSpace(name); name {
+		case "":
+		case "log":
+			reporters = append(reporters, &LogReporter{Log: log})
+		case "prometheus":
+			reporters = append(reporters, &PrometheusReporter{})
+		default:
+			return nil, fmt.Errorf("unknown reconcile reporter %q", name)
+		}
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type recorder []Outcome
+
+func (r *recorder) Report(outcome Outcome) {
+	*r = append(*r, outcome)
+}
+
+func TestWrap(t *testing.T) {
+	table := []struct {
+		err      error
+		result   ctrl.Result
+		expected string
+	}{
+		{nil, ctrl.Result{RequeueAfter: time.Minute}, ""},
+		{errors.NewConflict(schema.GroupResource{Resource: "frigates"}, "some", fmt.Errorf("changed")), ctrl.Result{}, "Conflict"},
+		{fmt.Errorf("boom"), ctrl.Result{}, "Unknown"},
+	}
+	for _, test := range table {
+		outcomes := &recorder{}
+		r := Wrap("frigate", reconcile.Func(func(ctrl.Request) (reconcile.Result, error) {
+			return test.result, test.err
+		}), outcomes)
+		result, err := r.Reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "some"}})
+		if err != test.err || result != test.result {
+			t.Errorf("reconcile result should be kept, got %v %v", result, err)
+		}
+		if len(*outcomes) != 1 {
+			t.Fatalf("expected one outcome got %v", *outcomes)
+		}
+		outcome := (*outcomes)[0]
+		if outcome.Controller != "frigate" || outcome.Name != "some" || outcome.ErrorClass != test.expected || outcome.RequeueAfter != test.result.RequeueAfter {
+			t.Errorf("unexpected outcome %+v", outcome)
+		}
+	}
+
+	if _, err := Parse("log,tracker", nil); err == nil {
+		t.Errorf("unknown reporters should be rejected")
+	}
+}
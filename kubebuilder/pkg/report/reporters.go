@@ -0,0 +1,147 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// LogReporter logs every outcome
+type LogReporter struct {
+	Log logr.Logger
+}
+
+var _ Reporter = &LogReporter{}
+
+// Report implements Reporter
+func (r *LogReporter) Report(outcome Outcome) {
+	r.Log.Info("reconciled",
+		"controller", outcome.Controller,
+		"namespace", outcome.Namespace,
+		"name", outcome.Name,
+		"requeue", outcome.Requeue || outcome.RequeueAfter > 0,
+		"errorClass", outcome.ErrorClass,
+		"duration", outcome.Duration,
+	)
+}
+
+var outcomeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "ship_reconcile_outcome_duration_seconds",
+	Help: "Duration of reconciles by controller and error class, empty on success",
+}, []string{"controller", "error_class"})
+
+func init() {
+	metrics.Registry.MustRegister(outcomeDuration)
+}
+
+// PrometheusReporter observes outcomes in a histogram by error class.
+// The vendored Prometheus client predates exemplars so the object is
+// not attached to the observation
+type PrometheusReporter struct{}
+
+var _ Reporter = &PrometheusReporter{}
+
+// Report implements Reporter
+func (r *PrometheusReporter) Report(outcome Outcome) {
+	outcomeDuration.WithLabelValues(outcome.Controller, outcome.ErrorClass).Observe(outcome.Duration.Seconds())
+}
+
+// HTTPReporter posts outcomes in batches as a JSON array to a collector.
+// Outcomes are dropped when the collector cannot keep up, reporting
+// never slows down reconciles
+type HTTPReporter struct {
+	URL    string
+	Client *http.Client
+	Log    logr.Logger
+	// Interval between batches
+	Interval time.Duration
+
+	outcomes chan Outcome
+}
+
+var _ Reporter = &HTTPReporter{}
+var _ manager.Runnable = &HTTPReporter{}
+
+// NewHTTPReporter builds a HTTPReporter buffering up to 1000 outcomes
+// and sending them every 10 seconds
+func NewHTTPReporter(url string, log logr.Logger) *HTTPReporter {
+	return &HTTPReporter{
+		URL:      url,
+		Log:      log,
+		Interval: 10 * time.Second,
+		outcomes: make(chan Outcome, 1000),
+	}
+}
+
+// Report implements Reporter
+func (r *HTTPReporter) Report(outcome Outcome) {
+	select {
+	case r.outcomes <- outcome:
+	default:
+		// the collector is slow or not started
+	}
+}
+
+// Start implements manager.Runnable
+func (r *HTTPReporter) Start(stop <-chan struct{}) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush sends the buffered outcomes
+func (r *HTTPReporter) flush() {
+	batch := make([]Outcome, 0, len(r.outcomes))
+	for len(batch) < cap(batch) {
+		batch = append(batch, <-r.outcomes)
+	}
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.send(batch); err != nil {
+		r.Log.Error(err, "reporting reconcile outcomes", "outcomes", len(batch))
+	}
+}
+
+func (r *HTTPReporter) send(batch []Outcome) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.Interval)
+	defer cancel()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("collector %s answered %s", r.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package seed applies a set of ship resources described in a YAML file
+// so demo and workshop environments start from a known world state.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KindOrder is the order in which kinds are applied. Kinds that other
+// resources depend on come first, unknown kinds are applied last
+// in the order they appear in the file
+var KindOrder = []string{
+	"Namespace", "ConfigMap", "Secret",
+	"ShipClass", "Harbor", "Armada",
+	"FrigateTemplate", "Frigate", "Fleet",
+}
+
+// ReadyFunc returns true when an applied object can be considered ready
+// and the loader can move on to the next kind
+type ReadyFunc func(obj *unstructured.Unstructured) bool
+
+// Options for a seed Loader
+type Options struct {
+	// Namespace used for namespaced objects without one
+	Namespace string
+	// Wait for each kind to be ready before applying the next
+	Wait bool
+	// Timeout when waiting for readiness of a single object, the
+	// deadline of the context passed to Load bounds the whole load
+	Timeout time.Duration
+	// Interval between readiness checks
+	Interval time.Duration
+}
+
+// Loader applies seed files using a client
+type Loader struct {
+	Client client.Client
+	// Mapper tells cluster-scoped kinds apart, they are never given
+	// Options.Namespace
+	Mapper meta.RESTMapper
+	Log    logr.Logger
+	// Ready functions by kind. Kinds without a function are
+	// considered ready as soon as they are applied
+	Ready   map[string]ReadyFunc
+	Options Options
+}
+
+// NewLoader builds a Loader with the default readiness checks
+func NewLoader(c client.Client, mapper meta.RESTMapper, log logr.Logger, opts Options) *Loader {
+	if opts.Timeout == 0 {
+		opts.Timeout = time.Minute
+	}
+	if opts.Interval == 0 {
+		opts.Interval = time.Second
+	}
+	return &Loader{
+		Client: c,
+		Mapper: mapper,
+		Log:    log,
+		Ready: map[string]ReadyFunc{
+			"Frigate": PhaseReady("Completed"),
+		},
+		Options: opts,
+	}
+}
+
+// PhaseReady returns a ReadyFunc that checks status.phase
+// against the given values
+func PhaseReady(phases ...string) ReadyFunc {
+	return func(obj *unstructured.Unstructured) bool {
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		for _, p := range phases {
+			if phase == p {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Decode reads all YAML or JSON documents in reader
+func Decode(reader io.Reader) (objs []*unstructured.Unstructured, err error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err = decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				err = nil
+				break
+			}
+			return
+		}
+		// empty documents, i.e "---" at the end of a file
+		if len(obj.Object) == 0 {
+			continue
+		}
+		objs = append(objs, obj)
+	}
+	return
+}
+
+// Sort orders objects using KindOrder keeping the file order
+// for objects of the same kind
+func Sort(objs []*unstructured.Unstructured) {
+	rank := func(kind string) int {
+		for i, k := range KindOrder {
+			if k == kind {
+				return i
+			}
+		}
+		return len(KindOrder)
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		return rank(objs[i].GetKind()) < rank(objs[j].GetKind())
+	})
+}
+
+// Load decodes, sorts and applies all objects in reader
+func (l *Loader) Load(ctx context.Context, reader io.Reader) (err error) {
+	var objs []*unstructured.Unstructured
+	if objs, err = Decode(reader); err != nil {
+		return fmt.Errorf("decoding seed file: %v", err)
+	}
+	Sort(objs)
+
+	// objects are applied kind by kind and only when all objects
+	// of a kind are ready we move to the next one
+	for start := 0; start < len(objs); {
+		end := start
+		for end < len(objs) && objs[end].GetKind() == objs[start].GetKind() {
+			end++
+		}
+		for _, obj := range objs[start:end] {
+			if err = l.apply(ctx, obj); err != nil {
+				return
+			}
+		}
+		if l.Options.Wait {
+			for _, obj := range objs[start:end] {
+				if err = l.waitReady(ctx, obj); err != nil {
+					return
+				}
+			}
+		}
+		start = end
+	}
+	return
+}
+
+// apply creates the object or updates it when it already exists
+func (l *Loader) apply(ctx context.Context, obj *unstructured.Unstructured) (err error) {
+	if obj.GetNamespace() == "" && l.Options.Namespace != "" {
+		gvk := obj.GroupVersionKind()
+		var mapping *meta.RESTMapping
+		if mapping, err = l.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			return fmt.Errorf("mapping %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			obj.SetNamespace(l.Options.Namespace)
+		}
+	}
+	log := l.Log.WithValues("kind", obj.GetKind(), "namespace", obj.GetNamespace(), "name", obj.GetName())
+
+	err = l.Client.Create(ctx, obj.DeepCopy())
+	switch {
+	case err == nil:
+		log.Info("created")
+	case errors.IsAlreadyExists(err):
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err = l.Client.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}, current); err != nil {
+			return
+		}
+		update := obj.DeepCopy()
+		update.SetResourceVersion(current.GetResourceVersion())
+		if err = l.Client.Update(ctx, update); err == nil {
+			log.Info("updated")
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("applying %s %s/%s: %v", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+	}
+	return
+}
+
+// waitReady polls the object until its ReadyFunc returns true, for at
+// most Options.Timeout or until ctx is done
+func (l *Loader) waitReady(ctx context.Context, obj *unstructured.Unstructured) error {
+	ready, ok := l.Ready[obj.GetKind()]
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, l.Options.Timeout)
+	defer cancel()
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	err := wait.PollImmediateUntil(l.Options.Interval, func() (bool, error) {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(obj.GroupVersionKind())
+		if err := l.Client.Get(ctx, key, current); err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return ready(current), nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("waiting for %s %s to be ready: %v", obj.GetKind(), key, err)
+	}
+	l.Log.Info("ready", "kind", obj.GetKind(), "namespace", key.Namespace, "name", key.Name)
+	return nil
+}
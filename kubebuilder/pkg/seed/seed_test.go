@@ -0,0 +1,160 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+const world = `
+apiVersion: ship.danielfbm.github.io/v1beta1
+kind: Frigate
+metadata:
+  name: first
+spec:
+  foo: bar
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: other
+data:
+  SPEED: "10"
+---
+{"apiVersion": "ship.danielfbm.github.io/v1beta1", "kind": "ShipClass", "metadata": {"name": "fast"}, "spec": {"image": "cargo:v1"}}
+---
+apiVersion: ship.danielfbm.github.io/v1beta1
+kind: Frigate
+metadata:
+  name: second
+spec:
+  foo: baz
+---
+`
+
+func names(objs []*unstructured.Unstructured) []string {
+	var result []string
+	for _, obj := range objs {
+		result = append(result, obj.GetKind()+"/"+obj.GetName())
+	}
+	return result
+}
+
+func TestDecode(t *testing.T) {
+	// YAML and JSON documents are decoded, empty ones are skipped
+	objs, err := Decode(strings.NewReader(world))
+	if err != nil {
+		t.Fatalf("should decode: %v", err)
+	}
+	expected := []string{"Frigate/first", "ConfigMap/settings", "ShipClass/fast", "Frigate/second"}
+	if got := names(objs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if _, err = Decode(strings.NewReader("kind: [")); err == nil {
+		t.Errorf("expected an error for malformed documents")
+	}
+}
+
+func TestSort(t *testing.T) {
+	objs := []*unstructured.Unstructured{}
+	for _, kind := range []string{"Fleet", "Unknown", "Frigate", "Harbor", "Frigate", "ShipClass", "Namespace"} {
+		obj := &unstructured.Unstructured{}
+		obj.SetKind(kind)
+		obj.SetName(strings.ToLower(kind) + "-" + string(rune('a'+len(objs))))
+		objs = append(objs, obj)
+	}
+
+	// known kinds follow KindOrder keeping the file order, unknown ones go last
+	Sort(objs)
+	expected := []string{"Namespace/namespace-g", "ShipClass/shipclass-f", "Harbor/harbor-d", "Frigate/frigate-c", "Frigate/frigate-e", "Fleet/fleet-a", "Unknown/unknown-b"}
+	if got := names(objs); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(shipv1beta1.GroupVersion.WithKind("Frigate"), meta.RESTScopeNamespace)
+	mapper.Add(shipv1beta1.GroupVersion.WithKind("ShipClass"), meta.RESTScopeRoot)
+
+	ctx := context.TODO()
+	existing := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default"},
+		Spec:       shipv1beta1.FrigateSpec{Foo: "old"},
+	}
+	c := fake.NewFakeClientWithScheme(scheme, existing)
+	loader := NewLoader(c, mapper, logf.Log, Options{Namespace: "default"})
+
+	// 1. objects are created or updated, only namespaced kinds get the
+	// default namespace
+	if err := loader.Load(ctx, strings.NewReader(world)); err != nil {
+		t.Fatalf("should load: %v", err)
+	}
+	for _, key := range []client.ObjectKey{{Namespace: "default", Name: "first"}, {Namespace: "default", Name: "second"}} {
+		frigate := &shipv1beta1.Frigate{}
+		if err := c.Get(ctx, key, frigate); err != nil {
+			t.Fatalf("should get frigate %s: %v", key, err)
+		}
+		if frigate.Spec.Foo == "old" {
+			t.Errorf("existing frigate %s should be updated", key)
+		}
+	}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "other", Name: "settings"}, &corev1.ConfigMap{}); err != nil {
+		t.Errorf("configmap should keep its namespace: %v", err)
+	}
+	class := &shipv1beta1.ShipClass{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "fast"}, class); err != nil {
+		t.Fatalf("should get cluster-scoped shipclass: %v", err)
+	}
+	if class.Namespace != "" {
+		t.Errorf("shipclass should not be namespaced, got %q", class.Namespace)
+	}
+
+	// 2. kinds the mapper does not know are rejected
+	if err := loader.Load(ctx, strings.NewReader("apiVersion: example.com/v1\nkind: Unknown\nmetadata:\n  name: some\n")); err == nil {
+		t.Errorf("expected an error for unmapped kinds")
+	}
+
+	// 3. waiting for readiness gives up after the timeout
+	loader.Options.Wait = true
+	loader.Options.Timeout = 50 * time.Millisecond
+	loader.Options.Interval = 10 * time.Millisecond
+	if err := loader.Load(ctx, strings.NewReader(world)); err == nil {
+		t.Errorf("expected frigates never Completed to time out")
+	}
+
+	// 4. and stops with the context of the load
+	loader.Options.Timeout = time.Hour
+	expired, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- loader.Load(expired, strings.NewReader(world)) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Errorf("expected the load to fail with its context")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("load should stop with its context")
+	}
+}
@@ -0,0 +1,197 @@
+// Package selftest continuously exercises the controller end to end
+// with a canary Frigate, as a health signal beyond liveness probes.
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/pressure"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	success = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "controller_selftest_success",
+		Help: "1 when the last canary round trip succeeded, 0 when it failed",
+	})
+	duration = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "controller_selftest_duration_seconds",
+		Help: "Duration of the last canary round trip",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(success, duration)
+}
+
+// DefaultName of the canary Frigate
+const DefaultName = "selftest-canary"
+
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=create
+
+// Canary maintains a canary Frigate in a dedicated namespace and
+// periodically drives it through its lifecycle:
+// create → ready → change spec → ready → delete
+type Canary struct {
+	Client client.Client
+	Log    logr.Logger
+	// Namespace dedicated to the canary, created when missing
+	Namespace string
+	// Name of the canary Frigate, defaults to DefaultName
+	Name string
+	// Interval between round trips
+	Interval time.Duration
+	// Timeout of every stage of a round trip
+	Timeout time.Duration
+	// Pressure skips round trips while the API server is under
+	// pressure, optional
+	Pressure pressure.Shedder
+}
+
+var _ manager.Runnable = &Canary{}
+
+// Start implements manager.Runnable. The canary only runs on the leader
+// as it waits for the controllers to act
+func (c *Canary) Start(stop <-chan struct{}) error {
+	if c.Name == "" {
+		c.Name = DefaultName
+	}
+	if c.Timeout == 0 {
+		c.Timeout = time.Minute
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	wait.Until(func() { c.run(ctx) }, c.Interval, stop)
+	return nil
+}
+
+// run does a round trip and exports its outcome, it is skipped while
+// shedding load so the metrics keep the last outcome
+func (c *Canary) run(ctx context.Context) {
+	if c.Pressure != nil && c.Pressure.Shedding() {
+		c.Log.V(1).Info("shedding load, skipping the canary round trip")
+		return
+	}
+	begin := time.Now()
+	err := c.roundTrip(ctx)
+	duration.Set(time.Since(begin).Seconds())
+	if err != nil {
+		success.Set(0)
+		c.Log.Error(err, "canary round trip failed", "namespace", c.Namespace, "name", c.Name)
+		return
+	}
+	success.Set(1)
+	c.Log.V(1).Info("canary round trip succeeded", "duration", time.Since(begin))
+}
+
+// roundTrip runs the whole lifecycle once
+func (c *Canary) roundTrip(ctx context.Context) (err error) {
+	if err = c.ensureNamespace(ctx); err != nil {
+		return
+	}
+	key := client.ObjectKey{Namespace: c.Namespace, Name: c.Name}
+	// a previous round trip could have been interrupted
+	if err = c.delete(ctx, key); err != nil {
+		return fmt.Errorf("removing leftover canary: %v", err)
+	}
+
+	canary := &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: c.Namespace, Name: c.Name},
+		Spec:       shipv1beta1.FrigateSpec{Foo: "canary"},
+	}
+	if err = c.Client.Create(ctx, canary); err != nil {
+		return fmt.Errorf("creating canary: %v", err)
+	}
+	// a failed canary is not left behind for the next round trip
+	defer func() {
+		if err == nil {
+			return
+		}
+		if cleanupErr := c.delete(ctx, key); cleanupErr != nil {
+			c.Log.Error(cleanupErr, "removing failed canary", "namespace", c.Namespace, "name", c.Name)
+		}
+	}()
+	var ready string
+	if ready, err = c.waitReady(ctx, key, ""); err != nil {
+		return fmt.Errorf("waiting canary to be ready: %v", err)
+	}
+
+	if err = c.Client.Get(ctx, key, canary); err != nil {
+		return
+	}
+	canary.Spec.Foo = fmt.Sprintf("canary-%d", time.Now().Unix())
+	if err = c.Client.Update(ctx, canary); err != nil {
+		return fmt.Errorf("changing canary: %v", err)
+	}
+	if _, err = c.waitReady(ctx, key, ready); err != nil {
+		return fmt.Errorf("waiting changed canary to be ready: %v", err)
+	}
+
+	if err = c.delete(ctx, key); err != nil {
+		return fmt.Errorf("deleting canary: %v", err)
+	}
+	return
+}
+
+func (c *Canary) ensureNamespace(ctx context.Context) (err error) {
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: c.Namespace}}
+	if err = c.Client.Create(ctx, namespace); errors.IsAlreadyExists(err) {
+		err = nil
+	}
+	return
+}
+
+// waitReady waits until the canary is Completed and the reconciled spec
+// differs from previous, returning the reconciled spec
+func (c *Canary) waitReady(ctx context.Context, key client.ObjectKey, previous string) (reconciled string, err error) {
+	err = c.poll(ctx, func() (bool, error) {
+		canary := &shipv1beta1.Frigate{}
+		if err := c.Client.Get(ctx, key, canary); err != nil {
+			return false, client.IgnoreNotFound(err)
+		}
+		checkpoint := canary.Status.Checkpoint
+		if canary.Status.Phase != shipv1beta1.FrigateCompleted || checkpoint == nil || checkpoint.Failed != "" || checkpoint.SpecHash == previous {
+			return false, nil
+		}
+		reconciled = checkpoint.SpecHash
+		return true, nil
+	})
+	return
+}
+
+// delete removes the canary and waits until it is gone
+func (c *Canary) delete(ctx context.Context, key client.ObjectKey) error {
+	canary := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}}
+	if err := c.Client.Delete(ctx, canary); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return c.poll(ctx, func() (bool, error) {
+		err := c.Client.Get(ctx, key, &shipv1beta1.Frigate{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+func (c *Canary) poll(ctx context.Context, condition wait.ConditionFunc) error {
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+	return wait.PollImmediateUntil(time.Second, condition, ctx.Done())
+}
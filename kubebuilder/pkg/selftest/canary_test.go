@@ -0,0 +1,146 @@
+package selftest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+)
+
+// shedding is a pressure.Shedder with a fixed answer
+type shedding bool
+
+func (s shedding) Shedding() bool {
+	return bool(s)
+}
+
+// reconciling stands in for the Frigate controller, the Frigates it
+// writes are Completed for their spec unless broken
+type reconciling struct {
+	client.Client
+	broken bool
+	// specs seen, in order
+	specs []string
+}
+
+func (c *reconciling) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	c.reconcile(obj)
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *reconciling) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	c.reconcile(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *reconciling) reconcile(obj runtime.Object) {
+	frigate, ok := obj.(*shipv1beta1.Frigate)
+	if !ok {
+		return
+	}
+	c.specs = append(c.specs, frigate.Spec.Foo)
+	if c.broken {
+		frigate.Status.Phase = shipv1beta1.FrigatePending
+		return
+	}
+	frigate.Status.Phase = shipv1beta1.FrigateCompleted
+	frigate.Status.Checkpoint = &shipv1beta1.StepCheckpoint{SpecHash: frigate.Spec.Foo}
+}
+
+func newCanary(c client.Client) *Canary {
+	return &Canary{
+		Client:    c,
+		Log:       logf.Log,
+		Namespace: "selftest",
+		Name:      DefaultName,
+		Timeout:   100 * time.Millisecond,
+	}
+}
+
+func TestCanaryRoundTrip(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	leftover := &shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Namespace: "selftest", Name: DefaultName}}
+	controller := &reconciling{Client: fake.NewFakeClientWithScheme(scheme, leftover)}
+	canary := newCanary(controller)
+
+	// the leftover of an interrupted round trip is replaced, the canary is
+	// created, becomes ready, is changed, becomes ready again and is deleted
+	canary.run(ctx)
+	if testutil.ToFloat64(success) != 1 {
+		t.Errorf("expected controller_selftest_success 1")
+	}
+	if len(controller.specs) != 2 || controller.specs[0] != "canary" || !strings.HasPrefix(controller.specs[1], "canary-") {
+		t.Errorf("expected the canary to be created then changed, got %v", controller.specs)
+	}
+	if err := controller.Get(ctx, client.ObjectKey{Name: "selftest"}, &corev1.Namespace{}); err != nil {
+		t.Errorf("namespace should be created: %v", err)
+	}
+	if err := controller.Get(ctx, client.ObjectKey{Namespace: "selftest", Name: DefaultName}, &shipv1beta1.Frigate{}); !errors.IsNotFound(err) {
+		t.Errorf("canary should be deleted, got %v", err)
+	}
+}
+
+func TestCanaryFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	controller := &reconciling{Client: fake.NewFakeClientWithScheme(scheme), broken: true}
+	canary := newCanary(controller)
+
+	// a canary never ready fails the round trip and is removed
+	canary.run(ctx)
+	if testutil.ToFloat64(success) != 0 {
+		t.Errorf("expected controller_selftest_success 0")
+	}
+	if err := controller.Get(ctx, client.ObjectKey{Namespace: "selftest", Name: DefaultName}, &shipv1beta1.Frigate{}); !errors.IsNotFound(err) {
+		t.Errorf("failed canary should be removed, got %v", err)
+	}
+
+	// the next successful round trip sets the metric back
+	controller.broken = false
+	canary.run(ctx)
+	if testutil.ToFloat64(success) != 1 {
+		t.Errorf("expected controller_selftest_success 1")
+	}
+}
+
+func TestCanarySkippedWhileShedding(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	c := fake.NewFakeClientWithScheme(scheme)
+	canary := newCanary(c)
+	canary.Pressure = shedding(true)
+
+	// nothing is created, not even the namespace
+	canary.run(context.TODO())
+	namespaces := &corev1.NamespaceList{}
+	if err := c.List(context.TODO(), namespaces); err != nil {
+		t.Fatalf("should list namespaces: %v", err)
+	}
+	frigates := &shipv1beta1.FrigateList{}
+	if err := c.List(context.TODO(), frigates); err != nil {
+		t.Fatalf("should list frigates: %v", err)
+	}
+	if len(namespaces.Items) != 0 || len(frigates.Items) != 0 {
+		t.Errorf("canary should not run while shedding, got %d namespaces and %d frigates", len(namespaces.Items), len(frigates.Items))
+	}
+}
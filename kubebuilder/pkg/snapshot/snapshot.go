@@ -0,0 +1,124 @@
+// Package snapshot helps controllers take decisions based on several
+// objects without acting on a torn read.
+//
+// Reading a Frigate and then, a few milliseconds later, the objects it
+// depends on gives a view of the cluster that never existed at a single
+// point in time. Decide records the resourceVersion of every object read,
+// verifies none of them changed right before writing, and writes with the
+// resourceVersion of the read as precondition. When any input changed the
+// whole decision is taken again from fresh reads.
+package snapshot
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrChanged is returned when an input changed while deciding
+// and all retries were exhausted
+var ErrChanged = fmt.Errorf("snapshot inputs changed while deciding")
+
+// ReadSet records objects read for a decision
+type ReadSet struct {
+	reader client.Reader
+	reads  map[string]read
+}
+
+// read is one recorded input
+type read struct {
+	key     client.ObjectKey
+	obj     runtime.Object
+	version string
+}
+
+// Get reads an object and records its resourceVersion.
+// A missing object is recorded as well, if it shows
+// up before writing the decision is retried
+func (s *ReadSet) Get(ctx context.Context, key client.ObjectKey, obj runtime.Object) error {
+	err := s.reader.Get(ctx, key, obj)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	version, versionErr := resourceVersion(obj, err)
+	if versionErr != nil {
+		return versionErr
+	}
+	s.reads[fmt.Sprintf("%T/%s", obj, key)] = read{key: key, obj: obj.DeepCopyObject(), version: version}
+	return err
+}
+
+// verify checks all recorded objects still have the same resourceVersion
+func (s *ReadSet) verify(ctx context.Context) (bool, error) {
+	for _, r := range s.reads {
+		current := r.obj.DeepCopyObject()
+		err := s.reader.Get(ctx, r.key, current)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, err
+		}
+		version, err := resourceVersion(current, err)
+		if err != nil {
+			return false, err
+		}
+		if version != r.version {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// resourceVersion of obj, empty when the object was not found
+func resourceVersion(obj runtime.Object, getErr error) (string, error) {
+	if errors.IsNotFound(getErr) {
+		return "", nil
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return accessor.GetResourceVersion(), nil
+}
+
+// DecideFunc reads its inputs using the ReadSet and returns
+// the objects to update. Updated objects must be the ones read,
+// so their resourceVersion is used as precondition
+type DecideFunc func(ctx context.Context, reads *ReadSet) (updates []runtime.Object, err error)
+
+// Decide runs decide until it is taken on a consistent set of reads.
+// reader should read from the API server, not from the cache, otherwise
+// the verification step only detects changes the cache already observed
+func Decide(ctx context.Context, reader client.Reader, writer client.Writer, decide DecideFunc) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		reads := &ReadSet{reader: reader, reads: map[string]read{}}
+		updates, err := decide(ctx, reads)
+		if err != nil {
+			return err
+		}
+		consistent, err := reads.verify(ctx)
+		if err != nil {
+			return err
+		}
+		if !consistent {
+			// a conflict makes RetryOnConflict take the decision again
+			return errors.NewConflict(schema.GroupResource{Resource: "snapshot"}, "", ErrChanged)
+		}
+		for _, obj := range updates {
+			// the resourceVersion from the read is kept
+			// so the API server rejects writes on stale objects
+			if err = writer.Update(ctx, obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.IsConflict(err) {
+		return fmt.Errorf("%v: %v", ErrChanged, err)
+	}
+	return err
+}
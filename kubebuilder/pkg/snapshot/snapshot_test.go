@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDecideRetriesOnTornRead(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	shipv1beta1.AddToScheme(scheme)
+
+	ctx := context.TODO()
+	frigateKey := client.ObjectKey{Namespace: "default", Name: "some"}
+	configKey := client.ObjectKey{Namespace: "default", Name: "orders"}
+	c := fake.NewFakeClientWithScheme(scheme,
+		&shipv1beta1.Frigate{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "some"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "orders"}, Data: map[string]string{"foo": "old"}},
+	)
+
+	attempts := 0
+	err := Decide(ctx, c, c, func(ctx context.Context, reads *ReadSet) ([]runtime.Object, error) {
+		attempts++
+		frigate := &shipv1beta1.Frigate{}
+		if err := reads.Get(ctx, frigateKey, frigate); err != nil {
+			return nil, err
+		}
+		orders := &corev1.ConfigMap{}
+		if err := reads.Get(ctx, configKey, orders); err != nil {
+			return nil, err
+		}
+
+		// someone changes the orders while we are deciding
+		// only on the first attempt
+		if attempts == 1 {
+			changed := orders.DeepCopy()
+			changed.Data["foo"] = "new"
+			if err := c.Update(ctx, changed); err != nil {
+				return nil, err
+			}
+		}
+
+		frigate.Spec.Foo = orders.Data["foo"]
+		return []runtime.Object{frigate}, nil
+	})
+	if err != nil {
+		t.Fatalf("should decide: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("should take the decision again after a torn read, attempts: %d", attempts)
+	}
+
+	frigate := &shipv1beta1.Frigate{}
+	if err = c.Get(ctx, frigateKey, frigate); err != nil {
+		t.Fatalf("should get frigate: %v", err)
+	}
+	if frigate.Spec.Foo != "new" {
+		t.Errorf("decision should be based on the latest orders, got %q", frigate.Spec.Foo)
+	}
+}
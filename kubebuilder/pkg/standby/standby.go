@@ -0,0 +1,76 @@
+// Package standby keeps non-leader replicas ready to take over.
+//
+// The manager only starts controllers, and with them their informers, on the
+// elected leader. A replica that wins the election later has to list and
+// sync every watched kind before reconciling anything. The runnables here
+// are split in two groups: WarmCache runs on every replica, while Leader
+// only runs once this replica is elected.
+package standby
+
+import (
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// WarmCache starts informers for Objects as soon as the manager starts,
+// regardless of leader election
+type WarmCache struct {
+	Cache   cache.Cache
+	Objects []runtime.Object
+	Log     logr.Logger
+}
+
+var _ manager.Runnable = &WarmCache{}
+var _ manager.LeaderElectionRunnable = &WarmCache{}
+
+// Start implements manager.Runnable
+func (w *WarmCache) Start(stop <-chan struct{}) error {
+	for _, obj := range w.Objects {
+		// requesting the informer is enough, the cache
+		// starts it and keeps it in sync from now on
+		if _, err := w.Cache.GetInformer(obj); err != nil {
+			return err
+		}
+	}
+	if w.Cache.WaitForCacheSync(stop) {
+		w.Log.Info("caches warm", "kinds", len(w.Objects))
+	}
+	<-stop
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (w *WarmCache) NeedLeaderElection() bool {
+	return false
+}
+
+// Leader records whether this replica is the elected leader.
+// The manager only starts it after winning the election
+type Leader struct {
+	elected int32
+}
+
+var _ manager.Runnable = &Leader{}
+var _ manager.LeaderElectionRunnable = &Leader{}
+
+// Start implements manager.Runnable
+func (l *Leader) Start(stop <-chan struct{}) error {
+	atomic.StoreInt32(&l.elected, 1)
+	<-stop
+	atomic.StoreInt32(&l.elected, 0)
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (l *Leader) NeedLeaderElection() bool {
+	return true
+}
+
+// IsLeader returns true while this replica is the leader
+func (l *Leader) IsLeader() bool {
+	return atomic.LoadInt32(&l.elected) == 1
+}
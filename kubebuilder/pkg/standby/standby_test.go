@@ -0,0 +1,39 @@
+package standby
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestLeader(t *testing.T) {
+	leader := &Leader{}
+	if !leader.NeedLeaderElection() {
+		t.Errorf("leader should only start once elected")
+	}
+
+	// 1. not the leader before the manager starts it
+	if leader.IsLeader() {
+		t.Fatalf("should not be the leader before the election")
+	}
+
+	// 2. the leader once started
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() { done <- leader.Start(stop) }()
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return leader.IsLeader(), nil
+	}); err != nil {
+		t.Fatalf("should be the leader once started: %v", err)
+	}
+
+	// 3. not anymore once stopped
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("should stop: %v", err)
+	}
+	if leader.IsLeader() {
+		t.Errorf("should not be the leader once stopped")
+	}
+}
@@ -0,0 +1,133 @@
+// Package stream serves Frigate phase changes as server-sent events
+// translated from the manager informers, so front-ends can display
+// live updates without polling the API server.
+package stream
+
+import (
+	"sync"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/go-logr/logr"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Event types sent to subscribers
+const (
+	Added    = "added"
+	Modified = "modified"
+	Deleted  = "deleted"
+)
+
+// Event is a change of a Frigate as sent to subscribers
+type Event struct {
+	Type          string `json:"type"`
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	Phase         string `json:"phase,omitempty"`
+	PreviousPhase string `json:"previousPhase,omitempty"`
+}
+
+// Broker fans out informer events to subscribers
+type Broker struct {
+	// Cache provides the Frigate informer, usually the manager cache
+	Cache cache.Informers
+	Log   logr.Logger
+	// Buffer of each subscriber, slow subscribers lose events
+	Buffer int
+
+	lock        sync.Mutex
+	subscribers map[chan Event]string
+}
+
+var _ manager.Runnable = &Broker{}
+var _ manager.LeaderElectionRunnable = &Broker{}
+
+// Subscribe returns a channel receiving events of namespace,
+// all namespaces when empty. cancel must be called when done
+func (b *Broker) Subscribe(namespace string) (events <-chan Event, cancel func()) {
+	size := b.Buffer
+	if size == 0 {
+		size = 100
+	}
+	ch := make(chan Event, size)
+
+	b.lock.Lock()
+	if b.subscribers == nil {
+		b.subscribers = map[chan Event]string{}
+	}
+	b.subscribers[ch] = namespace
+	b.lock.Unlock()
+
+	return ch, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Start implements manager.Runnable
+func (b *Broker) Start(stop <-chan struct{}) error {
+	informer, err := b.Cache.GetInformer(&shipv1beta1.Frigate{})
+	if err != nil {
+		return err
+	}
+	informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if frigate, ok := obj.(*shipv1beta1.Frigate); ok {
+				b.publish(Event{Type: Added, Namespace: frigate.Namespace, Name: frigate.Name, Phase: string(frigate.Status.Phase)})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			old, ok := oldObj.(*shipv1beta1.Frigate)
+			frigate, ok2 := newObj.(*shipv1beta1.Frigate)
+			// resyncs and spec only changes are not interesting for viewers
+			if !ok || !ok2 || old.Status.Phase == frigate.Status.Phase {
+				return
+			}
+			b.publish(Event{Type: Modified, Namespace: frigate.Namespace, Name: frigate.Name, Phase: string(frigate.Status.Phase), PreviousPhase: string(old.Status.Phase)})
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if frigate, ok := obj.(*shipv1beta1.Frigate); ok {
+				b.publish(Event{Type: Deleted, Namespace: frigate.Namespace, Name: frigate.Name, Phase: string(frigate.Status.Phase)})
+			}
+		},
+	})
+	<-stop
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for ch := range b.subscribers {
+		close(ch)
+	}
+	b.subscribers = nil
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable,
+// every replica can stream from its own cache
+func (b *Broker) NeedLeaderElection() bool {
+	return false
+}
+
+func (b *Broker) publish(evt Event) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for ch, namespace := range b.subscribers {
+		if namespace != "" && namespace != evt.Namespace {
+			continue
+		}
+		select {
+		case ch <- evt:
+		default:
+			b.Log.Info("subscriber too slow, dropping event", "frigate", evt.Name, "namespace", evt.Namespace)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Handler serves GET /streams/frigates?namespace=ns as text/event-stream.
+// Callers authenticate with a bearer token and must be allowed to watch
+// Frigates in the namespace, as if they were watching the API server
+type Handler struct {
+	Broker *Broker
+	// Client used for TokenReview and SubjectAccessReview
+	Client client.Client
+	Log    logr.Logger
+	// Heartbeat interval keeping idle connections open
+	Heartbeat time.Duration
+}
+
+var _ http.Handler = &Handler{}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+
+	user, err := h.authenticate(r.Context(), r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err = h.authorize(r.Context(), user, namespace); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events, cancel := h.Broker.Subscribe(namespace)
+	defer cancel()
+
+	heartbeat := h.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	h.Log.Info("stream opened", "user", user.Username, "namespace", namespace)
+	defer h.Log.Info("stream closed", "user", user.Username, "namespace", namespace)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// comments are ignored by EventSource clients
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(evt)
+			if err != nil {
+				h.Log.Error(err, "encoding event")
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", evt.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// authenticate validates the bearer token with a TokenReview
+func (h *Handler) authenticate(ctx context.Context, r *http.Request) (user authenticationv1.UserInfo, err error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" || token == r.Header.Get("Authorization") {
+		err = fmt.Errorf("bearer token required")
+		return
+	}
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	if err = h.Client.Create(ctx, review); err != nil {
+		return
+	}
+	if !review.Status.Authenticated {
+		err = fmt.Errorf("invalid token")
+		return
+	}
+	user = review.Status.User
+	return
+}
+
+// authorize checks the user can watch frigates in namespace
+func (h *Handler) authorize(ctx context.Context, user authenticationv1.UserInfo, namespace string) (err error) {
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "watch",
+				Group:     "ship.danielfbm.github.io",
+				Resource:  "frigates",
+			},
+		},
+	}
+	if err = h.Client.Create(ctx, review); err != nil {
+		return
+	}
+	if !review.Status.Allowed {
+		err = fmt.Errorf("user %q cannot watch frigates in namespace %q", user.Username, namespace)
+	}
+	return
+}
@@ -0,0 +1,153 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reviewClient answers TokenReviews and SubjectAccessReviews, users
+// are allowed to watch the namespace they are mapped to
+type reviewClient struct {
+	client.Client
+	tokens  map[string]string
+	allowed map[string]string
+}
+
+func (c *reviewClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		review.Status.User.Username, review.Status.Authenticated = c.tokens[review.Spec.Token]
+	case *authorizationv1.SubjectAccessReview:
+		namespace, ok := c.allowed[review.Spec.User]
+		review.Status.Allowed = ok && namespace == review.Spec.ResourceAttributes.Namespace
+	}
+	return nil
+}
+
+func testFrigate(namespace, name string, phase shipv1beta1.FrigatePhase) *shipv1beta1.Frigate {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     shipv1beta1.FrigateStatus{Phase: phase},
+	}
+}
+
+func TestHandlerRejects(t *testing.T) {
+	handler := &Handler{
+		Broker: &Broker{Log: logf.Log},
+		Client: &reviewClient{tokens: map[string]string{"secret": "alice"}, allowed: map[string]string{"alice": "blue"}},
+		Log:    logf.Log,
+	}
+	for _, test := range []struct {
+		name          string
+		authorization string
+		namespace     string
+		code          int
+	}{
+		{"no token", "", "blue", http.StatusUnauthorized},
+		{"not a bearer token", "Basic secret", "blue", http.StatusUnauthorized},
+		{"invalid token", "Bearer guess", "blue", http.StatusUnauthorized},
+		{"other namespace", "Bearer secret", "red", http.StatusForbidden},
+		{"all namespaces", "Bearer secret", "", http.StatusForbidden},
+	} {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/streams/frigates?namespace="+test.namespace, nil)
+		if test.authorization != "" {
+			req.Header.Set("Authorization", test.authorization)
+		}
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != test.code {
+			t.Errorf("%s: expected %d, got %d %s", test.name, test.code, recorder.Code, recorder.Body)
+		}
+	}
+}
+
+func TestHandlerStreamsEvents(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+	informers := &informertest.FakeInformers{Scheme: scheme}
+	informer, err := informers.FakeInformerFor(&shipv1beta1.Frigate{})
+	if err != nil {
+		t.Fatalf("should get informer: %v", err)
+	}
+	broker := &Broker{Cache: informers, Log: logf.Log}
+	// with a closed stop channel Start returns once the informer
+	// handlers are registered, they keep publishing afterwards
+	stop := make(chan struct{})
+	close(stop)
+	if err = broker.Start(stop); err != nil {
+		t.Fatalf("should start broker: %v", err)
+	}
+
+	server := httptest.NewServer(&Handler{
+		Broker:    broker,
+		Client:    &reviewClient{tokens: map[string]string{"secret": "alice"}, allowed: map[string]string{"alice": "blue"}},
+		Log:       logf.Log,
+		Heartbeat: time.Hour,
+	})
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/streams/frigates?namespace=blue", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("should open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %v", resp.StatusCode, resp.Header)
+	}
+	// the handler subscribes once the headers are sent
+	if err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		broker.lock.Lock()
+		defer broker.lock.Unlock()
+		return len(broker.subscribers) == 1, nil
+	}); err != nil {
+		t.Fatalf("handler should subscribe: %v", err)
+	}
+
+	// watch events of other namespaces and updates keeping the phase
+	// are not sent
+	informer.Add(testFrigate("red", "other", shipv1beta1.FrigatePending))
+	informer.Add(testFrigate("blue", "some", ""))
+	informer.Update(testFrigate("blue", "some", ""), testFrigate("blue", "some", ""))
+	informer.Update(testFrigate("blue", "some", ""), testFrigate("blue", "some", shipv1beta1.FrigateCompleted))
+	informer.Delete(testFrigate("blue", "some", shipv1beta1.FrigateCompleted))
+
+	reader := bufio.NewReader(resp.Body)
+	frame := func() string {
+		var lines []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("should read frame: %v", err)
+			}
+			if line == "\n" {
+				return strings.Join(lines, "")
+			}
+			lines = append(lines, line)
+		}
+	}
+	for _, expected := range []string{
+		"event: added\ndata: {\"type\":\"added\",\"namespace\":\"blue\",\"name\":\"some\"}\n",
+		"event: modified\ndata: {\"type\":\"modified\",\"namespace\":\"blue\",\"name\":\"some\",\"phase\":\"Completed\"}\n",
+		"event: deleted\ndata: {\"type\":\"deleted\",\"namespace\":\"blue\",\"name\":\"some\",\"phase\":\"Completed\"}\n",
+	} {
+		if got := frame(); got != expected {
+			t.Errorf("expected frame %q, got %q", expected, got)
+		}
+	}
+}
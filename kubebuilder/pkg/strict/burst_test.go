@@ -0,0 +1,84 @@
+package strict
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// burstSize is the number of Frigates applied at once by a large rollout
+const burstSize = 500
+
+// webhookTimeout is the default timeoutSeconds of a webhook configuration
+const webhookTimeout = 10 * time.Second
+
+func frigateRequest(i int) admission.Request {
+	raw := fmt.Sprintf(`{"apiVersion":"ship.danielfbm.github.io/v1beta1","kind":"Frigate","metadata":{"name":"frigate-%d"},"spec":{"foo":"bar","replicas":%d}}`, i, i%5)
+	return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+		Object: runtime.RawExtension{Raw: []byte(raw)},
+	}}
+}
+
+func newFrigateValidator() *Validator {
+	return &Validator{New: func() runtime.Object { return &shipv1beta1.Frigate{} }}
+}
+
+func TestValidatorBurst(t *testing.T) {
+	validator := newFrigateValidator()
+
+	// the API server sends every object of an apply in its own request,
+	// all of them should be answered well within the webhook timeout
+	start := time.Now()
+	var wait sync.WaitGroup
+	denied := make(chan string, burstSize)
+	for i := 0; i < burstSize; i++ {
+		wait.Add(1)
+		go func(i int) {
+			defer wait.Done()
+			if resp := validator.Handle(context.TODO(), frigateRequest(i)); !resp.Allowed {
+				denied <- fmt.Sprintf("frigate-%d: %v", i, resp.Result)
+			}
+		}(i)
+	}
+	wait.Wait()
+	close(denied)
+
+	for reason := range denied {
+		t.Errorf("valid frigate denied %s", reason)
+	}
+	if elapsed := time.Since(start); elapsed > webhookTimeout/10 {
+		t.Errorf("burst of %d admissions took %v, too close to the %v webhook timeout", burstSize, elapsed, webhookTimeout)
+	}
+}
+
+func BenchmarkValidator(b *testing.B) {
+	validator := newFrigateValidator()
+	b.Run("valid", func(b *testing.B) {
+		req := frigateRequest(1)
+		for i := 0; i < b.N; i++ {
+			validator.Handle(context.TODO(), req)
+		}
+	})
+	b.Run("unknown fields", func(b *testing.B) {
+		req := frigateRequest(1)
+		req.Object.Raw = []byte(`{"metadata":{"name":"a"},"spec":{"foo":"bar","replcas":3}}`)
+		for i := 0; i < b.N; i++ {
+			validator.Handle(context.TODO(), req)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			req := frigateRequest(1)
+			for pb.Next() {
+				validator.Handle(context.TODO(), req)
+			}
+		})
+	})
+}
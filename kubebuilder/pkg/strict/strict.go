@@ -0,0 +1,236 @@
+// Package strict rejects objects carrying fields unknown to the Go types.
+//
+// Structural schemas prune unknown fields silently, so a typo like
+// `replcas: 3` is accepted and simply disappears. The Validator decodes
+// the raw admission JSON into the typed object, encodes it again and
+// reports every field of spec that did not survive the round trip.
+// Objects implementing SpecValidator are then checked for the rules
+// their schema cannot express, unless the request leaves the spec as it
+// was or the object is being deleted, and on updates changing the spec
+// of a live object those implementing UpdateValidator are compared with
+// the old object. Admitted objects
+// implementing Warner return their deprecation notices as warnings.
+package strict
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/danielfbm/k8s-design-workshop/controller/pkg/warning"
+)
+
+// Validator is a validating admission handler for a single kind
+type Validator struct {
+	// Path the webhook is served on
+	Path string
+	// New returns an empty object of the validated kind
+	New func() runtime.Object
+	// Versions return empty objects of the other versions of the kind,
+	// requests of those versions are decoded into them
+	Versions map[string]func() runtime.Object
+	// Fields are the top level fields that are checked, defaults to spec
+	Fields []string
+}
+
+var (
+	_ admission.Handler = &Validator{}
+	_ warning.Handler   = &Validator{}
+)
+
+// SpecValidator is implemented by kinds with rules spanning several fields
+type SpecValidator interface {
+	ValidateSpec() field.ErrorList
+}
+
+// UpdateValidator is implemented by kinds with fields that cannot change
+// after creation. old is the stored object, of the same type
+type UpdateValidator interface {
+	ValidateUpdate(old runtime.Object) field.ErrorList
+}
+
+// Warner is implemented by kinds with deprecated fields, Warnings
+// returns a notice for each one set
+type Warner interface {
+	Warnings() []string
+}
+
+// SetupWebhookWithManager registers the validator on the manager webhook server
+func (v *Validator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	mgr.GetWebhookServer().Register(v.Path, &warning.Webhook{Handler: v})
+	return nil
+}
+
+// Handle implements admission.Handler
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	resp, _ := v.HandleWithWarnings(ctx, req)
+	return resp
+}
+
+// HandleWithWarnings implements warning.Handler
+func (v *Validator) HandleWithWarnings(ctx context.Context, req admission.Request) (admission.Response, []string) {
+	if len(req.Object.Raw) == 0 {
+		// deletes carry no object
+		return admission.Allowed(""), nil
+	}
+	obj := v.newObject(req)
+	resp := v.validate(req, obj)
+	if warner, ok := obj.(Warner); ok && resp.Allowed {
+		return resp, warner.Warnings()
+	}
+	return resp, nil
+}
+
+// validate decodes the request object into obj and checks it
+func (v *Validator) validate(req admission.Request, obj runtime.Object) admission.Response {
+	unknown, err := UnknownFields(req.Object.Raw, obj, v.fields()...)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if len(unknown) > 0 {
+		return admission.Denied(fmt.Sprintf("unknown fields: %s", strings.Join(unknown, ", ")))
+	}
+	if validator, ok := obj.(SpecValidator); ok && !v.settled(req, obj) {
+		if errs := validator.ValidateSpec(); len(errs) > 0 {
+			return admission.Denied(errs.ToAggregate().Error())
+		}
+	}
+	if validator, ok := obj.(UpdateValidator); ok && req.Operation == admissionv1beta1.Update && len(req.OldObject.Raw) > 0 && !v.settled(req, obj) {
+		old := v.newObject(req)
+		if err = json.Unmarshal(req.OldObject.Raw, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if errs := validator.ValidateUpdate(old); len(errs) > 0 {
+			return admission.Denied(errs.ToAggregate().Error())
+		}
+	}
+	return admission.Allowed("")
+}
+
+// settled returns true when obj is being deleted or the update leaves the
+// checked fields as they were. Objects stored before a rule existed keep
+// getting metadata patches, i.e. their finalizers removed, which must
+// not be denied
+func (v *Validator) settled(req admission.Request, obj runtime.Object) bool {
+	if accessor, err := meta.Accessor(obj); err == nil && accessor.GetDeletionTimestamp() != nil {
+		return true
+	}
+	if req.Operation != admissionv1beta1.Update || len(req.OldObject.Raw) == 0 {
+		return false
+	}
+	var object, old map[string]interface{}
+	if json.Unmarshal(req.Object.Raw, &object) != nil || json.Unmarshal(req.OldObject.Raw, &old) != nil {
+		return false
+	}
+	for _, field := range v.fields() {
+		if !reflect.DeepEqual(object[field], old[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// newObject returns an empty object of the version of req
+func (v *Validator) newObject(req admission.Request) runtime.Object {
+	if newVersion, ok := v.Versions[req.Kind.Version]; ok {
+		return newVersion()
+	}
+	return v.New()
+}
+
+func (v *Validator) fields() []string {
+	if len(v.Fields) == 0 {
+		return []string{"spec"}
+	}
+	return v.Fields
+}
+
+// UnknownFields returns the sorted paths of fields in raw, below the given
+// top level fields, that are not part of obj
+func UnknownFields(raw []byte, obj runtime.Object, fields ...string) (unknown []string, err error) {
+	// only a strict decode can tell a typo from an empty omitempty field
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if decoder.Decode(obj) == nil {
+		return
+	}
+	if err = json.Unmarshal(raw, obj); err != nil {
+		return
+	}
+	roundTrip, err := json.Marshal(obj)
+	if err != nil {
+		return
+	}
+	var original, kept map[string]interface{}
+	if err = json.Unmarshal(raw, &original); err != nil {
+		return
+	}
+	if err = json.Unmarshal(roundTrip, &kept); err != nil {
+		return
+	}
+	for _, field := range fields {
+		unknown = append(unknown, missing(field, original[field], kept[field])...)
+	}
+	sort.Strings(unknown)
+	return
+}
+
+// missing walks original and returns the paths not present in kept
+func missing(path string, original, kept interface{}) (paths []string) {
+	switch value := original.(type) {
+	case map[string]interface{}:
+		keptMap, _ := kept.(map[string]interface{})
+		for key, child := range value {
+			keptChild, ok := keptMap[key]
+			if !ok {
+				if !isEmpty(child) {
+					paths = append(paths, path+"."+key)
+				}
+				continue
+			}
+			paths = append(paths, missing(path+"."+key, child, keptChild)...)
+		}
+	case []interface{}:
+		keptList, _ := kept.([]interface{})
+		for i, child := range value {
+			var keptChild interface{}
+			if i < len(keptList) {
+				keptChild = keptList[i]
+			}
+			paths = append(paths, missing(fmt.Sprintf("%s[%d]", path, i), child, keptChild)...)
+		}
+	}
+	return
+}
+
+// isEmpty returns true for values omitempty drops when encoding,
+// those are most likely known fields and not typos
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
@@ -0,0 +1,245 @@
+package strict
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	shipv1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1"
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestUnknownFields(t *testing.T) {
+	table := []struct {
+		name     string
+		raw      string
+		expected []string
+	}{
+		{
+			name: "known fields",
+			raw:  `{"apiVersion":"ship.danielfbm.github.io/v1beta1","kind":"Frigate","metadata":{"name":"a"},"spec":{"foo":"bar"}}`,
+		},
+		{
+			name:     "typo in spec",
+			raw:      `{"metadata":{"name":"a"},"spec":{"foo":"bar","replcas":3}}`,
+			expected: []string{"spec.replcas"},
+		},
+		{
+			name:     "nested unknown fields",
+			raw:      `{"spec":{"fo":"bar","engine":{"power":1}}}`,
+			expected: []string{"spec.engine", "spec.fo"},
+		},
+		{
+			name: "unknown fields outside spec are ignored",
+			raw:  `{"spec":{"foo":""},"extra":true}`,
+		},
+	}
+	for _, test := range table {
+		unknown, err := UnknownFields([]byte(test.raw), &shipv1beta1.Frigate{}, "spec")
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(unknown, test.expected) {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, unknown)
+		}
+	}
+}
+
+func TestValidatorSpecRules(t *testing.T) {
+	validator := newFrigateValidator()
+	table := []struct {
+		raw     string
+		allowed bool
+	}{
+		{raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","replicas":2}}`, allowed: true},
+		{raw: `{"metadata":{"name":"a"},"spec":{"replicas":0}}`, allowed: true},
+		{raw: `{"metadata":{"name":"a"},"spec":{"replicas":2}}`},
+		// replicas defaults to 1
+		{raw: `{"metadata":{"name":"a"},"spec":{}}`},
+		{raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","configRef":{"kind":"Secret","name":"creds"}}}`},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Object.Raw = []byte(test.raw)
+		if resp := validator.Handle(context.TODO(), req); resp.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed %v got %+v", test.raw, test.allowed, resp.Result)
+		}
+	}
+}
+
+func TestValidatorSpecRulesOnChanges(t *testing.T) {
+	validator := newFrigateValidator()
+	// stored before foo was required
+	old := `{"metadata":{"name":"a","finalizers":["ship.danielfbm.github.io/tombstone"]},"spec":{"replicas":2}}`
+	table := []struct {
+		name    string
+		raw     string
+		allowed bool
+	}{
+		{
+			name:    "metadata only",
+			raw:     `{"metadata":{"name":"a","labels":{"team":"blue"},"finalizers":["ship.danielfbm.github.io/tombstone"]},"spec":{"replicas":2}}`,
+			allowed: true,
+		},
+		{
+			name:    "finalizer removed while deleting",
+			raw:     `{"metadata":{"name":"a","deletionTimestamp":"2020-01-02T03:04:05Z"},"spec":{"replicas":2}}`,
+			allowed: true,
+		},
+		{
+			name: "spec changed",
+			raw:  `{"metadata":{"name":"a","finalizers":["ship.danielfbm.github.io/tombstone"]},"spec":{"replicas":3}}`,
+		},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Operation = admissionv1beta1.Update
+		req.Object.Raw = []byte(test.raw)
+		req.OldObject.Raw = []byte(old)
+		if resp := validator.Handle(context.TODO(), req); resp.Allowed != test.allowed {
+			t.Errorf("%s: expected allowed %v got %+v", test.name, test.allowed, resp.Result)
+		}
+	}
+}
+
+func TestValidatorImmutableFields(t *testing.T) {
+	validator := newFrigateValidator()
+	old := `{"metadata":{"name":"a"},"spec":{"foo":"bar","harborRef":{"name":"north"}}}`
+	table := []struct {
+		operation admissionv1beta1.Operation
+		raw       string
+		allowed   bool
+	}{
+		{operation: admissionv1beta1.Update, raw: `{"metadata":{"name":"a"},"spec":{"foo":"baz","harborRef":{"name":"north"}}}`, allowed: true},
+		{operation: admissionv1beta1.Update, raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","harborRef":{"name":"south"}}}`},
+		{operation: admissionv1beta1.Update, raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","frigateClassName":"fast","harborRef":{"name":"north"}}}`},
+		// creates have no old object to compare with
+		{operation: admissionv1beta1.Create, raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","harborRef":{"name":"south"}}}`, allowed: true},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Operation = test.operation
+		req.Object.Raw = []byte(test.raw)
+		if test.operation == admissionv1beta1.Update {
+			req.OldObject.Raw = []byte(old)
+		}
+		resp := validator.Handle(context.TODO(), req)
+		if resp.Allowed != test.allowed {
+			t.Errorf("%s %s: expected allowed %v got %+v", test.operation, test.raw, test.allowed, resp.Result)
+		}
+		if !resp.Allowed && !strings.Contains(string(resp.Result.Reason)+resp.Result.Message, "immutable") {
+			t.Errorf("%s: expected an immutable field message, got %+v", test.raw, resp.Result)
+		}
+	}
+}
+
+func TestValidatorImmutableFieldsOnMetadataUpdates(t *testing.T) {
+	validator := newFrigateValidator()
+	old := `{"metadata":{"name":"a","finalizers":["ship.danielfbm.github.io/tombstone"]},"spec":{"foo":"bar","harborRef":{"name":"north"}}}`
+	table := []struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "labels added",
+			raw:  `{"metadata":{"name":"a","labels":{"team":"blue"},"finalizers":["ship.danielfbm.github.io/tombstone"]},"spec":{"foo":"bar","harborRef":{"name":"north"}}}`,
+		},
+		{
+			// the Harbor was moved by an older controller version
+			name: "finalizer removed while deleting",
+			raw:  `{"metadata":{"name":"a","deletionTimestamp":"2020-01-02T03:04:05Z"},"spec":{"foo":"bar","harborRef":{"name":"south"}}}`,
+		},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Operation = admissionv1beta1.Update
+		req.Object.Raw = []byte(test.raw)
+		req.OldObject.Raw = []byte(old)
+		if resp := validator.Handle(context.TODO(), req); !resp.Allowed {
+			t.Errorf("%s: should be allowed, got %+v", test.name, resp.Result)
+		}
+	}
+}
+
+func TestValidatorWarnings(t *testing.T) {
+	validator := newFrigateValidator()
+	table := []struct {
+		raw      string
+		warnings int
+	}{
+		{raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","replicas":2}}`, warnings: 1},
+		{raw: `{"metadata":{"name":"a"},"spec":{"replicas":0}}`},
+		// denied requests only carry the denial
+		{raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","replcas":2}}`},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Object.Raw = []byte(test.raw)
+		if _, warnings := validator.HandleWithWarnings(context.TODO(), req); len(warnings) != test.warnings {
+			t.Errorf("%s: expected %d warnings got %v", test.raw, test.warnings, warnings)
+		}
+	}
+}
+
+func newVersionedFrigateValidator() *Validator {
+	validator := newFrigateValidator()
+	validator.Versions = map[string]func() runtime.Object{
+		"v1": func() runtime.Object { return &shipv1.Frigate{} },
+	}
+	return validator
+}
+
+func TestValidatorV1SpecRules(t *testing.T) {
+	validator := newVersionedFrigateValidator()
+	table := []struct {
+		raw     string
+		allowed bool
+	}{
+		{raw: `{"metadata":{"name":"a"},"spec":{"callsign":"bar","replicas":2}}`, allowed: true},
+		{raw: `{"metadata":{"name":"a"},"spec":{"replicas":2}}`},
+		// foo was renamed, it is unknown in v1
+		{raw: `{"metadata":{"name":"a"},"spec":{"foo":"bar","replicas":2}}`},
+		{raw: `{"metadata":{"name":"a"},"spec":{"callsign":"bar","configRef":{"kind":"Secret","name":"creds"}}}`},
+		{raw: `{"metadata":{"name":"a"},"spec":{"expose":{"loadBalancerSourceRanges":["203.0.113.0/24"],"ports":[{"port":80}]}}}`},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Kind = metav1.GroupVersionKind{Group: shipv1.GroupVersion.Group, Version: "v1", Kind: "Frigate"}
+		req.Object.Raw = []byte(test.raw)
+		if resp := validator.Handle(context.TODO(), req); resp.Allowed != test.allowed {
+			t.Errorf("v1 %s: expected allowed %v got %+v", test.raw, test.allowed, resp.Result)
+		}
+	}
+}
+
+func TestValidatorV1ImmutableFields(t *testing.T) {
+	validator := newVersionedFrigateValidator()
+	old := `{"metadata":{"name":"a"},"spec":{"callsign":"bar","frigateClassName":"fast","harborRef":{"name":"north"}}}`
+	table := []struct {
+		raw     string
+		allowed bool
+	}{
+		{raw: `{"metadata":{"name":"a"},"spec":{"callsign":"baz","frigateClassName":"fast","harborRef":{"name":"north"}}}`, allowed: true},
+		{raw: `{"metadata":{"name":"a"},"spec":{"callsign":"bar","frigateClassName":"fast","harborRef":{"name":"south"}}}`},
+		{raw: `{"metadata":{"name":"a"},"spec":{"callsign":"bar","frigateClassName":"slow","harborRef":{"name":"north"}}}`},
+	}
+	for _, test := range table {
+		req := frigateRequest(0)
+		req.Kind = metav1.GroupVersionKind{Group: shipv1.GroupVersion.Group, Version: "v1", Kind: "Frigate"}
+		req.Operation = admissionv1beta1.Update
+		req.Object.Raw = []byte(test.raw)
+		req.OldObject.Raw = []byte(old)
+		resp := validator.Handle(context.TODO(), req)
+		if resp.Allowed != test.allowed {
+			t.Errorf("v1 %s: expected allowed %v got %+v", test.raw, test.allowed, resp.Result)
+		}
+		if !resp.Allowed && !strings.Contains(string(resp.Result.Reason)+resp.Result.Message, "immutable") {
+			t.Errorf("v1 %s: expected an immutable field message, got %+v", test.raw, resp.Result)
+		}
+	}
+}
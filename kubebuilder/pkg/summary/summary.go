@@ -0,0 +1,123 @@
+// Package summary serves a read-only overview of ship resources
+// straight from the manager cache.
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// Summary is the document served on /summary
+type Summary struct {
+	// Leader is true when the replica serving the request is reconciling
+	Leader bool `json:"leader"`
+	// Frigates counts by namespace and phase
+	Frigates FrigateSummary `json:"frigates"`
+}
+
+// FrigateSummary counts Frigates
+type FrigateSummary struct {
+	Total      int                       `json:"total"`
+	Phases     map[string]int            `json:"phases"`
+	Namespaces map[string]map[string]int `json:"namespaces"`
+}
+
+// Server serves read-only endpoints. It runs on every replica
+// so followers with a warm cache can answer as well as the leader
+type Server struct {
+	// Addr to listen on, i.e ":8081"
+	Addr string
+	// Reader should be the manager cache
+	Reader client.Reader
+	// IsLeader reports the leader election state, optional
+	IsLeader func() bool
+	Log      logr.Logger
+
+	mux *http.ServeMux
+}
+
+var _ manager.Runnable = &Server{}
+var _ manager.LeaderElectionRunnable = &Server{}
+
+// Handle registers an extra read-only handler on the server.
+// Must be called before the manager starts
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.init()
+	s.mux.Handle(pattern, handler)
+}
+
+func (s *Server) init() {
+	if s.mux == nil {
+		s.mux = http.NewServeMux()
+		s.mux.HandleFunc("/summary", s.serveSummary)
+	}
+}
+
+// Start implements manager.Runnable
+func (s *Server) Start(stop <-chan struct{}) error {
+	s.init()
+	listener, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: s.mux}
+	go func() {
+		<-stop
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			s.Log.Error(err, "shutting down summary server")
+		}
+	}()
+	s.Log.Info("serving summary", "addr", listener.Addr().String())
+	if err = server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) serveSummary(w http.ResponseWriter, r *http.Request) {
+	frigates := &shipv1beta1.FrigateList{}
+	if err := s.Reader.List(r.Context(), frigates, client.InNamespace(r.URL.Query().Get("namespace"))); err != nil {
+		s.Log.Error(err, "listing frigates")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	summary := Summary{
+		Frigates: FrigateSummary{
+			Total:      len(frigates.Items),
+			Phases:     map[string]int{},
+			Namespaces: map[string]map[string]int{},
+		},
+	}
+	if s.IsLeader != nil {
+		summary.Leader = s.IsLeader()
+	}
+	for _, f := range frigates.Items {
+		phase := string(f.Status.Phase)
+		summary.Frigates.Phases[phase]++
+		if summary.Frigates.Namespaces[f.Namespace] == nil {
+			summary.Frigates.Namespaces[f.Namespace] = map[string]int{}
+		}
+		summary.Frigates.Namespaces[f.Namespace][phase]++
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		s.Log.Error(err, "writing summary")
+	}
+}
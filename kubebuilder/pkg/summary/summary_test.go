@@ -0,0 +1,86 @@
+package summary
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func frigate(namespace, name string, phase shipv1beta1.FrigatePhase) runtime.Object {
+	return &shipv1beta1.Frigate{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     shipv1beta1.FrigateStatus{Phase: phase},
+	}
+}
+
+func TestSummary(t *testing.T) {
+	scheme := runtime.NewScheme()
+	shipv1beta1.AddToScheme(scheme)
+
+	leader := false
+	server := &Server{
+		Reader: fake.NewFakeClientWithScheme(scheme,
+			frigate("blue", "a", shipv1beta1.FrigateCompleted),
+			frigate("blue", "b", shipv1beta1.FrigateCompleted),
+			frigate("blue", "c", shipv1beta1.FrigateFailure),
+			frigate("red", "d", shipv1beta1.FrigateCompleted),
+			frigate("red", "e", ""),
+		),
+		IsLeader: func() bool { return leader },
+		Log:      logf.Log,
+	}
+	server.init()
+	get := func(url string) Summary {
+		recorder := httptest.NewRecorder()
+		server.mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url, nil))
+		if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("unexpected response %d %v: %s", recorder.Code, recorder.Header(), recorder.Body)
+		}
+		summary := Summary{}
+		if err := json.NewDecoder(recorder.Body).Decode(&summary); err != nil {
+			t.Fatalf("should decode summary: %v", err)
+		}
+		return summary
+	}
+
+	// 1. Frigates are counted by phase and namespace
+	expected := Summary{
+		Frigates: FrigateSummary{
+			Total:  5,
+			Phases: map[string]int{"Completed": 3, "Failure": 1, "": 1},
+			Namespaces: map[string]map[string]int{
+				"blue": {"Completed": 2, "Failure": 1},
+				"red":  {"Completed": 1, "": 1},
+			},
+		},
+	}
+	if got := get("/summary"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	// 2. a single namespace can be requested
+	expected = Summary{
+		Frigates: FrigateSummary{
+			Total:      2,
+			Phases:     map[string]int{"Completed": 1, "": 1},
+			Namespaces: map[string]map[string]int{"red": {"Completed": 1, "": 1}},
+		},
+	}
+	if got := get("/summary?namespace=red"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+
+	// 3. the leader flag follows the election
+	leader = true
+	if got := get("/summary?namespace=red"); !got.Leader {
+		t.Errorf("expected the leader flag once elected")
+	}
+}
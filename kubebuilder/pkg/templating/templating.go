@@ -0,0 +1,170 @@
+// Package templating resolves ${var} placeholders in selected fields at
+// admission time.
+//
+// Variables are read from the ship-variables ConfigMap in the namespace
+// of the object, so the same manifest can be applied to different
+// environments without external templating tools. Objects referencing a
+// variable that cannot be resolved are rejected instead of being stored
+// with the placeholder.
+package templating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// VariablesConfigMap is the ConfigMap holding the variables of a namespace
+const VariablesConfigMap = "ship-variables"
+
+// placeholder matches ${name}
+var placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+// Mutator is a mutating admission handler that resolves placeholders
+type Mutator struct {
+	// Path the webhook is served on
+	Path string
+	// Client used to read the variables ConfigMap
+	Client client.Reader
+	// Fields are the dotted paths of the string fields that are templated
+	Fields []string
+	// Versions are the templated fields of the other versions of the
+	// kind, requests of those versions use them instead of Fields
+	Versions map[string][]string
+}
+
+var _ admission.Handler = &Mutator{}
+
+// SetupWebhookWithManager registers the mutator on the manager webhook server
+func (m *Mutator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if m.Client == nil {
+		m.Client = mgr.GetClient()
+	}
+	mgr.GetWebhookServer().Register(m.Path, &webhook.Admission{Handler: m})
+	return nil
+}
+
+// Handle implements admission.Handler
+func (m *Mutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if len(req.Object.Raw) == 0 {
+		return admission.Allowed("")
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(req.Object.Raw, &obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	fields := m.fields(req)
+	if !templated(obj, fields) {
+		return admission.Allowed("")
+	}
+
+	vars, err := m.variables(ctx, req.Namespace)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	var unresolved []string
+	for _, field := range fields {
+		value, ok := lookup(obj, field)
+		if !ok {
+			continue
+		}
+		resolved, missing := Resolve(value, vars)
+		for _, name := range missing {
+			unresolved = append(unresolved, fmt.Sprintf("%s: ${%s}", field, name))
+		}
+		set(obj, field, resolved)
+	}
+	if len(unresolved) > 0 {
+		sort.Strings(unresolved)
+		return admission.Denied(fmt.Sprintf("unresolved variables, add them to ConfigMap %s/%s: %s",
+			req.Namespace, VariablesConfigMap, strings.Join(unresolved, ", ")))
+	}
+
+	mutated, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, mutated)
+}
+
+// fields returns the templated fields of the version of req
+func (m *Mutator) fields(req admission.Request) []string {
+	if fields, ok := m.Versions[req.Kind.Version]; ok {
+		return fields
+	}
+	return m.Fields
+}
+
+// templated returns true when any of fields has a placeholder
+// so the ConfigMap is only read when needed
+func templated(obj map[string]interface{}, fields []string) bool {
+	for _, field := range fields {
+		if value, ok := lookup(obj, field); ok && placeholder.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// variables returns the variables of a namespace, empty when
+// the ConfigMap does not exist
+func (m *Mutator) variables(ctx context.Context, namespace string) (map[string]string, error) {
+	configMap := &corev1.ConfigMap{}
+	err := m.Client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: VariablesConfigMap}, configMap)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return configMap.Data, err
+}
+
+// Resolve replaces the placeholders of value with vars and returns
+// the names of the variables that were not found
+func Resolve(value string, vars map[string]string) (resolved string, missing []string) {
+	seen := map[string]bool{}
+	resolved = placeholder.ReplaceAllStringFunc(value, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		if !seen[name] {
+			seen[name] = true
+			missing = append(missing, name)
+		}
+		return match
+	})
+	return
+}
+
+// lookup returns the string at a dotted path
+func lookup(obj map[string]interface{}, path string) (string, bool) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := obj[part].(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		obj = child
+	}
+	value, ok := obj[parts[len(parts)-1]].(string)
+	return value, ok
+}
+
+// set writes the string at a dotted path found by lookup
+func set(obj map[string]interface{}, path, value string) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		obj = obj[part].(map[string]interface{})
+	}
+	obj[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,89 @@
+package templating
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestResolve(t *testing.T) {
+	vars := map[string]string{"env": "prod", "region": "eu"}
+	table := []struct {
+		value    string
+		expected string
+		missing  []string
+	}{
+		{value: "plain", expected: "plain"},
+		{value: "${env}-${region}", expected: "prod-eu"},
+		{value: "${env}-${zone}-${zone}", expected: "prod-${zone}-${zone}", missing: []string{"zone"}},
+	}
+	for _, test := range table {
+		resolved, missing := Resolve(test.value, vars)
+		if resolved != test.expected || !reflect.DeepEqual(missing, test.missing) {
+			t.Errorf("%q: expected %q %v got %q %v", test.value, test.expected, test.missing, resolved, missing)
+		}
+	}
+}
+
+func TestMutator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	corev1.AddToScheme(scheme)
+	variables := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: VariablesConfigMap, Namespace: "staging"},
+		Data:       map[string]string{"env": "staging"},
+	}
+	mutator := &Mutator{
+		Client:   fake.NewFakeClientWithScheme(scheme, variables),
+		Fields:   []string{"spec.foo"},
+		Versions: map[string][]string{"v1": {"spec.callsign"}},
+	}
+	request := func(namespace, raw string) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Kind:      metav1.GroupVersionKind{Group: "ship.danielfbm.github.io", Version: "v1beta1", Kind: "Frigate"},
+			Namespace: namespace,
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		}}
+	}
+
+	// 1. placeholders are resolved with a patch
+	resp := mutator.Handle(context.TODO(), request("staging", `{"metadata":{"name":"a"},"spec":{"foo":"frigate-${env}"}}`))
+	if !resp.Allowed || len(resp.Patches) != 1 || resp.Patches[0].Path != "/spec/foo" || resp.Patches[0].Value != "frigate-staging" {
+		t.Errorf("should patch spec.foo, got %+v", resp)
+	}
+
+	// 2. objects without placeholders are left alone
+	resp = mutator.Handle(context.TODO(), request("staging", `{"metadata":{"name":"a"},"spec":{"foo":"frigate"}}`))
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("should allow without patches, got %+v", resp)
+	}
+
+	// 3. unresolved variables are rejected, also without a ConfigMap
+	resp = mutator.Handle(context.TODO(), request("staging", `{"metadata":{"name":"a"},"spec":{"foo":"${region}"}}`))
+	if resp.Allowed {
+		t.Errorf("should deny unresolved variables")
+	}
+	resp = mutator.Handle(context.TODO(), request("prod", `{"metadata":{"name":"a"},"spec":{"foo":"${env}"}}`))
+	if resp.Allowed {
+		t.Errorf("should deny when the namespace has no variables")
+	}
+
+	// 4. v1 requests template callsign, foo was renamed
+	req := request("staging", `{"metadata":{"name":"a"},"spec":{"callsign":"frigate-${env}"}}`)
+	req.Kind.Version = "v1"
+	resp = mutator.Handle(context.TODO(), req)
+	if !resp.Allowed || len(resp.Patches) != 1 || resp.Patches[0].Path != "/spec/callsign" || resp.Patches[0].Value != "frigate-staging" {
+		t.Errorf("should patch spec.callsign, got %+v", resp)
+	}
+	req = request("staging", `{"metadata":{"name":"a"},"spec":{"callsign":"${region}"}}`)
+	req.Kind.Version = "v1"
+	if resp = mutator.Handle(context.TODO(), req); resp.Allowed {
+		t.Errorf("should deny unresolved variables of v1")
+	}
+}
@@ -0,0 +1,53 @@
+// Package trigger lets subsystems outside a controller, like registry
+// callbacks or gateway actions, enqueue objects for reconciliation right
+// away instead of waiting for the next requeue.
+package trigger
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Object is a stub of the kind reconciled by the controller
+type Object interface {
+	metav1.Object
+	runtime.Object
+}
+
+// Channel feeds a source.Channel watched by a controller
+type Channel struct {
+	// New returns an empty object of the reconciled kind
+	New func() Object
+
+	events chan event.GenericEvent
+}
+
+// NewChannel returns a Channel buffering up to buffer triggers
+func NewChannel(buffer int, newObject func() Object) *Channel {
+	return &Channel{New: newObject, events: make(chan event.GenericEvent, buffer)}
+}
+
+// Source to pass to Watches, together with handler.EnqueueRequestForObject.
+// It must be watched by a single controller
+func (c *Channel) Source() source.Source {
+	return &source.Channel{Source: c.events}
+}
+
+// Enqueue asks for key to be reconciled. It blocks while the buffer is
+// full, until ctx is done
+func (c *Channel) Enqueue(ctx context.Context, key types.NamespacedName) error {
+	obj := c.New()
+	obj.SetNamespace(key.Namespace)
+	obj.SetName(key.Name)
+	select {
+	case c.events <- event.GenericEvent{Meta: obj, Object: obj}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
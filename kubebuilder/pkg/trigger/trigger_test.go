@@ -0,0 +1,54 @@
+package trigger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	shipv1beta1 "github.com/danielfbm/k8s-design-workshop/controller/api/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+func TestChannel(t *testing.T) {
+	triggers := NewChannel(1, func() Object { return &shipv1beta1.Frigate{} })
+	src := triggers.Source().(*source.Channel)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	if err := src.InjectStopChannel(stop); err != nil {
+		t.Fatalf("should inject stop channel: %v", err)
+	}
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+	if err := src.Start(&handler.EnqueueRequestForObject{}, queue); err != nil {
+		t.Fatalf("should start source: %v", err)
+	}
+
+	key := types.NamespacedName{Namespace: "default", Name: "some"}
+	begin := time.Now()
+	if err := triggers.Enqueue(context.TODO(), key); err != nil {
+		t.Fatalf("should enqueue: %v", err)
+	}
+	item, _ := queue.Get()
+	if req, ok := item.(reconcile.Request); !ok || req.NamespacedName != key {
+		t.Errorf("expected a request for %s got %v", key, item)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("trigger should wake the controller promptly, took %s", elapsed)
+	}
+
+	// a full buffer does not block forever
+	full := NewChannel(1, func() Object { return &shipv1beta1.Frigate{} })
+	if err := full.Enqueue(context.TODO(), key); err != nil {
+		t.Fatalf("should enqueue: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := full.Enqueue(ctx, key); err == nil {
+		t.Errorf("enqueue should fail once the context is done")
+	}
+}
@@ -0,0 +1,72 @@
+// Package warning serves admission handlers that return warnings along
+// with their response.
+//
+// Warnings are shown by kubectl and client-go without failing the
+// request, i.e. to announce a field is deprecated. The Kubernetes
+// libraries this project builds with predate the warnings field of the
+// AdmissionReview response, so Webhook adds it to the JSON itself. API
+// servers older than 1.19 ignore it.
+package warning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Handler is an admission handler that also returns warnings
+type Handler interface {
+	HandleWithWarnings(ctx context.Context, req admission.Request) (admission.Response, []string)
+}
+
+// Webhook serves a Handler, it replaces webhook.Admission for handlers
+// with warnings
+type Webhook struct {
+	Handler Handler
+}
+
+var _ http.Handler = &Webhook{}
+
+// review is an AdmissionReview with the warnings of newer API servers
+type review struct {
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Kind       string   `json:"kind,omitempty"`
+	Response   response `json:"response"`
+}
+
+type response struct {
+	admissionv1beta1.AdmissionResponse
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ServeHTTP implements http.Handler
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "application/json" {
+		http.Error(w, fmt.Sprintf("contentType=%s, expected application/json", contentType), http.StatusBadRequest)
+		return
+	}
+	in := admissionv1beta1.AdmissionReview{}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Request == nil {
+		http.Error(w, fmt.Sprintf("invalid AdmissionReview: %v", err), http.StatusBadRequest)
+		return
+	}
+	req := admission.Request{AdmissionRequest: *in.Request}
+	resp, warnings := wh.Handler.HandleWithWarnings(r.Context(), req)
+	if err := resp.Complete(req); err != nil {
+		resp = admission.Errored(http.StatusInternalServerError, err)
+		resp.UID = req.UID
+	}
+	out := review{
+		APIVersion: in.APIVersion,
+		Kind:       in.Kind,
+		Response:   response{AdmissionResponse: resp.AdmissionResponse, Warnings: warnings},
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
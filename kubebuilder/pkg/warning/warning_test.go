@@ -0,0 +1,52 @@
+package warning
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+type handler []string
+
+func (h handler) HandleWithWarnings(ctx context.Context, req admission.Request) (admission.Response, []string) {
+	return admission.Allowed(""), h
+}
+
+func TestWebhookWarnings(t *testing.T) {
+	webhook := &Webhook{Handler: handler{"spec.foo is deprecated"}}
+	body, _ := json.Marshal(admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{UID: "42", Operation: admissionv1beta1.Create},
+	})
+	req := httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, req)
+
+	out := struct {
+		Response struct {
+			UID      string   `json:"uid"`
+			Allowed  bool     `json:"allowed"`
+			Warnings []string `json:"warnings"`
+		} `json:"response"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &out); err != nil {
+		t.Fatalf("should decode %s: %v", recorder.Body.String(), err)
+	}
+	if out.Response.UID != "42" || !out.Response.Allowed || !reflect.DeepEqual(out.Response.Warnings, []string{"spec.foo is deprecated"}) {
+		t.Errorf("expected an allowed response with the warning, got %s", recorder.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body))
+	recorder = httptest.NewRecorder()
+	webhook.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("expected a bad request without content type, got %d", recorder.Code)
+	}
+}